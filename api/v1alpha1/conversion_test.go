@@ -0,0 +1,218 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/randfill"
+)
+
+const fuzzIterations = 1000
+
+func TestConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoke func() conversion.Convertible
+		hub   func() conversion.Hub
+	}{
+		{"Region", func() conversion.Convertible { return &Region{} }, func() conversion.Hub { return &v1beta1.Region{} }},
+		{"Project", func() conversion.Convertible { return &Project{} }, func() conversion.Hub { return &v1beta1.Project{} }},
+		{"User", func() conversion.Convertible { return &User{} }, func() conversion.Hub { return &v1beta1.User{} }},
+		{"UserProjectBinding", func() conversion.Convertible { return &UserProjectBinding{} }, func() conversion.Hub { return &v1beta1.UserProjectBinding{} }},
+	}
+
+	f := newFuzzer(t)
+	for _, k := range tests {
+		t.Run(k.name+" spoke-hub-spoke", func(t *testing.T) {
+			for i := 0; i < fuzzIterations; i++ {
+				spoke := k.spoke()
+				f.Fill(spoke)
+
+				hub := k.hub()
+				if err := spoke.ConvertTo(hub); err != nil {
+					t.Fatalf("failed to convert to hub: %v", err)
+				}
+				restored := k.spoke()
+				if err := restored.ConvertFrom(hub); err != nil {
+					t.Fatalf("failed to convert from hub: %v", err)
+				}
+
+				if !equality.Semantic.DeepEqual(spoke, restored) {
+					t.Fatalf("round trip changed the object:\n%s", diff.ObjectReflectDiff(spoke, restored))
+				}
+			}
+		})
+
+		t.Run(k.name+" hub-spoke-hub", func(t *testing.T) {
+			for i := 0; i < fuzzIterations; i++ {
+				hub := k.hub()
+				f.Fill(hub)
+
+				spoke := k.spoke()
+				if err := spoke.ConvertFrom(hub); err != nil {
+					t.Fatalf("failed to convert from hub: %v", err)
+				}
+				restored := k.hub()
+				if err := spoke.ConvertTo(restored); err != nil {
+					t.Fatalf("failed to convert to hub: %v", err)
+				}
+
+				if !equality.Semantic.DeepEqual(hub, restored) {
+					t.Fatalf("round trip changed the object:\n%s", diff.ObjectReflectDiff(hub, restored))
+				}
+			}
+		})
+	}
+}
+
+func TestRegionLegacyCredentials(t *testing.T) {
+	region := &Region{Spec: RegionSpec{Endpoint: "https://reseller.example.com", Username: "reseller", Password: "secret"}}
+
+	hub := &v1beta1.Region{}
+	if err := region.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err := hub.LegacyCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials == nil || credentials.Endpoint != region.Spec.Endpoint || credentials.Username != region.Spec.Username || credentials.Password != region.Spec.Password {
+		t.Errorf("expected the inline credentials to be kept within the annotation, got %v", hub.Annotations)
+	}
+	if region.Annotations != nil {
+		t.Errorf("expected the annotations of the source to be left untouched, got %v", region.Annotations)
+	}
+
+	hub.Annotations[v1beta1.LegacyCredentialsAnnotation] = "{"
+	if err := (&Region{}).ConvertFrom(hub); err == nil {
+		t.Error("expected an invalid annotation to be rejected")
+	}
+}
+
+func TestUserProjectBindingApplicationCredentialFlag(t *testing.T) {
+	upb := &UserProjectBinding{Spec: UserProjectBindingSpec{ApplicationCredential: true}}
+
+	hub := &v1beta1.UserProjectBinding{}
+	if err := upb.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	if hub.Spec.ApplicationCredential == nil {
+		t.Fatal("expected the flag to issue an application credential")
+	}
+
+	restored := &UserProjectBinding{}
+	if err := restored.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Spec.ApplicationCredential || restored.Spec.ApplicationCredentialOptions != nil {
+		t.Errorf("expected the plain flag to be restored, got %+v", restored.Spec)
+	}
+
+	//Objects stored by earlier versions carry the flag as boolean
+	stored := &UserProjectBinding{}
+	if err := json.Unmarshal([]byte(`{"spec":{"user":"user","project":"project","applicationCredential":true}}`), stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Spec.ApplicationCredential {
+		t.Error("expected the stored flag to be decoded")
+	}
+}
+
+func TestUserProjectBindingApplicationCredentialOptionsWithoutFlag(t *testing.T) {
+	options := &ApplicationCredentialSpec{Roles: []string{"reader"}, Unrestricted: true}
+	upb := &UserProjectBinding{Spec: UserProjectBindingSpec{ApplicationCredentialOptions: options}}
+
+	hub := &v1beta1.UserProjectBinding{}
+	if err := upb.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	if hub.Spec.ApplicationCredential != nil {
+		t.Fatalf("expected no application credential without the flag, got %+v", hub.Spec.ApplicationCredential)
+	}
+	if _, ok := hub.Annotations[v1beta1.LegacyApplicationCredentialOptionsAnnotation]; !ok {
+		t.Fatalf("expected the options to be kept within the annotation, got %v", hub.Annotations)
+	}
+	if upb.Annotations != nil {
+		t.Errorf("expected the annotations of the source to be left untouched, got %v", upb.Annotations)
+	}
+
+	restored := &UserProjectBinding{}
+	if err := restored.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Spec.ApplicationCredential || !equality.Semantic.DeepEqual(restored.Spec.ApplicationCredentialOptions, options) || restored.Annotations != nil {
+		t.Errorf("expected the options to be restored without the flag, got %+v", restored)
+	}
+
+	//An application credential enabled within v1beta1 replaces the kept options
+	hub.Spec.ApplicationCredential = &v1beta1.ApplicationCredentialSpec{Roles: []string{"member"}}
+	if err := restored.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Spec.ApplicationCredential || !equality.Semantic.DeepEqual(restored.Spec.ApplicationCredentialOptions.Roles, []string{"member"}) {
+		t.Errorf("expected the application credential of v1beta1 to be converted, got %+v", restored.Spec)
+	}
+
+	hub.Annotations[v1beta1.LegacyApplicationCredentialOptionsAnnotation] = "{"
+	if err := (&UserProjectBinding{}).ConvertFrom(hub); err == nil {
+		t.Error("expected an invalid annotation to be rejected")
+	}
+}
+
+func newFuzzer(t *testing.T) *randfill.Filler {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	seed := rand.Int63()
+	t.Logf("fuzzing with seed %d", seed)
+
+	return fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(seed), serializer.NewCodecFactory(scheme)).Funcs(
+		//Regions converted from v1alpha1 carry their inline credentials within an annotation
+		func(region *v1beta1.Region, c randfill.Continue) {
+			c.FillNoCustom(region)
+			if !c.Bool() {
+				return
+			}
+
+			credentials, err := json.Marshal(v1beta1.LegacyCredentials{Endpoint: c.String(0), Username: c.String(0), Password: c.String(0)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if region.Annotations == nil {
+				region.Annotations = map[string]string{}
+			}
+			region.Annotations[v1beta1.LegacyCredentialsAnnotation] = string(credentials)
+		},
+	)
+}
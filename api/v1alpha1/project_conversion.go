@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the project to the v1beta1 hub version
+func (src *Project) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Project)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1beta1.ProjectSpec{
+		Region:      src.Spec.Region,
+		Description: src.Spec.Description,
+	}
+	if src.Spec.Quotas != nil {
+		dst.Spec.Quotas = &v1beta1.QuotaCollection{
+			Compute: (*v1beta1.ComputeQuotas)(src.Spec.Quotas.Compute.DeepCopy()),
+			Network: (*v1beta1.NetworkQuotas)(src.Spec.Quotas.Network.DeepCopy()),
+			Volume:  (*v1beta1.VolumeQuotas)(src.Spec.Quotas.Volume.DeepCopy()),
+		}
+	}
+	dst.Status = v1beta1.ProjectStatus(*src.Status.DeepCopy())
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to the project
+func (dst *Project) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Project)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ProjectSpec{
+		Region:      src.Spec.Region,
+		Description: src.Spec.Description,
+	}
+	if src.Spec.Quotas != nil {
+		dst.Spec.Quotas = &QuotaCollection{
+			Compute: (*ComputeQuotas)(src.Spec.Quotas.Compute.DeepCopy()),
+			Network: (*NetworkQuotas)(src.Spec.Quotas.Network.DeepCopy()),
+			Volume:  (*VolumeQuotas)(src.Spec.Quotas.Volume.DeepCopy()),
+		}
+	}
+	dst.Status = ProjectStatus(*src.Status.DeepCopy())
+
+	return nil
+}
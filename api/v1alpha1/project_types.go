@@ -17,15 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"context"
-	"time"
-
-	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -33,6 +25,7 @@ import (
 
 // ProjectSpec defines the desired state of Project
 type ProjectSpec struct {
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="region is immutable"
 	Region      string `json:"region,omitempty"`
 	Description string `json:"description,omitempty"`
 	//Enabled     *bool            `json:"enabled,omitempty"`
@@ -47,125 +40,140 @@ type QuotaCollection struct {
 }
 
 // ComputeQuotas defines model for ComputeQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
 type ComputeQuotas struct {
-	// Number of cores between 0 and 500
-	Cores    *int `json:"cores,omitempty"`
+	// Number of cores between 0 and 500, -1 means no limit
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=500
+	Cores *int `json:"cores,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	FixedIps *int `json:"fixed_ips,omitempty"`
 
 	// The number of allowed floating IP addresses for each project
-	FloatingIps              *int `json:"floating_ips,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	FloatingIps *int `json:"floating_ips,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	InjectedFileContentBytes *int `json:"injected_file_content_bytes,omitempty"`
-	InjectedFilePathBytes    *int `json:"injected_file_path_bytes,omitempty"`
-	InjectedFiles            *int `json:"injected_files,omitempty"`
-	Instances                int  `json:"instances"`
-	KeyPairs                 int  `json:"key_pairs"`
-	MetadataItems            int  `json:"metadata_items"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	InjectedFilePathBytes *int `json:"injected_file_path_bytes,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	InjectedFiles *int `json:"injected_files,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Instances int `json:"instances"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	KeyPairs int `json:"key_pairs"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	MetadataItems int `json:"metadata_items"`
 
 	// Maximum amount of RAM in MiB
-	Ram                *int `json:"ram,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Ram *int `json:"ram,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	SecurityGroupRules *int `json:"security_group_rules,omitempty"`
-	SecurityGroups     *int `json:"security_groups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroups *int `json:"security_groups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	ServerGroupMembers *int `json:"server_group_members,omitempty"`
-	ServerGroups       int  `json:"server_groups"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	ServerGroups int `json:"server_groups"`
 }
 
 // VolumeQuotas defines model for VolumeQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
 type VolumeQuotas struct {
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	BackupGigabytes int `json:"backup_gigabytes"`
-	Backups         int `json:"backups"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Backups int `json:"backups"`
 
 	// Maximum amount of available Storage
-	Gigabytes          *int `json:"gigabytes,omitempty"`
-	Groups             *int `json:"groups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Gigabytes *int `json:"gigabytes,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Groups *int `json:"groups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	PerVolumeGigabytes *int `json:"per_volume_gigabytes,omitempty"`
 
 	// Maximum amount of snapshots
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	Snapshots *int `json:"snapshots,omitempty"`
-	Volumes   int  `json:"volumes"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Volumes int `json:"volumes"`
 }
 
 // NetworkQuotas defines model for NetworkQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
 type NetworkQuotas struct {
 	// The number of floating IP addresses allowed for each project.A value of -1 means no limit
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
 	Floatingip *int `json:"floatingip,omitempty"`
-	Network    int  `json:"network"`
-	Port       *int `json:"port,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Network int `json:"network"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Port *int `json:"port,omitempty"`
 
 	// The number of role-based access control (RBAC) policies for each project
-	RbacPolicy        *int `json:"rbac_policy,omitempty"`
-	Router            int  `json:"router"`
-	SecurityGroup     int  `json:"security_group"`
-	SecurityGroupRule int  `json:"security_group_rule"`
-	Subnet            int  `json:"subnet"`
-	Subnetpool        *int `json:"subnetpool,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	RbacPolicy *int `json:"rbac_policy,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Router int `json:"router"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroup int `json:"security_group"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroupRule int `json:"security_group_rule"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Subnet int `json:"subnet"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Subnetpool *int `json:"subnetpool,omitempty"`
 }
 
 // ProjectStatus defines the observed state of Project
 type ProjectStatus struct {
-	Conditions []metav1.Condition `json:"conditions"`
-}
-
-// AwaitReady blocks until the project is completely ready
-func (v *Project) AwaitReady(ctx context.Context, timeout time.Duration, client client.Client, logger logr.Logger) error {
-	timeoutContext, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	out := make(chan bool)
-
-	go v.awaitAllConditionsTrue(timeoutContext, client, logger, out)
-
-	select {
-	case <-out:
-		return nil
-	case <-timeoutContext.Done():
-		return timeoutContext.Err()
-	}
-}
-
-func (v *Project) awaitAllConditionsTrue(ctx context.Context, client client.Client, logger logr.Logger, out chan bool) {
-	for !utils.AllConditionsTrue(v.Status.Conditions) {
-		//Check if the context is still good
-		if ctx.Err() != nil {
-			return
-		}
-
-		//Wait before refreshing object
-		time.Sleep(3 * time.Second)
+	// ObservedGeneration is the generation of the project the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-		//Refresh object
-		if err := client.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Name}, v); err != nil {
-			logger.Error(err, "Couldn't refresh conditions")
-		}
-	}
-
-	out <- true
-}
-
-// UpdateProjectCondition updates the given condition within the project object and patches its status subresource
-func (r *Project) UpdateProjectCondition(ctx context.Context, reconcileClient client.Client, reason ProjectReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(ProjectReady), reason.projectStatus(), string(reason), message)
-}
-
-// UpdateRegionCondition updates the given condition within the project object and patches its status subresource
-func (r *Project) UpdateRegionCondition(ctx context.Context, reconcileClient client.Client, reason RegionReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(RegionReady), reason.regionStatus(), string(reason), message)
-}
-
-func (r *Project) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
-	oldProject := r.DeepCopy()
-
-	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
-		Type:    typeString,
-		Status:  status,
-		Reason:  reason,
-		Message: message,
-	})
+	Conditions []metav1.Condition `json:"conditions"`
 
-	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldProject))
+	// PlannedChanges are the mutations of OpenStack the last reconcile planned instead of executing them, as its region is in plan mode
+	// +optional
+	PlannedChanges []string `json:"plannedChanges,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Project is the Schema for the projects API
 type Project struct {
@@ -0,0 +1,163 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the region to the v1beta1 hub version.
+// Inline credentials have no field in v1beta1 and are kept within v1beta1.LegacyCredentialsAnnotation
+func (src *Region) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Region)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1beta1.RegionSpec{
+		SecretRef:        (*v1beta1.SecretRef)(src.Spec.SecretRef.DeepCopy()),
+		OrphanCollection: (*v1beta1.OrphanCollectionSpec)(src.Spec.OrphanCollection.DeepCopy()),
+		RateLimit:        (*v1beta1.RateLimitSpec)(src.Spec.RateLimit.DeepCopy()),
+		CircuitBreaker:   (*v1beta1.CircuitBreakerSpec)(src.Spec.CircuitBreaker.DeepCopy()),
+		PlanMode:         src.Spec.PlanMode,
+	}
+	dst.Status = v1beta1.RegionStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+		ControllerID:       src.Status.ControllerID,
+		Orphans:            convertOrphanStatusTo(src.Status.Orphans),
+	}
+
+	if src.Spec.Endpoint == "" && src.Spec.Username == "" && src.Spec.Password == "" {
+		return nil
+	}
+
+	credentials, err := json.Marshal(v1beta1.LegacyCredentials{
+		Endpoint: src.Spec.Endpoint,
+		Username: src.Spec.Username,
+		Password: src.Spec.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	//Copy the annotations, as the object meta is shared with the source
+	dst.Annotations = make(map[string]string, len(src.Annotations)+1)
+	for k, v := range src.Annotations {
+		dst.Annotations[k] = v
+	}
+	dst.Annotations[v1beta1.LegacyCredentialsAnnotation] = string(credentials)
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to the region, restoring the inline credentials from v1beta1.LegacyCredentialsAnnotation
+func (dst *Region) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Region)
+
+	credentials, err := src.LegacyCredentials()
+	if err != nil {
+		return err
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = RegionSpec{
+		SecretRef:        (*SecretRef)(src.Spec.SecretRef.DeepCopy()),
+		OrphanCollection: (*OrphanCollectionSpec)(src.Spec.OrphanCollection.DeepCopy()),
+		RateLimit:        (*RateLimitSpec)(src.Spec.RateLimit.DeepCopy()),
+		CircuitBreaker:   (*CircuitBreakerSpec)(src.Spec.CircuitBreaker.DeepCopy()),
+		PlanMode:         src.Spec.PlanMode,
+	}
+	dst.Status = RegionStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+		ControllerID:       src.Status.ControllerID,
+		Orphans:            convertOrphanStatusFrom(src.Status.Orphans),
+	}
+
+	if credentials == nil {
+		return nil
+	}
+
+	dst.Spec.Endpoint = credentials.Endpoint
+	dst.Spec.Username = credentials.Username
+	dst.Spec.Password = credentials.Password
+
+	dst.Annotations = nil
+	for k, v := range src.Annotations {
+		if k == v1beta1.LegacyCredentialsAnnotation {
+			continue
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[k] = v
+	}
+
+	return nil
+}
+
+func convertOrphanStatusTo(src *OrphanStatus) *v1beta1.OrphanStatus {
+	if src == nil {
+		return nil
+	}
+
+	return &v1beta1.OrphanStatus{
+		LastScanTime: src.LastScanTime.DeepCopy(),
+		Projects:     convertOrphanedResourcesTo(src.Projects),
+		Users:        convertOrphanedResourcesTo(src.Users),
+	}
+}
+
+func convertOrphanedResourcesTo(src []OrphanedResource) []v1beta1.OrphanedResource {
+	if src == nil {
+		return nil
+	}
+
+	dst := make([]v1beta1.OrphanedResource, len(src))
+	for i, k := range src {
+		dst[i] = v1beta1.OrphanedResource(*k.DeepCopy())
+	}
+
+	return dst
+}
+
+func convertOrphanStatusFrom(src *v1beta1.OrphanStatus) *OrphanStatus {
+	if src == nil {
+		return nil
+	}
+
+	return &OrphanStatus{
+		LastScanTime: src.LastScanTime.DeepCopy(),
+		Projects:     convertOrphanedResourcesFrom(src.Projects),
+		Users:        convertOrphanedResourcesFrom(src.Users),
+	}
+}
+
+func convertOrphanedResourcesFrom(src []v1beta1.OrphanedResource) []OrphanedResource {
+	if src == nil {
+		return nil
+	}
+
+	dst := make([]OrphanedResource, len(src))
+	for i, k := range src {
+		dst[i] = OrphanedResource(*k.DeepCopy())
+	}
+
+	return dst
+}
@@ -17,24 +17,19 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"context"
-	"time"
-
-	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // RegionSpec defines the desired state of Region
+// +kubebuilder:validation:XValidation:rule="!has(self.secretRef) || !(has(self.endpoint) || has(self.username) || has(self.password))",message="secretRef and the inline endpoint, username and password are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="has(self.secretRef) || (has(self.endpoint) && has(self.username) && has(self.password))",message="endpoint, username and password must be specified if no secretRef is specified"
 type RegionSpec struct {
 	// Endpoint defines the Address of the PCO Reseller API
 	// Deprecated please use secretRef instead
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="endpoint is immutable"
 	// +optional
 	Endpoint string `json:"endpoint,omitempty"`
 
@@ -55,6 +50,70 @@ type RegionSpec struct {
 	//
 	// +optional
 	SecretRef *SecretRef `json:"secretRef,omitempty"`
+
+	// OrphanCollection configures the periodic detection of OpenStack projects and users of this operator without a resource.
+	// Orphans are only reported, unless their deletion is enabled
+	// +optional
+	OrphanCollection *OrphanCollectionSpec `json:"orphanCollection,omitempty"`
+
+	// RateLimit limits the requests of the operator to the reseller API and Keystone of this region.
+	// It applies with its defaults if omitted
+	// +optional
+	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
+
+	// CircuitBreaker stops all requests to the APIs of this region for a while once they failed repeatedly.
+	// It applies with its defaults if omitted
+	// +optional
+	CircuitBreaker *CircuitBreakerSpec `json:"circuitBreaker,omitempty"`
+
+	// PlanMode records the mutations of OpenStack projects, users, memberships, roles and application credentials within this region
+	// in the status and events of the resources instead of executing them. Overrides the --plan-mode flag of the manager if set
+	// +optional
+	PlanMode *bool `json:"planMode,omitempty"`
+}
+
+// RateLimitSpec configures the token bucket limiting the requests to the APIs of a region
+type RateLimitSpec struct {
+	// RequestsPerSecond is the sustained rate of requests. Defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RequestsPerSecond *int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the number of requests which may be sent at once. Defaults to 20
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+
+	// MaxWait is how long a request waits for the rate limit before the reconcile is postponed. Defaults to 5 seconds
+	// +optional
+	MaxWait *metav1.Duration `json:"maxWait,omitempty"`
+}
+
+// CircuitBreakerSpec configures when requests to the APIs of a region are stopped
+type CircuitBreakerSpec struct {
+	// FailureThreshold is the number of consecutive failed requests opening the circuit. Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// OpenDuration is how long no requests are sent once the circuit opened. Defaults to 30 seconds
+	// +optional
+	OpenDuration *metav1.Duration `json:"openDuration,omitempty"`
+}
+
+// OrphanCollectionSpec configures the detection and deletion of orphaned OpenStack projects and users
+type OrphanCollectionSpec struct {
+	// Interval between two scans of the region. Defaults to one hour
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Delete enables the deletion of orphans once they were detected for longer than the grace period
+	// +optional
+	Delete bool `json:"delete,omitempty"`
+
+	// GracePeriod defines how long an orphan has to be detected before it gets deleted. Defaults to 24 hours
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 }
 
 // SecretRef defines the Reference to a Secret
@@ -69,65 +128,54 @@ type SecretRef struct {
 
 // RegionStatus defines the observed state of Region
 type RegionStatus struct {
-	Conditions []metav1.Condition `json:"conditions"`
-}
-
-// AwaitReady blocks until the region is completely ready
-func (v *Region) AwaitReady(ctx context.Context, timeout time.Duration, client client.Client, logger logr.Logger) error {
-	timeoutContext, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	// ObservedGeneration is the generation of the region the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-	out := make(chan bool)
+	Conditions []metav1.Condition `json:"conditions"`
 
-	go v.awaitAllConditionsTrue(timeoutContext, client, logger, out)
+	// ControllerID is the identifier of the operator, which all OpenStack project and user names within the region are prefixed or suffixed with
+	// +optional
+	ControllerID string `json:"controllerID,omitempty"`
 
-	select {
-	case <-out:
-		return nil
-	case <-timeoutContext.Done():
-		return timeoutContext.Err()
-	}
+	// Orphans are the OpenStack projects and users named after this operator, which no resource exists for
+	// +optional
+	Orphans *OrphanStatus `json:"orphans,omitempty"`
 }
 
-func (v *Region) awaitAllConditionsTrue(ctx context.Context, client client.Client, logger logr.Logger, out chan bool) {
-	for !utils.AllConditionsTrue(v.Status.Conditions) {
-		//Check if the context is still good
-		if ctx.Err() != nil {
-			return
-		}
-
-		//Wait before refreshing object
-		time.Sleep(3 * time.Second)
-
-		//Refresh object
-		if err := client.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Name}, v); err != nil {
-			logger.Error(err, "Couldn't refresh conditions")
-		}
-	}
+// OrphanStatus stores the result of the last orphan scan of a region
+type OrphanStatus struct {
+	// LastScanTime is the time of the last successful scan
+	// +optional
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
 
-	out <- true
-}
+	// Projects are the orphaned OpenStack projects
+	// +optional
+	Projects []OrphanedResource `json:"projects,omitempty"`
 
-// UpdateRegionCondition updates the given condition within the region resource and updates its status subresource
-func (r *Region) UpdateRegionCondition(ctx context.Context, reconcileClient client.Client, reason RegionReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(RegionReady), reason.regionStatus(), string(reason), message)
+	// Users are the orphaned OpenStack users
+	// +optional
+	Users []OrphanedResource `json:"users,omitempty"`
 }
 
-func (r *Region) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
-	oldRegion := r.DeepCopy()
+// OrphanedResource is an OpenStack project or user without resource
+type OrphanedResource struct {
+	// ID of the OpenStack project or user
+	ID string `json:"id"`
 
-	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
-		Type:    typeString,
-		Status:  status,
-		Reason:  reason,
-		Message: message,
-	})
+	// Name of the OpenStack project or user
+	Name string `json:"name"`
 
-	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+	// FirstSeen is the time the orphan was detected first
+	FirstSeen metav1.Time `json:"firstSeen"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Controller ID",type=string,JSONPath=`.status.controllerID`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 //+kubebuilder:resource:scope=Cluster
 
 // Region is the Schema for the regions API
@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+)
+
+func convertSecretTemplateTo(src *SecretTemplate) *v1beta1.SecretTemplate {
+	if src == nil {
+		return nil
+	}
+
+	dst := &v1beta1.SecretTemplate{
+		Name:         src.Name,
+		Namespace:    src.Namespace,
+		Labels:       src.Labels,
+		Annotations:  src.Annotations,
+		ExternalOnly: src.ExternalOnly,
+	}
+	if src.Vault != nil {
+		dst.Vault = &v1beta1.VaultSink{
+			Address:        src.Vault.Address,
+			Mount:          src.Vault.Mount,
+			Path:           src.Vault.Path,
+			TokenSecretRef: v1beta1.SecretRef(src.Vault.TokenSecretRef),
+		}
+	}
+
+	return dst
+}
+
+func convertSecretTemplateFrom(src *v1beta1.SecretTemplate) *SecretTemplate {
+	if src == nil {
+		return nil
+	}
+
+	dst := &SecretTemplate{
+		Name:         src.Name,
+		Namespace:    src.Namespace,
+		Labels:       src.Labels,
+		Annotations:  src.Annotations,
+		ExternalOnly: src.ExternalOnly,
+	}
+	if src.Vault != nil {
+		dst.Vault = &VaultSink{
+			Address:        src.Vault.Address,
+			Mount:          src.Vault.Mount,
+			Path:           src.Vault.Path,
+			TokenSecretRef: SecretRef(src.Vault.TokenSecretRef),
+		}
+	}
+
+	return dst
+}
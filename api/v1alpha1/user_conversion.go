@@ -0,0 +1,52 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the user to the v1beta1 hub version
+func (src *User) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.User)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1beta1.UserSpec{
+		Description:    src.Spec.Description,
+		Enabled:        src.Spec.Enabled,
+		SecretTemplate: convertSecretTemplateTo(src.Spec.SecretTemplate),
+	}
+	dst.Status = v1beta1.UserStatus(*src.Status.DeepCopy())
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to the user
+func (dst *User) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.User)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = UserSpec{
+		Description:    src.Spec.Description,
+		Enabled:        src.Spec.Enabled,
+		SecretTemplate: convertSecretTemplateFrom(src.Spec.SecretTemplate),
+	}
+	dst.Status = UserStatus(*src.Status.DeepCopy())
+
+	return nil
+}
@@ -17,16 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"context"
-	"fmt"
-	"time"
-
-	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // UserSpec defines the desired state of User
@@ -35,70 +26,26 @@ type UserSpec struct {
 	Description string `json:"description,omitempty"`
 	// Enabled represents if the user is enabled or not
 	Enabled *bool `json:"enabled,omitempty"`
+	// SecretTemplate customizes the Secret storing the user credentials
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
 }
 
 // UserStatus defines the observed state of User
 type UserStatus struct {
+	// ObservedGeneration is the generation of the user the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Conditions store the conditions of the user object
 	Conditions []metav1.Condition `json:"conditions"`
 }
 
-// AwaitReady blocks until the user is completely ready
-func (v *User) AwaitReady(ctx context.Context, timeout time.Duration, client client.Client, logger logr.Logger) error {
-	timeoutContext, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	out := make(chan bool)
-
-	go v.awaitAllConditionsTrue(timeoutContext, client, logger, out)
-
-	select {
-	case <-out:
-		return nil
-	case <-timeoutContext.Done():
-		return timeoutContext.Err()
-	}
-}
-
-func (v *User) awaitAllConditionsTrue(ctx context.Context, client client.Client, logger logr.Logger, out chan bool) {
-	for !utils.AllConditionsTrue(v.Status.Conditions) {
-		//Check if the context is still good
-		if ctx.Err() != nil {
-			return
-		}
-
-		//Wait before refreshing object
-		time.Sleep(3 * time.Second)
-
-		//Refresh object
-		if err := client.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Name}, v); err != nil {
-			logger.Error(err, "Couldn't refresh conditions")
-		}
-	}
-
-	out <- true
-}
-
-// UpdateUserCondition updates the given condition in the user object and patches its status subresource
-func (r *User) UpdateUserCondition(ctx context.Context, reconcileClient client.Client, reason UserReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(UserReady), reason.userStatus(), string(reason), message)
-}
-
-func (r *User) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
-	oldUser := r.DeepCopy()
-
-	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
-		Type:    typeString,
-		Status:  status,
-		Reason:  reason,
-		Message: message,
-	})
-
-	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
-}
-
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // User is the Schema for the users API
 type User struct {
@@ -109,33 +56,6 @@ type User struct {
 	Status UserStatus `json:"status,omitempty"`
 }
 
-// Mail returns the e-mail address of the user
-func (u User) Mail(ctx context.Context, r client.Client) (*string, error) {
-	controllerId, err := utils.ControllerIdentifier(ctx, r)
-	if err != nil {
-		return nil, err
-	}
-
-	mail := fmt.Sprintf("%s-%s@%s.k8s", u.Name, u.Namespace, *controllerId)
-	return &mail, nil
-}
-
-// UserAccessSecretName returns the secret name for the user object
-func (u User) UserAccessSecretName() types.NamespacedName {
-	return types.NamespacedName{
-		Namespace: u.Namespace,
-		Name:      fmt.Sprintf("%s-openstack", u.Name),
-	}
-}
-
-// DefaultUserProjectBindingName returns the default project for the user
-func (u User) DefaultUserProjectBindingName() types.NamespacedName {
-	return types.NamespacedName{
-		Namespace: u.Namespace,
-		Name:      fmt.Sprintf("%s-default-project", u.Name),
-	}
-}
-
 //+kubebuilder:object:root=true
 
 // UserList contains a list of User
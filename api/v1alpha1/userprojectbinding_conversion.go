@@ -0,0 +1,165 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the userprojectbinding to the v1beta1 hub version.
+// Options without applicationCredential and empty options have no equivalent in v1beta1 and are kept within
+// v1beta1.LegacyApplicationCredentialOptionsAnnotation
+func (src *UserProjectBinding) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.UserProjectBinding)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1beta1.UserProjectBindingSpec{
+		Project:        src.Spec.Project,
+		User:           src.Spec.User,
+		Roles:          src.Spec.Roles,
+		SecretTemplate: convertSecretTemplateTo(src.Spec.SecretTemplate),
+	}
+	//The options of v1alpha1 are the application credential of v1beta1
+	if src.Spec.ApplicationCredential {
+		dst.Spec.ApplicationCredential = &v1beta1.ApplicationCredentialSpec{}
+		if k := src.Spec.ApplicationCredentialOptions; k != nil {
+			dst.Spec.ApplicationCredential = convertApplicationCredentialTo(k)
+		}
+	}
+	dst.Status = v1beta1.UserProjectBindingStatus{
+		ObservedGeneration:    src.Status.ObservedGeneration,
+		Conditions:            src.Status.Conditions,
+		ApplicationCredential: (*v1beta1.ApplicationCredentialStatus)(src.Status.ApplicationCredential.DeepCopy()),
+		PlannedChanges:        src.Status.PlannedChanges,
+		UserSecretUID:         src.Status.UserSecretUID,
+	}
+
+	k := src.Spec.ApplicationCredentialOptions
+	if k == nil || (src.Spec.ApplicationCredential && !reflect.DeepEqual(*k, ApplicationCredentialSpec{})) {
+		return nil
+	}
+
+	options, err := json.Marshal(convertApplicationCredentialTo(k))
+	if err != nil {
+		return err
+	}
+
+	//Copy the annotations, as the object meta is shared with the source
+	dst.Annotations = make(map[string]string, len(src.Annotations)+1)
+	for k, v := range src.Annotations {
+		dst.Annotations[k] = v
+	}
+	dst.Annotations[v1beta1.LegacyApplicationCredentialOptionsAnnotation] = string(options)
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to the userprojectbinding,
+// restoring the options v1beta1 can't express from v1beta1.LegacyApplicationCredentialOptionsAnnotation
+func (dst *UserProjectBinding) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.UserProjectBinding)
+
+	options, err := src.LegacyApplicationCredentialOptions()
+	if err != nil {
+		return err
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = UserProjectBindingSpec{
+		Project:        src.Spec.Project,
+		User:           src.Spec.User,
+		Roles:          src.Spec.Roles,
+		SecretTemplate: convertSecretTemplateFrom(src.Spec.SecretTemplate),
+	}
+	if src.Spec.ApplicationCredential != nil {
+		dst.Spec.ApplicationCredential = true
+	}
+	//Credentials without options are converted to the plain flag
+	if k := src.Spec.ApplicationCredential; k != nil && !reflect.DeepEqual(*k, v1beta1.ApplicationCredentialSpec{}) {
+		dst.Spec.ApplicationCredentialOptions = convertApplicationCredentialFrom(k)
+	}
+	dst.Status = UserProjectBindingStatus{
+		ObservedGeneration:    src.Status.ObservedGeneration,
+		Conditions:            src.Status.Conditions,
+		ApplicationCredential: (*ApplicationCredentialStatus)(src.Status.ApplicationCredential.DeepCopy()),
+		PlannedChanges:        src.Status.PlannedChanges,
+		UserSecretUID:         src.Status.UserSecretUID,
+	}
+
+	if options == nil {
+		return nil
+	}
+
+	//The kept options only apply as long as the application credential of v1beta1 doesn't replace them
+	k := src.Spec.ApplicationCredential
+	if k == nil || (reflect.DeepEqual(*k, v1beta1.ApplicationCredentialSpec{}) && reflect.DeepEqual(*options, v1beta1.ApplicationCredentialSpec{})) {
+		dst.Spec.ApplicationCredentialOptions = convertApplicationCredentialFrom(options)
+	}
+
+	dst.Annotations = nil
+	for k, v := range src.Annotations {
+		if k == v1beta1.LegacyApplicationCredentialOptionsAnnotation {
+			continue
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[k] = v
+	}
+
+	return nil
+}
+
+func convertApplicationCredentialTo(src *ApplicationCredentialSpec) *v1beta1.ApplicationCredentialSpec {
+	dst := &v1beta1.ApplicationCredentialSpec{
+		ExpiresAfter: src.ExpiresAfter,
+		RotateBefore: src.RotateBefore,
+		GracePeriod:  src.GracePeriod,
+		Roles:        src.Roles,
+		Unrestricted: src.Unrestricted,
+	}
+	if src.AccessRules != nil {
+		dst.AccessRules = make([]v1beta1.ApplicationCredentialAccessRule, len(src.AccessRules))
+		for i, rule := range src.AccessRules {
+			dst.AccessRules[i] = v1beta1.ApplicationCredentialAccessRule(rule)
+		}
+	}
+
+	return dst
+}
+
+func convertApplicationCredentialFrom(src *v1beta1.ApplicationCredentialSpec) *ApplicationCredentialSpec {
+	dst := &ApplicationCredentialSpec{
+		ExpiresAfter: src.ExpiresAfter,
+		RotateBefore: src.RotateBefore,
+		GracePeriod:  src.GracePeriod,
+		Roles:        src.Roles,
+		Unrestricted: src.Unrestricted,
+	}
+	if src.AccessRules != nil {
+		dst.AccessRules = make([]ApplicationCredentialAccessRule, len(src.AccessRules))
+		for i, rule := range src.AccessRules {
+			dst.AccessRules[i] = ApplicationCredentialAccessRule(rule)
+		}
+	}
+
+	return dst
+}
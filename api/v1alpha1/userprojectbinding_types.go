@@ -17,96 +17,131 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"context"
-	"fmt"
-	"time"
-
-	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // UserProjectBindingSpec defines the desired state of UserProjectBinding
+// +kubebuilder:validation:XValidation:rule="!has(self.applicationCredentialOptions) || (has(self.applicationCredential) && self.applicationCredential)",message="applicationCredentialOptions require applicationCredential"
 type UserProjectBindingSpec struct {
-	Project               string `json:"project"`
-	User                  string `json:"user"`
-	ApplicationCredential bool   `json:"applicationCredential,omitempty"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="project is immutable"
+	Project string `json:"project"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="user is immutable"
+	User string `json:"user"`
+	// Roles are the Keystone roles granted to the user within the project, e.g. member, reader, load-balancer_member or creator.
+	// Roles not listed are removed from the user. If empty, the default role of the reseller API is granted and roles are not managed
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+	// ApplicationCredential causes an application credential to be issued for the binding
+	// +optional
+	ApplicationCredential bool `json:"applicationCredential,omitempty"`
+	// ApplicationCredentialOptions configure the lifecycle of the application credential, they require applicationCredential
+	// +optional
+	ApplicationCredentialOptions *ApplicationCredentialSpec `json:"applicationCredentialOptions,omitempty"`
+	// SecretTemplate customizes the delivery of the application credential
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
 }
 
-// UserProjectBindingStatus defines the observed state of UserProjectBinding
-type UserProjectBindingStatus struct {
-	Conditions []metav1.Condition `json:"conditions"`
+// ApplicationCredentialSpec defines the lifecycle of the application credential issued for a binding
+type ApplicationCredentialSpec struct {
+	// ExpiresAfter defines how long an issued application credential stays valid.
+	// If unset, the application credential never expires and is never rotated
+	// +optional
+	ExpiresAfter *metav1.Duration `json:"expiresAfter,omitempty"`
+
+	// RotateBefore defines how long before its expiry the application credential gets replaced.
+	// Defaults to a fifth of ExpiresAfter
+	// +optional
+	RotateBefore *metav1.Duration `json:"rotateBefore,omitempty"`
+
+	// GracePeriod defines how long the replaced application credential stays valid after a rotation.
+	// Defaults to one hour
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// Roles restricts the application credential to a subset of the roles the user has within the project.
+	// If empty, the application credential inherits all roles of the user
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// Unrestricted allows the application credential to create further application credentials and trusts
+	// +optional
+	Unrestricted bool `json:"unrestricted,omitempty"`
+
+	// AccessRules restrict the API calls the application credential may perform.
+	// If empty, all API calls permitted by the roles are allowed
+	// +optional
+	AccessRules []ApplicationCredentialAccessRule `json:"accessRules,omitempty"`
 }
 
-// AwaitReady blocks until the userprojectbinding is completely ready
-func (v *UserProjectBinding) AwaitReady(ctx context.Context, timeout time.Duration, client client.Client, logger logr.Logger) error {
-	timeoutContext, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// ApplicationCredentialAccessRule permits a single kind of API call
+type ApplicationCredentialAccessRule struct {
+	// Service is the service type identifier, e.g. compute or identity
+	Service string `json:"service"`
 
-	out := make(chan bool)
+	// Method is the permitted request method
+	// +kubebuilder:validation:Enum=HEAD;GET;POST;PUT;PATCH;DELETE
+	Method string `json:"method"`
 
-	go v.awaitAllConditionsTrue(timeoutContext, client, logger, out)
-
-	select {
-	case <-out:
-		return nil
-	case <-timeoutContext.Done():
-		return timeoutContext.Err()
-	}
+	// Path is the permitted API path, which may contain wildcards, e.g. /v2.1/servers/*
+	Path string `json:"path"`
 }
 
-func (v *UserProjectBinding) awaitAllConditionsTrue(ctx context.Context, client client.Client, logger logr.Logger, out chan bool) {
-	for !utils.AllConditionsTrue(v.Status.Conditions) {
-		//Check if the context is still good
-		if ctx.Err() != nil {
-			return
-		}
+// UserProjectBindingStatus defines the observed state of UserProjectBinding
+type UserProjectBindingStatus struct {
+	// ObservedGeneration is the generation of the userprojectbinding the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions"`
 
-		//Wait before refreshing object
-		time.Sleep(3 * time.Second)
+	// ApplicationCredential stores the state of the issued application credentials
+	// +optional
+	ApplicationCredential *ApplicationCredentialStatus `json:"applicationCredential,omitempty"`
 
-		//Refresh object
-		if err := client.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Name}, v); err != nil {
-			logger.Error(err, "Couldn't refresh conditions")
-		}
-	}
+	// PlannedChanges are the mutations of OpenStack the last reconcile planned instead of executing them, as its region is in plan mode
+	// +optional
+	PlannedChanges []string `json:"plannedChanges,omitempty"`
 
-	out <- true
+	// UserSecretUID is the uid of the secret of the user, whose password was last set on the OpenStack user
+	// +optional
+	UserSecretUID types.UID `json:"userSecretUID,omitempty"`
 }
 
-// UpdateUserProjectBindingCondition updates the given condition within the userprojectbinding resource and patches its status subresource
-func (r *UserProjectBinding) UpdateUserProjectBindingCondition(ctx context.Context, reconcileClient client.Client, reason UserProjectBindingReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(UserProjectBindingReady), reason.userProjectBindingStatus(), string(reason), message)
-}
+// ApplicationCredentialStatus defines the observed state of the issued application credentials
+type ApplicationCredentialStatus struct {
+	// CurrentID is the id of the application credential stored in the secret
+	// +optional
+	CurrentID string `json:"currentId,omitempty"`
 
-// UpdateUserCondition updates the given condition within the userprojectbinding resource and patches its status subresource
-func (r *UserProjectBinding) UpdateUserCondition(ctx context.Context, reconcileClient client.Client, reason UserReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(UserReady), reason.userStatus(), string(reason), message)
-}
+	// CurrentExpiresAt is the expiry of the current application credential
+	// +optional
+	CurrentExpiresAt *metav1.Time `json:"currentExpiresAt,omitempty"`
 
-// UpdateProjectCondition updates the given condition within the userprojectbinding resource and patches its status subresource
-func (r *UserProjectBinding) UpdateProjectCondition(ctx context.Context, reconcileClient client.Client, reason ProjectReadyReasons, message string) error {
-	return r.updateCondition(ctx, reconcileClient, string(ProjectReady), reason.projectStatus(), string(reason), message)
-}
+	// CurrentRoles are the roles the current application credential was restricted to, empty if it inherits all roles
+	// +optional
+	CurrentRoles []string `json:"currentRoles,omitempty"`
 
-func (r *UserProjectBinding) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
-	oldUser := r.DeepCopy()
+	// PreviousID is the id of the replaced application credential, which is still valid during the grace period
+	// +optional
+	PreviousID string `json:"previousId,omitempty"`
 
-	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
-		Type:    typeString,
-		Status:  status,
-		Reason:  reason,
-		Message: message,
-	})
+	// PreviousExpiresAt is the point in time at which the replaced application credential gets deleted
+	// +optional
+	PreviousExpiresAt *metav1.Time `json:"previousExpiresAt,omitempty"`
 
-	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
+	// PendingID is the id of an issued application credential whose secret may not have been written yet.
+	// It gets adopted once its secret was written and revoked otherwise
+	// +optional
+	PendingID string `json:"pendingId,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // UserProjectBinding is the Schema for the userprojectbindings API
 type UserProjectBinding struct {
@@ -117,11 +152,6 @@ type UserProjectBinding struct {
 	Status UserProjectBindingStatus `json:"status,omitempty"`
 }
 
-// ApplicationCredentialName returns the target application credential name
-func (upb UserProjectBinding) ApplicationCredentialName() string {
-	return fmt.Sprintf("%s-appcred", upb.Name)
-}
-
 //+kubebuilder:object:root=true
 
 // UserProjectBindingList contains a list of UserProjectBinding
@@ -0,0 +1,13 @@
+package v1beta1
+
+const (
+	// PausedAnnotation stops the operator from reconciling and finalizing the annotated object while set to "true"
+	PausedAnnotation = "pco.plusserver.com/paused"
+	// ReconcileAtAnnotation triggers a reconcile of the annotated object whenever its value changes, e.g. to the current timestamp
+	ReconcileAtAnnotation = "pco.plusserver.com/reconcile-at"
+)
+
+// IsPaused returns true if the object is annotated to be paused
+func IsPaused(annotations map[string]string) bool {
+	return annotations[PausedAnnotation] == "true"
+}
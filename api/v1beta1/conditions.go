@@ -0,0 +1,278 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionTypes stores the different kinds of conditions
+type ConditionTypes string
+
+const (
+	// Ready summarizes all other conditions of an object. It is true once all of them are true for the current generation
+	Ready ConditionTypes = "Ready"
+	// UserReady represents whether the OpenStack user is ready or not
+	UserReady ConditionTypes = "UserReady"
+	// ProjectReady represents whether the OpenStack project is ready or not
+	ProjectReady ConditionTypes = "ProjectReady"
+	// RegionReady represents whether the OpenStack region is ready or not
+	RegionReady ConditionTypes = "RegionReady"
+	// UserProjectBindingReady represents whether the userprojectbinding object is ready or not
+	UserProjectBindingReady ConditionTypes = "UserProjectBindingReady"
+	// Paused is present and true while the reconciliation of the object is paused by PausedAnnotation.
+	// It isn't taken into account by the Ready condition
+	Paused ConditionTypes = "Paused"
+	// APIThrottled is present and true while requests to the APIs of a region are rejected by its rate limit.
+	// It isn't taken into account by the Ready condition
+	APIThrottled ConditionTypes = "APIThrottled"
+	// APIUnavailable is present and true while the circuit breaker of a region stops requests to its failing APIs.
+	// It isn't taken into account by the Ready condition
+	APIUnavailable ConditionTypes = "APIUnavailable"
+)
+
+// RegionReadyReasons are the different states of readiness, which a region can have
+type RegionReadyReasons string
+
+const (
+	// RegionIsReady is set when the region is ready
+	RegionIsReady RegionReadyReasons = "RegionIsReady"
+	// RegionIsUnready is set when the region is not ready
+	RegionIsUnready RegionReadyReasons = "RegionIsUnready"
+	// RegionNotFound is set when the region could not be found
+	RegionNotFound RegionReadyReasons = "RegionNotFound"
+	// RegionUnknown is set if the readiness could not be determined
+	RegionUnknown RegionReadyReasons = "UnknownError"
+	// RegionAuthenticationFailed is set when the API rejected the credentials of the region
+	RegionAuthenticationFailed RegionReadyReasons = "AuthenticationFailed"
+	// RegionInvalidRequest is set when the API rejected a request as invalid
+	RegionInvalidRequest RegionReadyReasons = "InvalidRequest"
+	// RegionConflict is set when a request conflicted with the current state within the API
+	RegionConflict RegionReadyReasons = "Conflict"
+	// RegionRateLimited is set when the API throttled the requests
+	RegionRateLimited RegionReadyReasons = "RateLimited"
+	// RegionTransientError is set when the API failed temporarily, e.g. due to server or network errors
+	RegionTransientError RegionReadyReasons = "TransientError"
+)
+
+func (r RegionReadyReasons) regionStatus() v1.ConditionStatus {
+	switch r {
+	case RegionIsReady:
+		{
+			return v1.ConditionTrue
+		}
+	case RegionUnknown, RegionTransientError:
+		{
+			return v1.ConditionUnknown
+		}
+	default:
+		{
+			return v1.ConditionFalse
+		}
+	}
+}
+
+// ProjectReadyReasons are the different states of readiness, which a project can have
+type ProjectReadyReasons string
+
+const (
+	// ProjectIsReady is set when the project is ready
+	ProjectIsReady ProjectReadyReasons = "ProjectIsReady"
+	// ProjectIsUnready is set when the project is not ready
+	ProjectIsUnready ProjectReadyReasons = "ProjectIsUnready"
+	// ProjectNotFound is set when the project could not be found
+	ProjectNotFound ProjectReadyReasons = "ProjectNotFound"
+	// ProjectUnknown is set if the readiness could not be determined
+	ProjectUnknown ProjectReadyReasons = "UnknownError"
+	// ProjectAuthenticationFailed is set when the API rejected the credentials of the region
+	ProjectAuthenticationFailed ProjectReadyReasons = "AuthenticationFailed"
+	// ProjectInvalidRequest is set when the API rejected a request as invalid
+	ProjectInvalidRequest ProjectReadyReasons = "InvalidRequest"
+	// ProjectConflict is set when a request conflicted with the current state within the API
+	ProjectConflict ProjectReadyReasons = "Conflict"
+	// ProjectRateLimited is set when the API throttled the requests
+	ProjectRateLimited ProjectReadyReasons = "RateLimited"
+	// ProjectTransientError is set when the API failed temporarily, e.g. due to server or network errors
+	ProjectTransientError ProjectReadyReasons = "TransientError"
+	// ProjectChangesPlanned is set in plan mode when the OpenStack project doesn't match the spec and the changes were only planned
+	ProjectChangesPlanned ProjectReadyReasons = "ChangesPlanned"
+)
+
+func (r ProjectReadyReasons) projectStatus() v1.ConditionStatus {
+	switch r {
+	case ProjectIsReady:
+		{
+			return v1.ConditionTrue
+		}
+	case ProjectUnknown, ProjectTransientError:
+		{
+			return v1.ConditionUnknown
+		}
+	default:
+		{
+			return v1.ConditionFalse
+		}
+	}
+}
+
+// UserReadyReasons are the different states of readiness, which a user can have
+type UserReadyReasons string
+
+const (
+	// UserIsReady is set when the user is ready
+	UserIsReady UserReadyReasons = "UserIsReady"
+	// UserHasNoSecret is set when the users secret could not be found
+	UserHasNoSecret UserReadyReasons = "SecretNotFound"
+	// UserSecretNamespaceNotAllowed is set when the secret of the user may not be delivered into the requested namespace
+	UserSecretNamespaceNotAllowed UserReadyReasons = "SecretNamespaceNotAllowed"
+	// UserSecretNotManaged is set when a secret, which wasn't generated for the user, occupies the name of its secret
+	UserSecretNotManaged UserReadyReasons = "SecretNotManaged"
+	// UserNotFound is set when the user could not be found
+	UserNotFound UserReadyReasons = "UserNotFound"
+	// UserIsUnready is set when the user is not ready
+	UserIsUnready UserReadyReasons = "UserIsUnready"
+	// UserUnknown is set if the readiness could not be determined
+	UserUnknown UserReadyReasons = "UnknownError"
+)
+
+func (r UserReadyReasons) userStatus() v1.ConditionStatus {
+	switch r {
+	case UserIsReady:
+		{
+			return v1.ConditionTrue
+		}
+	case UserUnknown:
+		{
+			return v1.ConditionUnknown
+		}
+	default:
+		{
+			return v1.ConditionFalse
+		}
+	}
+}
+
+// UserProjectBindingReadyReasons are the different states of readiness, which a userprojectbinding can have
+type UserProjectBindingReadyReasons string
+
+const (
+	// UserProjectBindingIsReady is set when the userprojectbinding is ready
+	UserProjectBindingIsReady UserProjectBindingReadyReasons = "UserProjectBindingIsReady"
+	// UserProjectBindingHasNoSecret is set when the application credential secret is missing or doesn't match the application credential
+	UserProjectBindingHasNoSecret UserProjectBindingReadyReasons = "SecretNotFound"
+	// UserProjectBindingSecretNamespaceNotAllowed is set when the application credential may not be delivered into the requested namespace
+	UserProjectBindingSecretNamespaceNotAllowed UserProjectBindingReadyReasons = "SecretNamespaceNotAllowed"
+	// UserProjectBindingSecretNotManaged is set when a secret, which wasn't generated for the binding, occupies the name of its secret
+	UserProjectBindingSecretNotManaged UserProjectBindingReadyReasons = "SecretNotManaged"
+	// UserProjectBindingInvalidRoles is set when requested roles are not available in the region
+	UserProjectBindingInvalidRoles UserProjectBindingReadyReasons = "InvalidRoles"
+	// UserProjectBindingUnknown is set if the readiness could not be determined
+	UserProjectBindingUnknown UserProjectBindingReadyReasons = "UnknownError"
+	// UserProjectBindingAuthenticationFailed is set when the API rejected the credentials of the region
+	UserProjectBindingAuthenticationFailed UserProjectBindingReadyReasons = "AuthenticationFailed"
+	// UserProjectBindingInvalidRequest is set when the API rejected a request as invalid
+	UserProjectBindingInvalidRequest UserProjectBindingReadyReasons = "InvalidRequest"
+	// UserProjectBindingConflict is set when a request conflicted with the current state within the API
+	UserProjectBindingConflict UserProjectBindingReadyReasons = "Conflict"
+	// UserProjectBindingRateLimited is set when the API throttled the requests
+	UserProjectBindingRateLimited UserProjectBindingReadyReasons = "RateLimited"
+	// UserProjectBindingTransientError is set when the API failed temporarily, e.g. due to server or network errors
+	UserProjectBindingTransientError UserProjectBindingReadyReasons = "TransientError"
+	// UserProjectBindingChangesPlanned is set in plan mode when OpenStack doesn't match the spec and the changes were only planned
+	UserProjectBindingChangesPlanned UserProjectBindingReadyReasons = "ChangesPlanned"
+)
+
+func (r UserProjectBindingReadyReasons) userProjectBindingStatus() v1.ConditionStatus {
+	switch r {
+	case UserProjectBindingIsReady:
+		{
+			return v1.ConditionTrue
+		}
+	case UserProjectBindingUnknown, UserProjectBindingTransientError:
+		{
+			return v1.ConditionUnknown
+		}
+	default:
+		{
+			return v1.ConditionFalse
+		}
+	}
+}
+
+const (
+	// readyReason is the reason of the Ready condition if all other conditions are true
+	readyReason = "Ready"
+	// reconcilingReason is the reason of the Ready condition while conditions of a previous generation are left
+	reconcilingReason = "Reconciling"
+	// pausedReason is the reason of the Paused condition
+	pausedReason = "Paused"
+	// apiThrottledReason is the reason of the APIThrottled condition
+	apiThrottledReason = "RateLimitExceeded"
+	// apiUnavailableReason is the reason of the APIUnavailable condition
+	apiUnavailableReason = "CircuitOpen"
+)
+
+// setCondition sets the condition observed at the given generation and recomputes the Ready condition
+func setCondition(conditions *[]v1.Condition, generation int64, condition v1.Condition) {
+	condition.ObservedGeneration = generation
+	meta.SetStatusCondition(conditions, condition)
+
+	ready := v1.Condition{
+		Type:               string(Ready),
+		Status:             v1.ConditionTrue,
+		Reason:             readyReason,
+		Message:            "All conditions are true",
+		ObservedGeneration: generation,
+	}
+
+	//The first false condition takes precedence over unknown ones
+	for _, k := range *conditions {
+		if k.Type == string(Ready) || k.Type == string(Paused) || k.Type == string(APIThrottled) || k.Type == string(APIUnavailable) {
+			continue
+		}
+
+		status, reason, message := k.Status, k.Reason, k.Message
+		//Conditions set for a previous generation can't be trusted to be true anymore
+		if status == v1.ConditionTrue && k.ObservedGeneration != generation {
+			status, reason, message = v1.ConditionUnknown, reconcilingReason, fmt.Sprintf("Generation %d not reconciled yet", generation)
+		}
+
+		if status == v1.ConditionTrue || ready.Status == v1.ConditionFalse || (ready.Status == v1.ConditionUnknown && status == v1.ConditionUnknown) {
+			continue
+		}
+
+		ready.Status = status
+		ready.Reason = reason
+		ready.Message = fmt.Sprintf("%s: %s", k.Type, message)
+	}
+
+	meta.SetStatusCondition(conditions, ready)
+}
+
+// setPaused sets the Paused condition if paused and removes it otherwise. It returns whether the conditions changed
+func setPaused(conditions *[]v1.Condition, generation int64, paused bool) bool {
+	return setPresent(conditions, v1.Condition{
+		Type:               string(Paused),
+		Status:             v1.ConditionTrue,
+		Reason:             pausedReason,
+		Message:            fmt.Sprintf("Reconciliation paused by annotation %s", PausedAnnotation),
+		ObservedGeneration: generation,
+	}, paused)
+}
+
+// setPresent sets the condition if present and removes it otherwise. It returns whether the conditions changed
+func setPresent(conditions *[]v1.Condition, condition v1.Condition, present bool) bool {
+	if !present {
+		return meta.RemoveStatusCondition(conditions, condition.Type)
+	}
+
+	return meta.SetStatusCondition(conditions, condition)
+}
+
+// isReady returns true if the Ready condition is true and reflects the given generation.
+// Objects which weren't reconciled yet have no Ready condition and aren't ready
+func isReady(conditions []v1.Condition, generation int64) bool {
+	ready := meta.FindStatusCondition(conditions, string(Ready))
+	return ready != nil && ready.Status == v1.ConditionTrue && ready.ObservedGeneration == generation
+}
@@ -0,0 +1,263 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ProjectSpec defines the desired state of Project
+type ProjectSpec struct {
+	// Region is the name of the region the OpenStack project is created in
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="region is immutable"
+	Region      string `json:"region"`
+	Description string `json:"description,omitempty"`
+	//Enabled     *bool            `json:"enabled,omitempty"`
+	Quotas *QuotaCollection `json:"quotas,omitempty"`
+}
+
+// QuotaCollection stores the different quota sub-types
+type QuotaCollection struct {
+	Compute *ComputeQuotas `json:"compute,omitempty"`
+	Network *NetworkQuotas `json:"network,omitempty"`
+	Volume  *VolumeQuotas  `json:"volume,omitempty"`
+}
+
+// ComputeQuotas defines model for ComputeQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
+type ComputeQuotas struct {
+	// Number of cores between 0 and 500, -1 means no limit
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=500
+	Cores *int `json:"cores,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	FixedIps *int `json:"fixedIps,omitempty"`
+
+	// The number of allowed floating IP addresses for each project
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	FloatingIps *int `json:"floatingIps,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	InjectedFileContentBytes *int `json:"injectedFileContentBytes,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	InjectedFilePathBytes *int `json:"injectedFilePathBytes,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	InjectedFiles *int `json:"injectedFiles,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Instances int `json:"instances"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	KeyPairs int `json:"keyPairs"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	MetadataItems int `json:"metadataItems"`
+
+	// Maximum amount of RAM in MiB
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Ram *int `json:"ram,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroupRules *int `json:"securityGroupRules,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroups *int `json:"securityGroups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	ServerGroupMembers *int `json:"serverGroupMembers,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	ServerGroups int `json:"serverGroups"`
+}
+
+// VolumeQuotas defines model for VolumeQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
+type VolumeQuotas struct {
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	BackupGigabytes int `json:"backupGigabytes"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Backups int `json:"backups"`
+
+	// Maximum amount of available Storage
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Gigabytes *int `json:"gigabytes,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Groups *int `json:"groups,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	PerVolumeGigabytes *int `json:"perVolumeGigabytes,omitempty"`
+
+	// Maximum amount of snapshots
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Snapshots *int `json:"snapshots,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Volumes int `json:"volumes"`
+}
+
+// NetworkQuotas defines model for NetworkQuotas.
+// Quotas range from 0 to 2147483647, the largest limit OpenStack stores, unless documented otherwise. -1 means no limit
+type NetworkQuotas struct {
+	// The number of floating IP addresses allowed for each project.A value of -1 means no limit
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Floatingip *int `json:"floatingip,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Network int `json:"network"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Port *int `json:"port,omitempty"`
+
+	// The number of role-based access control (RBAC) policies for each project
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	RbacPolicy *int `json:"rbacPolicy,omitempty"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Router int `json:"router"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroup int `json:"securityGroup"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	SecurityGroupRule int `json:"securityGroupRule"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Subnet int `json:"subnet"`
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=2147483647
+	Subnetpool *int `json:"subnetpool,omitempty"`
+}
+
+// ProjectStatus defines the observed state of Project
+type ProjectStatus struct {
+	// ObservedGeneration is the generation of the project the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the project. Ready summarizes all other conditions
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// PlannedChanges are the mutations of OpenStack the last reconcile planned instead of executing them, as its region is in plan mode
+	// +optional
+	PlannedChanges []string `json:"plannedChanges,omitempty"`
+}
+
+// IsReady returns true if the Ready condition of the project is true for its current generation
+func (v *Project) IsReady() bool {
+	return isReady(v.Status.Conditions, v.Generation)
+}
+
+// UpdateProjectCondition updates the given condition within the project object and patches its status subresource
+func (r *Project) UpdateProjectCondition(ctx context.Context, reconcileClient client.Client, reason ProjectReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(ProjectReady), reason.projectStatus(), string(reason), message)
+}
+
+// UpdateRegionCondition updates the given condition within the project object and patches its status subresource
+func (r *Project) UpdateRegionCondition(ctx context.Context, reconcileClient client.Client, reason RegionReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(RegionReady), reason.regionStatus(), string(reason), message)
+}
+
+// UpdatePlannedChanges sets the planned changes within the project object and patches its status subresource if they changed
+func (r *Project) UpdatePlannedChanges(ctx context.Context, reconcileClient client.Client, changes []string) error {
+	if equality.Semantic.DeepEqual(r.Status.PlannedChanges, changes) {
+		return nil
+	}
+
+	oldProject := r.DeepCopy()
+
+	r.Status.PlannedChanges = changes
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldProject))
+}
+
+// UpdatePausedCondition sets or removes the Paused condition of the project and patches its status subresource if it changed
+func (r *Project) UpdatePausedCondition(ctx context.Context, reconcileClient client.Client, paused bool) error {
+	oldProject := r.DeepCopy()
+
+	if !setPaused(&r.Status.Conditions, r.Generation, paused) {
+		return nil
+	}
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldProject))
+}
+
+func (r *Project) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
+	oldProject := r.DeepCopy()
+
+	r.Status.ObservedGeneration = r.Generation
+	setCondition(&r.Status.Conditions, r.Generation, metav1.Condition{
+		Type:    typeString,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldProject))
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Project is the Schema for the projects API
+type Project struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProjectSpec   `json:"spec,omitempty"`
+	Status ProjectStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectList contains a list of Project
+type ProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Project `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Project{}, &ProjectList{})
+}
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package v1alpha1
+package v1beta1
 
 import (
 	"context"
@@ -23,7 +23,9 @@ import (
 
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -37,12 +39,13 @@ func (r *Project) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		WithDefaulter(&ProjectCustomDefaulter{}).
-		WithValidator(&ProjectCustomValidator{}).
+		WithValidator(&ProjectCustomValidator{Reader: mgr.GetAPIReader(), References: DanglingReferences}).
 		Complete()
 }
 
-//+kubebuilder:webhook:path=/mutate-pco-plusserver-com-v1alpha1-project,mutating=true,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=projects,verbs=create;update,versions=v1alpha1,name=mproject.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/mutate-pco-plusserver-com-v1beta1-project,mutating=true,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=projects,verbs=create;update,versions=v1beta1,name=mproject.kb.io,admissionReviewVersions=v1
 
+// +kubebuilder:object:generate=false
 type ProjectCustomDefaulter struct {
 	// TODO(user): Add more fields as needed for validation
 }
@@ -123,10 +126,14 @@ func intPointer(v int) *int {
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1alpha1-project,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=projects,verbs=create;update,versions=v1alpha1,name=vproject.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1beta1-project,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=projects,verbs=create;update,versions=v1beta1,name=vproject.kb.io,admissionReviewVersions=v1
 
+// +kubebuilder:object:generate=false
 type ProjectCustomValidator struct {
-	// TODO(user): Add more fields as needed for validation
+	// Reader looks up the referenced region. The API is read directly, as the cache of the manager may not contain it
+	Reader client.Reader
+	// References is the policy towards a missing region
+	References ReferencePolicy
 }
 
 var _ webhook.CustomValidator = &ProjectCustomValidator{}
@@ -143,7 +150,8 @@ func (v *ProjectCustomValidator) ValidateCreate(ctx context.Context, obj runtime
 		return nil, errors.New(".spec.region must be specified")
 	}
 
-	return nil, nil
+	//References are only checked on creation, so objects whose region got deleted can still be updated and finalized
+	return validateReference(ctx, v.Reader, v.References, ".spec.region", types.NamespacedName{Name: project.Spec.Region}, &Region{})
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -0,0 +1,97 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ReferencePolicy decides how the validating webhooks treat references to objects which don't exist
+type ReferencePolicy string
+
+const (
+	// ReferencePolicyReject rejects objects referencing missing objects
+	ReferencePolicyReject ReferencePolicy = "reject"
+	// ReferencePolicyWarn admits objects referencing missing objects with a warning, e.g. for GitOps tools applying all objects at once
+	ReferencePolicyWarn ReferencePolicy = "warn"
+)
+
+// DanglingReferences is the policy of the validating webhooks towards references to missing regions, users and projects
+var DanglingReferences = ReferencePolicyReject
+
+// ParseReferencePolicy parses the policy of the --dangling-references flag
+func ParseReferencePolicy(policy string) (ReferencePolicy, error) {
+	switch ReferencePolicy(policy) {
+	case ReferencePolicyReject, ReferencePolicyWarn:
+		return ReferencePolicy(policy), nil
+	}
+
+	return "", fmt.Errorf("unknown reference policy %s, expected %s or %s", policy, ReferencePolicyReject, ReferencePolicyWarn)
+}
+
+// validateReference looks up the object referenced by field. A missing object is rejected or warned about depending on the policy
+func validateReference(ctx context.Context, reader client.Reader, policy ReferencePolicy, field string, key types.NamespacedName, obj client.Object) (admission.Warnings, error) {
+	if reader == nil {
+		return nil, nil
+	}
+
+	err := reader.Get(ctx, key, obj)
+	if err == nil {
+		return nil, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("%s references %s, which doesn't exist", field, key.Name)
+	if key.Namespace != "" {
+		message = fmt.Sprintf("%s references %s, which doesn't exist in namespace %s", field, key.Name, key.Namespace)
+	}
+
+	if policy == ReferencePolicyWarn {
+		return admission.Warnings{message}, nil
+	}
+
+	return nil, errors.New(message)
+}
+
+// validateUniqueBinding rejects a second binding of the same user to the same project, as deleting either of them would revoke the access of both
+func validateUniqueBinding(ctx context.Context, reader client.Reader, upb *UserProjectBinding) error {
+	if reader == nil {
+		return nil
+	}
+
+	bindings := &UserProjectBindingList{}
+	if err := reader.List(ctx, bindings, client.InNamespace(upb.Namespace)); err != nil {
+		return err
+	}
+
+	for _, k := range bindings.Items {
+		if k.Name != upb.Name && k.Spec.User == upb.Spec.User && k.Spec.Project == upb.Spec.Project {
+			return fmt.Errorf("user %s is already bound to project %s by userprojectbinding %s", upb.Spec.User, upb.Spec.Project, k.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Reference validation", func() {
+	It("rejects projects of missing regions", func() {
+		project := &Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "project-dangling", Namespace: "default"},
+			Spec:       ProjectSpec{Region: "region-missing"},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(MatchError(ContainSubstring(".spec.region references region-missing")))
+
+		region := &Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-missing"},
+			Spec:       RegionSpec{SecretRef: &SecretRef{Name: "reseller-credentials"}},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(Succeed())
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+	})
+
+	It("rejects bindings of missing users and projects and duplicate bindings", func() {
+		upb := &UserProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "upb-dangling", Namespace: "default"},
+			Spec:       UserProjectBindingSpec{User: "user-bound", Project: "project-bound"},
+		}
+		Expect(k8sClient.Create(ctx, upb)).To(MatchError(ContainSubstring(".spec.user references user-bound")))
+
+		By("warning about them with the warn policy")
+		validator := &UserProjectBindingCustomValidator{Reader: k8sClient, References: ReferencePolicyWarn}
+		warnings, err := validator.ValidateCreate(ctx, upb)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(HaveLen(2))
+
+		user := &User{ObjectMeta: metav1.ObjectMeta{Name: "user-bound", Namespace: "default"}}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		Expect(k8sClient.Create(ctx, upb)).To(MatchError(ContainSubstring(".spec.project references project-bound")))
+
+		region := &Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-bound"},
+			Spec:       RegionSpec{SecretRef: &SecretRef{Name: "reseller-credentials"}},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(Succeed())
+		project := &Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "project-bound", Namespace: "default"},
+			Spec:       ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+		Expect(k8sClient.Create(ctx, upb)).To(Succeed())
+
+		duplicate := &UserProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "upb-duplicate", Namespace: "default"},
+			Spec:       upb.Spec,
+		}
+		Expect(k8sClient.Create(ctx, duplicate)).To(MatchError(ContainSubstring("already bound to project project-bound by userprojectbinding upb-dangling")))
+	})
+})
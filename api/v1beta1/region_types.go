@@ -0,0 +1,408 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// RegionSpec defines the desired state of Region.
+// The credentials are either referenced by SecretRef or, for regions created with v1alpha1, stored within the annotation LegacyCredentialsAnnotation.
+// Validation rules of the CRD can't access annotations, so only the webhook ensures exactly one of them is present and the legacy endpoint is immutable
+type RegionSpec struct {
+	// SecretRef references a Secret with the credentials of the PCO Reseller API in the following format:
+	// endpoint: string
+	// username: string
+	// password: string
+	//
+	// The namespace of the Secret defaults to the namespace of the operator
+	// +optional
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+
+	// OrphanCollection configures the periodic detection of OpenStack projects and users of this operator without a resource.
+	// Orphans are only reported, unless their deletion is enabled
+	// +optional
+	OrphanCollection *OrphanCollectionSpec `json:"orphanCollection,omitempty"`
+
+	// RateLimit limits the requests of the operator to the reseller API and Keystone of this region.
+	// It applies with its defaults if omitted
+	// +optional
+	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
+
+	// CircuitBreaker stops all requests to the APIs of this region for a while once they failed repeatedly.
+	// It applies with its defaults if omitted
+	// +optional
+	CircuitBreaker *CircuitBreakerSpec `json:"circuitBreaker,omitempty"`
+
+	// PlanMode records the mutations of OpenStack projects, users, memberships, roles and application credentials within this region
+	// in the status and events of the resources instead of executing them. Overrides the --plan-mode flag of the manager if set
+	// +optional
+	PlanMode *bool `json:"planMode,omitempty"`
+}
+
+// PlanModeEnabled returns true if mutations within the region are planned instead of executed, falling back to the default of the manager
+func (r *RegionSpec) PlanModeEnabled(managerDefault bool) bool {
+	if r.PlanMode != nil {
+		return *r.PlanMode
+	}
+
+	return managerDefault
+}
+
+// RateLimitSpec configures the token bucket limiting the requests to the APIs of a region
+type RateLimitSpec struct {
+	// RequestsPerSecond is the sustained rate of requests. Defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RequestsPerSecond *int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the number of requests which may be sent at once. Defaults to 20
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+
+	// MaxWait is how long a request waits for the rate limit before the reconcile is postponed. Defaults to 5 seconds
+	// +optional
+	MaxWait *metav1.Duration `json:"maxWait,omitempty"`
+}
+
+// CircuitBreakerSpec configures when requests to the APIs of a region are stopped
+type CircuitBreakerSpec struct {
+	// FailureThreshold is the number of consecutive failed requests opening the circuit. Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// OpenDuration is how long no requests are sent once the circuit opened. Defaults to 30 seconds
+	// +optional
+	OpenDuration *metav1.Duration `json:"openDuration,omitempty"`
+}
+
+// OrphanCollectionSpec configures the detection and deletion of orphaned OpenStack projects and users
+type OrphanCollectionSpec struct {
+	// Interval between two scans of the region. Defaults to one hour
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Delete enables the deletion of orphans once they were detected for longer than the grace period
+	// +optional
+	Delete bool `json:"delete,omitempty"`
+
+	// GracePeriod defines how long an orphan has to be detected before it gets deleted. Defaults to 24 hours
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// SecretRef defines the Reference to a Secret
+type SecretRef struct {
+	// Name of the Object
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the Object. Defaults to the namespace of the referencing object or, for cluster scoped objects, the namespace of the operator
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// NamespacedName returns the key of the referenced Secret, falling back to the given namespace
+func (s *SecretRef) NamespacedName(defaultNamespace string) types.NamespacedName {
+	namespace := s.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: s.Name}
+}
+
+// LegacyCredentialsAnnotation stores the inline credentials of regions created with v1alpha1, which v1beta1 has no fields for
+const LegacyCredentialsAnnotation = "pco.plusserver.com/v1alpha1-credentials"
+
+// LegacyCredentials are the inline credentials of a v1alpha1 region
+type LegacyCredentials struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// LegacyCredentials returns the inline credentials the region was created with in v1alpha1 or nil if it has none
+func (r *Region) LegacyCredentials() (*LegacyCredentials, error) {
+	value, ok := r.Annotations[LegacyCredentialsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	credentials := &LegacyCredentials{}
+	if err := json.Unmarshal([]byte(value), credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %w", LegacyCredentialsAnnotation, err)
+	}
+
+	return credentials, nil
+}
+
+// RegionStatus defines the observed state of Region
+type RegionStatus struct {
+	// ObservedGeneration is the generation of the region the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the region. Ready summarizes all other conditions
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ControllerID is the identifier of the operator, which all OpenStack project and user names within the region are prefixed or suffixed with
+	// +optional
+	ControllerID string `json:"controllerID,omitempty"`
+
+	// Orphans are the OpenStack projects and users named after this operator, which no resource exists for
+	// +optional
+	Orphans *OrphanStatus `json:"orphans,omitempty"`
+}
+
+// OrphanStatus stores the result of the last orphan scan of a region
+type OrphanStatus struct {
+	// LastScanTime is the time of the last successful scan
+	// +optional
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+
+	// Projects are the orphaned OpenStack projects
+	// +optional
+	Projects []OrphanedResource `json:"projects,omitempty"`
+
+	// Users are the orphaned OpenStack users
+	// +optional
+	Users []OrphanedResource `json:"users,omitempty"`
+}
+
+// OrphanedResource is an OpenStack project or user without resource
+type OrphanedResource struct {
+	// ID of the OpenStack project or user
+	ID string `json:"id"`
+
+	// Name of the OpenStack project or user
+	Name string `json:"name"`
+
+	// FirstSeen is the time the orphan was detected first
+	FirstSeen metav1.Time `json:"firstSeen"`
+}
+
+const (
+	defaultOrphanCollectionInterval    = 1 * time.Hour
+	defaultOrphanCollectionGracePeriod = 24 * time.Hour
+)
+
+// IntervalDuration returns the interval between two orphan scans, falling back to the default
+func (o *OrphanCollectionSpec) IntervalDuration() time.Duration {
+	if o != nil && o.Interval != nil {
+		return o.Interval.Duration
+	}
+
+	return defaultOrphanCollectionInterval
+}
+
+// GracePeriodDuration returns the time orphans are kept, falling back to the default
+func (o *OrphanCollectionSpec) GracePeriodDuration() time.Duration {
+	if o != nil && o.GracePeriod != nil {
+		return o.GracePeriod.Duration
+	}
+
+	return defaultOrphanCollectionGracePeriod
+}
+
+// DeletionEnabled returns true if orphans get deleted after the grace period
+func (o *OrphanCollectionSpec) DeletionEnabled() bool {
+	return o != nil && o.Delete
+}
+
+const (
+	defaultRateLimitRequestsPerSecond     = 10
+	defaultRateLimitBurst                 = 20
+	defaultRateLimitMaxWait               = 5 * time.Second
+	defaultCircuitBreakerFailureThreshold = 5
+	defaultCircuitBreakerOpenDuration     = 30 * time.Second
+)
+
+// RequestsPerSecondValue returns the sustained rate of requests, falling back to the default
+func (r *RateLimitSpec) RequestsPerSecondValue() int32 {
+	if r != nil && r.RequestsPerSecond != nil {
+		return *r.RequestsPerSecond
+	}
+
+	return defaultRateLimitRequestsPerSecond
+}
+
+// BurstValue returns the number of requests which may be sent at once, falling back to the default
+func (r *RateLimitSpec) BurstValue() int32 {
+	if r != nil && r.Burst != nil {
+		return *r.Burst
+	}
+
+	return defaultRateLimitBurst
+}
+
+// MaxWaitDuration returns how long a request waits for the rate limit, falling back to the default
+func (r *RateLimitSpec) MaxWaitDuration() time.Duration {
+	if r != nil && r.MaxWait != nil {
+		return r.MaxWait.Duration
+	}
+
+	return defaultRateLimitMaxWait
+}
+
+// FailureThresholdValue returns the number of consecutive failures opening the circuit, falling back to the default
+func (c *CircuitBreakerSpec) FailureThresholdValue() int32 {
+	if c != nil && c.FailureThreshold != nil {
+		return *c.FailureThreshold
+	}
+
+	return defaultCircuitBreakerFailureThreshold
+}
+
+// OpenDurationValue returns how long the circuit stays open, falling back to the default
+func (c *CircuitBreakerSpec) OpenDurationValue() time.Duration {
+	if c != nil && c.OpenDuration != nil {
+		return c.OpenDuration.Duration
+	}
+
+	return defaultCircuitBreakerOpenDuration
+}
+
+// IsReady returns true if the Ready condition of the region is true for its current generation
+func (v *Region) IsReady() bool {
+	return isReady(v.Status.Conditions, v.Generation)
+}
+
+// UpdateRegionCondition updates the given condition within the region resource and updates its status subresource
+func (r *Region) UpdateRegionCondition(ctx context.Context, reconcileClient client.Client, reason RegionReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(RegionReady), reason.regionStatus(), string(reason), message)
+}
+
+// UpdateControllerID stores the controller identifier and patches the status subresource of the region if it changed
+func (r *Region) UpdateControllerID(ctx context.Context, reconcileClient client.Client, controllerID string) error {
+	if r.Status.ControllerID == controllerID {
+		return nil
+	}
+
+	oldRegion := r.DeepCopy()
+	r.Status.ControllerID = controllerID
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+}
+
+// UpdateOrphans stores the result of an orphan scan and patches the status subresource of the region
+func (r *Region) UpdateOrphans(ctx context.Context, reconcileClient client.Client, orphans OrphanStatus) error {
+	oldRegion := r.DeepCopy()
+	r.Status.Orphans = &orphans
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+}
+
+// UpdatePausedCondition sets or removes the Paused condition of the region and patches its status subresource if it changed
+func (r *Region) UpdatePausedCondition(ctx context.Context, reconcileClient client.Client, paused bool) error {
+	oldRegion := r.DeepCopy()
+
+	if !setPaused(&r.Status.Conditions, r.Generation, paused) {
+		return nil
+	}
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+}
+
+// UpdateAPIConditions sets or removes the APIThrottled and APIUnavailable conditions of the region and patches its status subresource if they changed.
+// Empty messages remove the conditions
+func (r *Region) UpdateAPIConditions(ctx context.Context, reconcileClient client.Client, throttledMessage string, unavailableMessage string) error {
+	oldRegion := r.DeepCopy()
+
+	throttled := setPresent(&r.Status.Conditions, metav1.Condition{
+		Type:               string(APIThrottled),
+		Status:             metav1.ConditionTrue,
+		Reason:             apiThrottledReason,
+		Message:            throttledMessage,
+		ObservedGeneration: r.Generation,
+	}, throttledMessage != "")
+
+	unavailable := setPresent(&r.Status.Conditions, metav1.Condition{
+		Type:               string(APIUnavailable),
+		Status:             metav1.ConditionTrue,
+		Reason:             apiUnavailableReason,
+		Message:            unavailableMessage,
+		ObservedGeneration: r.Generation,
+	}, unavailableMessage != "")
+
+	if !throttled && !unavailable {
+		return nil
+	}
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+}
+
+func (r *Region) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
+	oldRegion := r.DeepCopy()
+
+	r.Status.ObservedGeneration = r.Generation
+	setCondition(&r.Status.Conditions, r.Generation, metav1.Condition{
+		Type:    typeString,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldRegion))
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Controller ID",type=string,JSONPath=`.status.controllerID`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+
+// Region is the Schema for the regions API
+type Region struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RegionSpec   `json:"spec,omitempty"`
+	Status RegionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RegionList contains a list of Region
+type RegionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Region `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Region{}, &RegionList{})
+}
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package v1alpha1
+package v1beta1
 
 import (
 	"context"
@@ -40,11 +40,10 @@ func (r *Region) SetupWebhookWithManager(mgr ctrl.Manager) error {
 		Complete()
 }
 
-//+kubebuilder:webhook:path=/mutate-pco-plusserver-com-v1alpha1-region,mutating=true,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=regions,verbs=create;update,versions=v1alpha1,name=mregion.kb.io,admissionReviewVersions=v1
-
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1alpha1-region,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=regions,verbs=create;update,versions=v1alpha1,name=vregion.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1beta1-region,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=regions,verbs=create;update,versions=v1beta1,name=vregion.kb.io,admissionReviewVersions=v1
 
+// +kubebuilder:object:generate=false
 type RegionCustomValidator struct {
 	// TODO(user): Add more fields as needed for validation
 }
@@ -59,24 +58,11 @@ func (v *RegionCustomValidator) ValidateCreate(ctx context.Context, obj runtime.
 	}
 	regionlog.Info("validate create", "name", region.Name)
 
-	if region.Spec.SecretRef == nil {
-		if utils.IsEmpty(region.Spec.Endpoint) {
-			return nil, errors.New("endpoint must be specified if no Secret is specified")
-		}
-		if utils.IsEmpty(region.Spec.Username) {
-			return nil, errors.New("username must be specified if no Secret is specified")
-		}
-		if utils.IsEmpty(region.Spec.Password) {
-			return nil, errors.New("password must be specified if no Secret is specified")
-		}
-	}
-
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil, nil
+	return validateRegionCredentials(region)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (v *RegionCustomValidator) ValidateUpdate(ctx context.Context, newObj runtime.Object, oldObj runtime.Object) (admission.Warnings, error) {
+func (v *RegionCustomValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
 	oldRegion, ok := oldObj.(*Region)
 	if !ok {
 		return nil, fmt.Errorf("expected a Region object but got %T", oldObj)
@@ -87,11 +73,19 @@ func (v *RegionCustomValidator) ValidateUpdate(ctx context.Context, newObj runti
 	}
 	regionlog.Info("validate update", "name", oldRegion.Name)
 
-	if oldRegion.Spec.Endpoint != newRegion.Spec.Endpoint {
+	oldCredentials, err := oldRegion.LegacyCredentials()
+	if err != nil {
+		return nil, err
+	}
+	newCredentials, err := newRegion.LegacyCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if oldCredentials != nil && newCredentials != nil && oldCredentials.Endpoint != newCredentials.Endpoint {
 		return nil, errors.New("endpoint is immutable")
 	}
 
-	return nil, nil
+	return validateRegionCredentials(newRegion)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -105,3 +99,36 @@ func (v *RegionCustomValidator) ValidateDelete(ctx context.Context, obj runtime.
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil, nil
 }
+
+// validateRegionCredentials requires a Secret with the credentials, unless the region was created with inline credentials in v1alpha1
+func validateRegionCredentials(region *Region) (admission.Warnings, error) {
+	credentials, err := region.LegacyCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if region.Spec.SecretRef != nil {
+		if utils.IsEmpty(region.Spec.SecretRef.Name) {
+			return nil, errors.New(".spec.secretRef.name must be specified")
+		}
+		if credentials != nil {
+			return nil, fmt.Errorf(".spec.secretRef and the inline credentials of annotation %s are mutually exclusive", LegacyCredentialsAnnotation)
+		}
+		return nil, nil
+	}
+
+	if credentials == nil {
+		return nil, errors.New(".spec.secretRef must be specified")
+	}
+	if utils.IsEmpty(credentials.Endpoint) {
+		return nil, errors.New("endpoint must be specified if no Secret is specified")
+	}
+	if utils.IsEmpty(credentials.Username) {
+		return nil, errors.New("username must be specified if no Secret is specified")
+	}
+	if utils.IsEmpty(credentials.Password) {
+		return nil, errors.New("password must be specified if no Secret is specified")
+	}
+
+	return admission.Warnings{"inline credentials are deprecated, please move them into a Secret referenced by .spec.secretRef"}, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Region validation", func() {
+	It("validates the updated region instead of the existing one", func() {
+		region := &Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-updated"},
+			Spec:       RegionSpec{SecretRef: &SecretRef{Name: "reseller-credentials"}},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(Succeed())
+
+		invalid := region.DeepCopy()
+		invalid.Spec.SecretRef = nil
+		Expect(k8sClient.Update(ctx, invalid)).To(MatchError(ContainSubstring(".spec.secretRef must be specified")))
+
+		By("rejecting the invalid update with the validator")
+		_, err := (&RegionCustomValidator{}).ValidateUpdate(ctx, region, invalid)
+		Expect(err).To(MatchError(".spec.secretRef must be specified"))
+
+		valid := region.DeepCopy()
+		valid.Spec.SecretRef.Name = "other-credentials"
+		Expect(k8sClient.Update(ctx, valid)).To(Succeed())
+	})
+
+	It("rejects an empty secret name by the schema", func() {
+		region := &Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-empty-secret"},
+			Spec:       RegionSpec{SecretRef: &SecretRef{}},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(MatchError(And(ContainSubstring("spec.secretRef.name"), ContainSubstring("at least 1 chars long"))))
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SecretTemplate defines where and how generated credentials are delivered
+type SecretTemplate struct {
+	// Name of the generated Secret, defaults to a name derived from the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the generated Secret, defaults to the namespace of the resource.
+	// Other namespaces must be allowed by the operator
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Labels are added to the generated Secret
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the generated Secret
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Vault additionally pushes the credentials into a HashiCorp Vault KV v2 secrets engine
+	// +optional
+	Vault *VaultSink `json:"vault,omitempty"`
+
+	// ExternalOnly skips the creation of the Kubernetes Secret, so credentials are only delivered to Vault
+	// +optional
+	ExternalOnly bool `json:"externalOnly,omitempty"`
+}
+
+// VaultSink defines the location of the credentials within HashiCorp Vault
+type VaultSink struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200
+	Address string `json:"address"`
+
+	// Mount is the path of the KV v2 secrets engine, defaults to secret
+	// +optional
+	Mount string `json:"mount,omitempty"`
+
+	// Path of the credentials within the secrets engine
+	Path string `json:"path"`
+
+	// TokenSecretRef references a Secret, which stores the Vault token within the key token.
+	// Its namespace has to be empty or the namespace of the resource
+	TokenSecretRef SecretRef `json:"tokenSecretRef"`
+}
+
+// secretName applies the template to the default name of a generated Secret
+func (t *SecretTemplate) secretName(defaultName types.NamespacedName) types.NamespacedName {
+	if t == nil {
+		return defaultName
+	}
+
+	if t.Name != "" {
+		defaultName.Name = t.Name
+	}
+	if t.Namespace != "" {
+		defaultName.Namespace = t.Namespace
+	}
+
+	return defaultName
+}
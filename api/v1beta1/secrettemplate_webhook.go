@@ -0,0 +1,76 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// validateSecretTemplate validates the template of a resource within namespace
+func validateSecretTemplate(template *SecretTemplate, namespace string) error {
+	if template == nil {
+		return nil
+	}
+
+	if template.ExternalOnly && template.Vault == nil {
+		return errors.New(".spec.secretTemplate.externalOnly requires .spec.secretTemplate.vault")
+	}
+
+	if template.Vault != nil {
+		if utils.IsEmpty(template.Vault.Address) {
+			return errors.New(".spec.secretTemplate.vault.address must be specified")
+		}
+		if utils.IsEmpty(template.Vault.Path) {
+			return errors.New(".spec.secretTemplate.vault.path must be specified")
+		}
+		if utils.IsEmpty(template.Vault.TokenSecretRef.Name) {
+			return errors.New(".spec.secretTemplate.vault.tokenSecretRef.name must be specified")
+		}
+		//The Vault token is read with the permissions of the operator, so it may only come from the namespace of the resource
+		if template.Vault.TokenSecretRef.Namespace != "" && template.Vault.TokenSecretRef.Namespace != namespace {
+			return errors.New(".spec.secretTemplate.vault.tokenSecretRef.namespace must be the namespace of the resource")
+		}
+	}
+
+	return nil
+}
+
+// validateSecretTemplateUpdate prevents moving delivered credentials, which would orphan them at their old location
+func validateSecretTemplateUpdate(oldTemplate *SecretTemplate, newTemplate *SecretTemplate) error {
+	oldName := oldTemplate.secretName(types.NamespacedName{})
+	newName := newTemplate.secretName(types.NamespacedName{})
+	if oldName != newName {
+		return errors.New(".spec.secretTemplate.name and .spec.secretTemplate.namespace are immutable")
+	}
+
+	var oldVault, newVault *VaultSink
+	if oldTemplate != nil {
+		oldVault = oldTemplate.Vault
+	}
+	if newTemplate != nil {
+		newVault = newTemplate.Vault
+	}
+	if !reflect.DeepEqual(oldVault, newVault) {
+		return errors.New(".spec.secretTemplate.vault is immutable")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Secret template validation", func() {
+	It("rejects vault tokens of other namespaces", func() {
+		user := &User{
+			ObjectMeta: metav1.ObjectMeta{Name: "user-vault", Namespace: "default"},
+			Spec: UserSpec{SecretTemplate: &SecretTemplate{Vault: &VaultSink{
+				Address:        "http://vault:8200",
+				Path:           "credentials",
+				TokenSecretRef: SecretRef{Name: "vault-token", Namespace: "kube-system"},
+			}}},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(MatchError(ContainSubstring(".spec.secretTemplate.vault.tokenSecretRef.namespace must be the namespace of the resource")))
+
+		user.Spec.SecretTemplate.Vault.TokenSecretRef.Namespace = "default"
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+	})
+})
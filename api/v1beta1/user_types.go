@@ -0,0 +1,148 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UserSpec defines the desired state of User
+type UserSpec struct {
+	// Description is a free-text field for storing information about the user
+	Description string `json:"description,omitempty"`
+	// Enabled represents if the user is enabled or not
+	Enabled *bool `json:"enabled,omitempty"`
+	// SecretTemplate customizes the Secret storing the user credentials
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+}
+
+// UserStatus defines the observed state of User
+type UserStatus struct {
+	// ObservedGeneration is the generation of the user the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions store the conditions of the user object
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// IsReady returns true if the Ready condition of the user is true for its current generation
+func (v *User) IsReady() bool {
+	return isReady(v.Status.Conditions, v.Generation)
+}
+
+// UpdateUserCondition updates the given condition in the user object and patches its status subresource
+func (r *User) UpdateUserCondition(ctx context.Context, reconcileClient client.Client, reason UserReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(UserReady), reason.userStatus(), string(reason), message)
+}
+
+// UpdatePausedCondition sets or removes the Paused condition of the user and patches its status subresource if it changed
+func (r *User) UpdatePausedCondition(ctx context.Context, reconcileClient client.Client, paused bool) error {
+	oldUser := r.DeepCopy()
+
+	if !setPaused(&r.Status.Conditions, r.Generation, paused) {
+		return nil
+	}
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
+}
+
+func (r *User) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
+	oldUser := r.DeepCopy()
+
+	r.Status.ObservedGeneration = r.Generation
+	setCondition(&r.Status.Conditions, r.Generation, metav1.Condition{
+		Type:    typeString,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// User is the Schema for the users API
+type User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserSpec   `json:"spec,omitempty"`
+	Status UserStatus `json:"status,omitempty"`
+}
+
+// Mail returns the e-mail address of the user
+func (u User) Mail(ctx context.Context, r client.Client) (*string, error) {
+	controllerId, err := utils.ControllerIdentifier(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	mail := u.MailFor(*controllerId)
+	return &mail, nil
+}
+
+// MailFor returns the e-mail address of the user for the given controller id
+func (u User) MailFor(controllerId string) string {
+	return fmt.Sprintf("%s-%s@%s.k8s", u.Name, u.Namespace, controllerId)
+}
+
+// UserAccessSecretName returns the secret name for the user object
+func (u User) UserAccessSecretName() types.NamespacedName {
+	return u.Spec.SecretTemplate.secretName(types.NamespacedName{
+		Namespace: u.Namespace,
+		Name:      fmt.Sprintf("%s-openstack", u.Name),
+	})
+}
+
+// DefaultUserProjectBindingName returns the default project for the user
+func (u User) DefaultUserProjectBindingName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: u.Namespace,
+		Name:      fmt.Sprintf("%s-default-project", u.Name),
+	}
+}
+
+//+kubebuilder:object:root=true
+
+// UserList contains a list of User
+type UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []User `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&User{}, &UserList{})
+}
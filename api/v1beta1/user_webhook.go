@@ -14,10 +14,11 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package v1alpha1
+package v1beta1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
@@ -42,7 +43,9 @@ func (r *User) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 // TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 
-// +kubebuilder:webhook:path=/mutate-pco-plusserver-com-v1alpha1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=users,verbs=create;update,versions=v1alpha1,name=muser.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/mutate-pco-plusserver-com-v1beta1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=users,verbs=create;update,versions=v1beta1,name=muser.kb.io,admissionReviewVersions=v1
+
+// +kubebuilder:object:generate=false
 type UserCustomDefaulter struct {
 	// TODO(user): Add more fields as needed for validation
 }
@@ -69,8 +72,9 @@ func (v *UserCustomDefaulter) Default(ctx context.Context, obj runtime.Object) e
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1alpha1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=users,verbs=create;update,versions=v1alpha1,name=vuser.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-pco-plusserver-com-v1beta1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=users,verbs=create;update,versions=v1beta1,name=vuser.kb.io,admissionReviewVersions=v1
 
+// +kubebuilder:object:generate=false
 type UserCustomValidator struct {
 	// TODO(user): Add more fields as needed for validation
 }
@@ -85,21 +89,45 @@ func (v *UserCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Ob
 	}
 	userlog.Info("validate create", "name", user.Name)
 
+	if err := validateUserSecretTemplate(user.Spec.SecretTemplate, user.Namespace); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (v *UserCustomValidator) ValidateUpdate(ctx context.Context, newObj runtime.Object, oldObj runtime.Object) (admission.Warnings, error) {
+func (v *UserCustomValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
 	user, ok := oldObj.(*User)
 	if !ok {
 		return nil, fmt.Errorf("expected a User object but got %T", oldObj)
 	}
+	newUser, ok := newObj.(*User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User object but got %T", newObj)
+	}
 	userlog.Info("validate update", "name", user.Name)
 
-	//All currently defined fields are mutable
+	if err := validateUserSecretTemplate(newUser.Spec.SecretTemplate, newUser.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := validateSecretTemplateUpdate(user.Spec.SecretTemplate, newUser.Spec.SecretTemplate); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
+func validateUserSecretTemplate(template *SecretTemplate, namespace string) error {
+	//The operator reads the password of the user from the Secret
+	if template != nil && template.ExternalOnly {
+		return errors.New(".spec.secretTemplate.externalOnly is not supported for users")
+	}
+
+	return validateSecretTemplate(template, namespace)
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (v *UserCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	user, ok := obj.(*User)
@@ -0,0 +1,321 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UserProjectBindingSpec defines the desired state of UserProjectBinding
+type UserProjectBindingSpec struct {
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="project is immutable"
+	Project string `json:"project"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="user is immutable"
+	User string `json:"user"`
+	// Roles are the Keystone roles granted to the user within the project, e.g. member, reader, load-balancer_member or creator.
+	// Roles not listed are removed from the user. If empty, the default role of the reseller API is granted and roles are not managed
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+	// ApplicationCredential causes an application credential to be issued for the binding, if specified
+	// +optional
+	ApplicationCredential *ApplicationCredentialSpec `json:"applicationCredential,omitempty"`
+	// SecretTemplate customizes the delivery of the application credential
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+}
+
+// ApplicationCredentialSpec defines the lifecycle of the application credential issued for a binding
+type ApplicationCredentialSpec struct {
+	// ExpiresAfter defines how long an issued application credential stays valid.
+	// If unset, the application credential never expires and is never rotated
+	// +optional
+	ExpiresAfter *metav1.Duration `json:"expiresAfter,omitempty"`
+
+	// RotateBefore defines how long before its expiry the application credential gets replaced.
+	// Defaults to a fifth of ExpiresAfter
+	// +optional
+	RotateBefore *metav1.Duration `json:"rotateBefore,omitempty"`
+
+	// GracePeriod defines how long the replaced application credential stays valid after a rotation.
+	// Defaults to one hour
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// Roles restricts the application credential to a subset of the roles granted by .spec.roles, or of the default role member if .spec.roles is empty.
+	// If empty, the application credential inherits all roles of the user
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// Unrestricted allows the application credential to create further application credentials and trusts
+	// +optional
+	Unrestricted bool `json:"unrestricted,omitempty"`
+
+	// AccessRules restrict the API calls the application credential may perform.
+	// If empty, all API calls permitted by the roles are allowed
+	// +optional
+	AccessRules []ApplicationCredentialAccessRule `json:"accessRules,omitempty"`
+}
+
+// ApplicationCredentialAccessRule permits a single kind of API call
+type ApplicationCredentialAccessRule struct {
+	// Service is the service type identifier, e.g. compute or identity
+	Service string `json:"service"`
+
+	// Method is the permitted request method
+	// +kubebuilder:validation:Enum=HEAD;GET;POST;PUT;PATCH;DELETE
+	Method string `json:"method"`
+
+	// Path is the permitted API path, which may contain wildcards, e.g. /v2.1/servers/*
+	Path string `json:"path"`
+}
+
+// DefaultRole is the role the reseller API grants to members of a project
+const DefaultRole = "member"
+
+// GrantedRoles returns the roles the user holds within the project, falling back to the default role of the reseller API
+func (s UserProjectBindingSpec) GrantedRoles() []string {
+	if len(s.Roles) == 0 {
+		return []string{DefaultRole}
+	}
+
+	return s.Roles
+}
+
+// defaultApplicationCredentialGracePeriod is used if no grace period is specified
+const defaultApplicationCredentialGracePeriod = 1 * time.Hour
+
+// RotateBeforeDuration returns the rotation lead time, falling back to a fifth of the credential lifetime
+func (a ApplicationCredentialSpec) RotateBeforeDuration() time.Duration {
+	if a.RotateBefore != nil {
+		return a.RotateBefore.Duration
+	}
+
+	if a.ExpiresAfter != nil {
+		return a.ExpiresAfter.Duration / 5
+	}
+
+	return 0
+}
+
+// GracePeriodDuration returns the grace period of replaced credentials, falling back to the default
+func (a ApplicationCredentialSpec) GracePeriodDuration() time.Duration {
+	if a.GracePeriod != nil {
+		return a.GracePeriod.Duration
+	}
+
+	return defaultApplicationCredentialGracePeriod
+}
+
+// LegacyApplicationCredentialOptionsAnnotation stores the application credential options of bindings created with v1alpha1,
+// which v1beta1 can't express, as they lack applicationCredential or are empty
+const LegacyApplicationCredentialOptionsAnnotation = "pco.plusserver.com/v1alpha1-application-credential-options"
+
+// LegacyApplicationCredentialOptions returns the application credential options the binding was created with in v1alpha1
+// or nil if they are expressed by the spec
+func (r *UserProjectBinding) LegacyApplicationCredentialOptions() (*ApplicationCredentialSpec, error) {
+	value, ok := r.Annotations[LegacyApplicationCredentialOptionsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	options := &ApplicationCredentialSpec{}
+	if err := json.Unmarshal([]byte(value), options); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %w", LegacyApplicationCredentialOptionsAnnotation, err)
+	}
+
+	return options, nil
+}
+
+// UserProjectBindingStatus defines the observed state of UserProjectBinding
+type UserProjectBindingStatus struct {
+	// ObservedGeneration is the generation of the userprojectbinding the conditions were last updated for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the userprojectbinding. Ready summarizes all other conditions
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ApplicationCredential stores the state of the issued application credentials
+	// +optional
+	ApplicationCredential *ApplicationCredentialStatus `json:"applicationCredential,omitempty"`
+
+	// PlannedChanges are the mutations of OpenStack the last reconcile planned instead of executing them, as its region is in plan mode
+	// +optional
+	PlannedChanges []string `json:"plannedChanges,omitempty"`
+
+	// UserSecretUID is the uid of the secret of the user, whose password was last set on the OpenStack user
+	// +optional
+	UserSecretUID types.UID `json:"userSecretUID,omitempty"`
+}
+
+// ApplicationCredentialStatus defines the observed state of the issued application credentials
+type ApplicationCredentialStatus struct {
+	// CurrentID is the id of the application credential stored in the secret
+	// +optional
+	CurrentID string `json:"currentId,omitempty"`
+
+	// CurrentExpiresAt is the expiry of the current application credential
+	// +optional
+	CurrentExpiresAt *metav1.Time `json:"currentExpiresAt,omitempty"`
+
+	// CurrentRoles are the roles the current application credential was restricted to, empty if it inherits all roles
+	// +optional
+	CurrentRoles []string `json:"currentRoles,omitempty"`
+
+	// PreviousID is the id of the replaced application credential, which is still valid during the grace period
+	// +optional
+	PreviousID string `json:"previousId,omitempty"`
+
+	// PreviousExpiresAt is the point in time at which the replaced application credential gets deleted
+	// +optional
+	PreviousExpiresAt *metav1.Time `json:"previousExpiresAt,omitempty"`
+
+	// PendingID is the id of an issued application credential whose secret may not have been written yet.
+	// It gets adopted once its secret was written and revoked otherwise
+	// +optional
+	PendingID string `json:"pendingId,omitempty"`
+}
+
+// IsReady returns true if the Ready condition of the userprojectbinding is true for its current generation
+func (v *UserProjectBinding) IsReady() bool {
+	return isReady(v.Status.Conditions, v.Generation)
+}
+
+// UpdateUserProjectBindingCondition updates the given condition within the userprojectbinding resource and patches its status subresource
+func (r *UserProjectBinding) UpdateUserProjectBindingCondition(ctx context.Context, reconcileClient client.Client, reason UserProjectBindingReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(UserProjectBindingReady), reason.userProjectBindingStatus(), string(reason), message)
+}
+
+// UpdateUserCondition updates the given condition within the userprojectbinding resource and patches its status subresource
+func (r *UserProjectBinding) UpdateUserCondition(ctx context.Context, reconcileClient client.Client, reason UserReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(UserReady), reason.userStatus(), string(reason), message)
+}
+
+// UpdateProjectCondition updates the given condition within the userprojectbinding resource and patches its status subresource
+func (r *UserProjectBinding) UpdateProjectCondition(ctx context.Context, reconcileClient client.Client, reason ProjectReadyReasons, message string) error {
+	return r.updateCondition(ctx, reconcileClient, string(ProjectReady), reason.projectStatus(), string(reason), message)
+}
+
+// UpdateApplicationCredentialStatus sets the application credential state within the userprojectbinding resource and patches its status subresource
+func (r *UserProjectBinding) UpdateApplicationCredentialStatus(ctx context.Context, reconcileClient client.Client, status *ApplicationCredentialStatus) error {
+	oldUpb := r.DeepCopy()
+
+	r.Status.ApplicationCredential = status
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUpb))
+}
+
+// UpdateUserSecretUID records the secret of the user, whose password was set on the OpenStack user, and patches its status subresource
+func (r *UserProjectBinding) UpdateUserSecretUID(ctx context.Context, reconcileClient client.Client, uid types.UID) error {
+	oldUpb := r.DeepCopy()
+
+	r.Status.UserSecretUID = uid
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUpb))
+}
+
+// UpdatePlannedChanges sets the planned changes within the userprojectbinding resource and patches its status subresource if they changed
+func (r *UserProjectBinding) UpdatePlannedChanges(ctx context.Context, reconcileClient client.Client, changes []string) error {
+	if equality.Semantic.DeepEqual(r.Status.PlannedChanges, changes) {
+		return nil
+	}
+
+	oldUpb := r.DeepCopy()
+
+	r.Status.PlannedChanges = changes
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUpb))
+}
+
+// UpdatePausedCondition sets or removes the Paused condition of the userprojectbinding and patches its status subresource if it changed
+func (r *UserProjectBinding) UpdatePausedCondition(ctx context.Context, reconcileClient client.Client, paused bool) error {
+	oldUser := r.DeepCopy()
+
+	if !setPaused(&r.Status.Conditions, r.Generation, paused) {
+		return nil
+	}
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
+}
+
+func (r *UserProjectBinding) updateCondition(ctx context.Context, reconcileClient client.Client, typeString string, status metav1.ConditionStatus, reason string, message string) error {
+	oldUser := r.DeepCopy()
+
+	r.Status.ObservedGeneration = r.Generation
+	setCondition(&r.Status.Conditions, r.Generation, metav1.Condition{
+		Type:    typeString,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+
+	return reconcileClient.Status().Patch(ctx, r, client.MergeFrom(oldUser))
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UserProjectBinding is the Schema for the userprojectbindings API
+type UserProjectBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserProjectBindingSpec   `json:"spec,omitempty"`
+	Status UserProjectBindingStatus `json:"status,omitempty"`
+}
+
+// ApplicationCredentialName returns the target application credential name
+func (upb UserProjectBinding) ApplicationCredentialName() string {
+	return fmt.Sprintf("%s-appcred", upb.Name)
+}
+
+// ApplicationCredentialSecretName returns the name of the secret storing the application credential
+func (upb UserProjectBinding) ApplicationCredentialSecretName() types.NamespacedName {
+	return upb.Spec.SecretTemplate.secretName(types.NamespacedName{
+		Namespace: upb.Namespace,
+		Name:      upb.ApplicationCredentialName(),
+	})
+}
+
+//+kubebuilder:object:root=true
+
+// UserProjectBindingList contains a list of UserProjectBinding
+type UserProjectBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UserProjectBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserProjectBinding{}, &UserProjectBindingList{})
+}
@@ -0,0 +1,245 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var userprojectbindinglog = logf.Log.WithName("userprojectbinding-resource")
+
+// SetupWebhookWithManager registers the webhook within the manager
+func (r *UserProjectBinding) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		WithValidator(&UserProjectBindingCustomValidator{Reader: mgr.GetAPIReader(), References: DanglingReferences}).
+		For(r).
+		Complete()
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// +kubebuilder:webhook:path=/validate-pco-plusserver-com-v1beta1-userprojectbinding,mutating=false,failurePolicy=fail,sideEffects=None,groups=pco.plusserver.com,resources=userprojectbindings,verbs=create;update,versions=v1beta1,name=vuserprojectbinding.kb.io,admissionReviewVersions=v1
+
+// +kubebuilder:object:generate=false
+type UserProjectBindingCustomValidator struct {
+	// Reader looks up the referenced user and project and the other bindings of the namespace.
+	// The API is read directly, as the cache of the manager may not contain them
+	Reader client.Reader
+	// References is the policy towards a missing user or project
+	References ReferencePolicy
+}
+
+var _ webhook.CustomValidator = &UserProjectBindingCustomValidator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (v *UserProjectBindingCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	upb, ok := obj.(*UserProjectBinding)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserProjectBinding object but got %T", obj)
+	}
+	userprojectbindinglog.Info("validate create", "name", upb.Name)
+
+	if utils.IsEmpty(upb.Spec.Project) {
+		return nil, errors.New(".spec.project must be specified")
+	}
+
+	if utils.IsEmpty(upb.Spec.User) {
+		return nil, errors.New(".spec.user must be specified")
+	}
+
+	if err := validateRoles(upb.Spec.Roles); err != nil {
+		return nil, err
+	}
+
+	if err := validateApplicationCredential(upb.Spec.ApplicationCredential); err != nil {
+		return nil, err
+	}
+
+	if err := validateApplicationCredentialRoles(upb.Spec.ApplicationCredential, upb.Spec.GrantedRoles()); err != nil {
+		return nil, err
+	}
+
+	if err := validateSecretTemplate(upb.Spec.SecretTemplate, upb.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := validateUniqueBinding(ctx, v.Reader, upb); err != nil {
+		return nil, err
+	}
+
+	//References are only checked on creation, so bindings whose user or project got deleted can still be updated and finalized
+	warnings, err := validateReference(ctx, v.Reader, v.References, ".spec.user", types.NamespacedName{Namespace: upb.Namespace, Name: upb.Spec.User}, &User{})
+	if err != nil {
+		return warnings, err
+	}
+
+	projectWarnings, err := validateReference(ctx, v.Reader, v.References, ".spec.project", types.NamespacedName{Namespace: upb.Namespace, Name: upb.Spec.Project}, &Project{})
+	return append(warnings, projectWarnings...), err
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (v *UserProjectBindingCustomValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	newUpb, ok := newObj.(*UserProjectBinding)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserProjectBinding object but got %T", newObj)
+	}
+	oldUpb, ok := oldObj.(*UserProjectBinding)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserProjectBinding object but got %T", oldObj)
+	}
+	userprojectbindinglog.Info("validate update", "name", newUpb.Name)
+
+	if oldUpb.Spec.Project != newUpb.Spec.Project {
+		return nil, errors.New(".spec.project is immutable")
+	}
+	if oldUpb.Spec.User != newUpb.Spec.User {
+		return nil, errors.New(".spec.user is immutable")
+	}
+
+	if err := validateRoles(newUpb.Spec.Roles); err != nil {
+		return nil, err
+	}
+
+	if err := validateApplicationCredential(newUpb.Spec.ApplicationCredential); err != nil {
+		return nil, err
+	}
+
+	//Bindings admitted before the roles of application credentials were checked stay updatable until their roles change
+	if rolesChanged(oldUpb.Spec, newUpb.Spec) {
+		if err := validateApplicationCredentialRoles(newUpb.Spec.ApplicationCredential, newUpb.Spec.GrantedRoles()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateSecretTemplate(newUpb.Spec.SecretTemplate, newUpb.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := validateSecretTemplateUpdate(oldUpb.Spec.SecretTemplate, newUpb.Spec.SecretTemplate); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func validateRoles(roles []string) error {
+	seen := map[string]bool{}
+	for _, k := range roles {
+		if utils.IsEmpty(k) {
+			return errors.New(".spec.roles must not contain empty role names")
+		}
+		if seen[k] {
+			return fmt.Errorf(".spec.roles contains %s multiple times", k)
+		}
+		seen[k] = true
+	}
+
+	return nil
+}
+
+func validateApplicationCredential(appCred *ApplicationCredentialSpec) error {
+	if appCred == nil {
+		return nil
+	}
+
+	if appCred.ExpiresAfter == nil {
+		if appCred.RotateBefore != nil {
+			return errors.New(".spec.applicationCredential.rotateBefore requires .spec.applicationCredential.expiresAfter")
+		}
+	} else {
+		if appCred.ExpiresAfter.Duration <= 0 {
+			return errors.New(".spec.applicationCredential.expiresAfter must be positive")
+		}
+		if appCred.RotateBeforeDuration() <= 0 || appCred.RotateBeforeDuration() >= appCred.ExpiresAfter.Duration {
+			return errors.New(".spec.applicationCredential.rotateBefore must be positive and shorter than .spec.applicationCredential.expiresAfter")
+		}
+	}
+
+	if appCred.GracePeriod != nil && appCred.GracePeriod.Duration < 0 {
+		return errors.New(".spec.applicationCredential.gracePeriod must not be negative")
+	}
+
+	for _, k := range appCred.Roles {
+		if utils.IsEmpty(k) {
+			return errors.New(".spec.applicationCredential.roles must not contain empty role names")
+		}
+	}
+
+	for _, k := range appCred.AccessRules {
+		if utils.IsEmpty(k.Service) || utils.IsEmpty(k.Method) || utils.IsEmpty(k.Path) {
+			return errors.New(".spec.applicationCredential.accessRules require service, method and path")
+		}
+	}
+
+	return nil
+}
+
+// validateApplicationCredentialRoles ensures the application credential is restricted to roles granted by the binding
+func validateApplicationCredentialRoles(appCred *ApplicationCredentialSpec, granted []string) error {
+	if appCred == nil {
+		return nil
+	}
+
+	for _, k := range appCred.Roles {
+		//Keystone rejects credentials with roles the user lacks, and only the roles granted by the binding are known
+		if !slices.Contains(granted, k) {
+			return fmt.Errorf(".spec.applicationCredential.roles must be a subset of .spec.roles or the default role %s, %s isn't granted", DefaultRole, k)
+		}
+	}
+
+	return nil
+}
+
+// rolesChanged returns whether the roles of the binding or of its application credential changed
+func rolesChanged(oldSpec UserProjectBindingSpec, newSpec UserProjectBindingSpec) bool {
+	if !slices.Equal(oldSpec.Roles, newSpec.Roles) {
+		return true
+	}
+
+	var oldRoles, newRoles []string
+	if oldSpec.ApplicationCredential != nil {
+		oldRoles = oldSpec.ApplicationCredential.Roles
+	}
+	if newSpec.ApplicationCredential != nil {
+		newRoles = newSpec.ApplicationCredential.Roles
+	}
+
+	return !slices.Equal(oldRoles, newRoles)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (v *UserProjectBindingCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	upb, ok := obj.(*UserProjectBinding)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserProjectBinding object but got %T", obj)
+	}
+	userprojectbindinglog.Info("validate delete", "name", upb.Name)
+
+	// TODO(user): fill in your validation logic upon object deletion.
+	return nil, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 PlusServer GmbH
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UserProjectBinding validation", func() {
+	DescribeTable("restricts application credentials to the granted roles",
+		func(roles []string, applicationCredentialRoles []string, valid bool) {
+			spec := UserProjectBindingSpec{Roles: roles}
+			err := validateApplicationCredentialRoles(&ApplicationCredentialSpec{Roles: applicationCredentialRoles}, spec.GrantedRoles())
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(".spec.applicationCredential.roles must be a subset of .spec.roles")))
+			}
+		},
+		Entry("inheriting all roles", []string{"member"}, nil, true),
+		Entry("inheriting the default role", nil, nil, true),
+		Entry("subset of the granted roles", []string{"member", "reader"}, []string{"reader"}, true),
+		Entry("all granted roles", []string{"member", "reader"}, []string{"reader", "member"}, true),
+		Entry("role which isn't granted", []string{"member"}, []string{"admin"}, false),
+		Entry("partially granted roles", []string{"member"}, []string{"member", "reader"}, false),
+		Entry("the default role while no roles are specified", nil, []string{DefaultRole}, true),
+		Entry("another role while no roles are specified", nil, []string{"reader"}, false),
+	)
+
+	DescribeTable("checks the roles of application credentials on update only if roles changed",
+		func(oldRoles []string, newRoles []string, applicationCredentialRoles []string, valid bool) {
+			binding := func(roles []string) *UserProjectBinding {
+				return &UserProjectBinding{Spec: UserProjectBindingSpec{
+					Project:               "project",
+					User:                  "user",
+					Roles:                 roles,
+					ApplicationCredential: &ApplicationCredentialSpec{Roles: applicationCredentialRoles},
+				}}
+			}
+
+			_, err := (&UserProjectBindingCustomValidator{}).ValidateUpdate(context.Background(), binding(oldRoles), binding(newRoles))
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(".spec.applicationCredential.roles must be a subset of .spec.roles")))
+			}
+		},
+		Entry("unchanged binding admitted before its roles were checked", []string{"member"}, []string{"member"}, []string{"admin"}, true),
+		Entry("changed roles which don't grant the application credential roles", []string{"member", "reader"}, []string{"member"}, []string{"reader"}, false),
+		Entry("changed roles which grant the application credential roles", []string{"member"}, []string{"member", "reader"}, []string{"reader"}, true),
+		Entry("roles removed in favour of the default role", []string{"reader"}, nil, []string{DefaultRole}, true),
+	)
+
+	It("checks the roles of application credentials on update if they changed", func() {
+		old := &UserProjectBinding{Spec: UserProjectBindingSpec{Project: "project", User: "user", Roles: []string{"member"}, ApplicationCredential: &ApplicationCredentialSpec{}}}
+		updated := old.DeepCopy()
+		updated.Spec.ApplicationCredential.Roles = []string{"admin"}
+
+		_, err := (&UserProjectBindingCustomValidator{}).ValidateUpdate(context.Background(), old, updated)
+		Expect(err).To(MatchError(ContainSubstring(".spec.applicationCredential.roles must be a subset of .spec.roles")))
+	})
+})
@@ -17,9 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"math/rand"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	pcocontroller "github.com/pluscontainer/pco-reseller-operator/internal/controller"
@@ -27,15 +32,26 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -47,21 +63,68 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	//v1alpha1 is only served through the conversion webhook, which requires all versions within the scheme
 	utilruntime.Must(pcov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(pcov1beta1.AddToScheme(scheme))
 
 	rand.Seed(time.Now().UnixNano())
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	//The rekey subcommand migrates the OpenStack names to another controller id instead of running the manager
+	if len(os.Args) > 1 && os.Args[1] == "rekey" {
+		os.Exit(rekey(os.Args[2:]))
+	}
+
 	var metricsAddr string
+	var controllerId string
 	var enableLeaderElection bool
 	var probeAddr string
+	var secretNamespaces string
+	var watchNamespaces string
+	var instanceName string
+	var instanceSelector string
+	var inventoryRefreshInterval time.Duration
+	var planMode bool
+	var auditLogFile string
+	var danglingReferences string
+	var tracingOpts tracing.Options
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&secretNamespaces, "secret-namespaces", "",
+		"Comma separated list of namespaces into which credentials of other namespaces may be delivered. "+
+			"Use * to allow all namespaces.")
+	flag.StringVar(&tracingOpts.Endpoint, "tracing-endpoint", "",
+		"The host:port of the OTLP/HTTP collector to export traces to. Tracing is disabled if empty.")
+	flag.BoolVar(&tracingOpts.Insecure, "tracing-insecure", false, "Disable TLS towards the OTLP/HTTP collector.")
+	flag.Float64Var(&tracingOpts.SampleRatio, "tracing-sample-ratio", 1, "The ratio of reconciles which get traced.")
+	flag.StringVar(&controllerId, "controller-id", "",
+		"The identifier all OpenStack project and user names are derived from. "+
+			"If empty, the stored one is used or a random one is generated on the first start.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated list of namespaces whose projects, users and userprojectbindings are reconciled. "+
+			"All namespaces are watched if empty.")
+	flag.StringVar(&instanceSelector, "instance-selector", "",
+		"Label selector restricting the reconciled regions, projects, users and userprojectbindings, "+
+			"e.g. pco.plusserver.com/instance=staging.")
+	flag.StringVar(&instanceName, "instance-name", "",
+		"Name of this operator instance, which the leader election id is derived from. "+
+			"Required to run multiple instances within one cluster.")
+	flag.DurationVar(&inventoryRefreshInterval, "inventory-refresh-interval", time.Minute,
+		"The age after which the cached OpenStack projects and users of a region are listed again on lookup.")
+	flag.BoolVar(&planMode, "plan-mode", false,
+		"Record the mutations of OpenStack in the status and events of the resources instead of executing them. "+
+			"Regions may override it with spec.planMode.")
+	flag.StringVar(&auditLogFile, "audit-log-file", "",
+		"File to append the audit records of all OpenStack mutations to as JSON lines. "+
+			"They are written to the log of the manager if empty.")
+	flag.StringVar(&danglingReferences, "dangling-references", string(pcov1beta1.ReferencePolicyReject),
+		"Whether the webhooks reject or warn about projects and userprojectbindings referencing a missing region, user or project "+
+			"(reject or warn).")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -70,14 +133,70 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	allowedSecretNamespaces := splitList(secretNamespaces)
+	reseller.SetInventoryRefreshInterval(inventoryRefreshInterval)
+
+	if errs := validation.IsDNS1123Label(instanceName); instanceName != "" && len(errs) > 0 {
+		setupLog.Error(errors.New(strings.Join(errs, ", ")), "invalid instance name")
+		os.Exit(1)
+	}
+
+	referencePolicy, err := pcov1beta1.ParseReferencePolicy(danglingReferences)
+	if err != nil {
+		setupLog.Error(err, "invalid dangling references policy")
+		os.Exit(1)
+	}
+	pcov1beta1.DanglingReferences = referencePolicy
+
+	cacheOpts, err := cacheOptions(splitList(watchNamespaces), allowedSecretNamespaces, instanceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid instance selector")
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	//The file stays open for the lifetime of the process, every record is written through
+	if auditLogFile != "" {
+		auditWriter, err := audit.NewFileWriter(auditLogFile)
+		if err != nil {
+			setupLog.Error(err, "unable to open audit log file")
+			os.Exit(1)
+		}
+		audit.SetWriter(auditWriter)
+	} else {
+		audit.SetWriter(audit.NewLogWriter(ctrl.Log.WithName("audit")))
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+
+	//The cache of the manager isn't running yet
+	setupClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	controllerIdentifier, err := utils.EnsureControllerIdentifier(context.Background(), setupClient, controllerId)
+	if err != nil {
+		setupLog.Error(err, "invalid controller id")
+		os.Exit(1)
+	}
+	setupLog.Info("using controller id", "controllerId", controllerIdentifier)
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
 		HealthProbeBindAddress: probeAddr,
+		Cache:                  cacheOpts,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "d2def39d.plusserver.com",
+		LeaderElectionID:       leaderElectionID(instanceName),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -95,52 +214,94 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = pcocontroller.SetupFieldIndexes(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+
+	if err = metrics.Registry.Register(pcocontroller.NewResourceCollector(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
 	if err = (&pcocontroller.ProjectReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("project-controller"),
+		NewResellerClient: reseller.Login,
+		PlanMode:          planMode,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Project")
 		os.Exit(1)
 	}
 	if err = (&pcocontroller.UserReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorderFor("user-controller"),
+		AllowedSecretNamespaces: allowedSecretNamespaces,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "User")
 		os.Exit(1)
 	}
 	if err = (&pcocontroller.UserProjectBindingReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorderFor("userprojectbinding-controller"),
+		AllowedSecretNamespaces: allowedSecretNamespaces,
+		NewResellerClient:       reseller.Login,
+		KeystoneEndpoint:        pcocontroller.OpenStackIdentityEndpoint,
+		PlanMode:                planMode,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UserProjectBinding")
 		os.Exit(1)
 	}
 	if err = (&pcocontroller.RegionReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("region-controller"),
+		NewResellerClient: reseller.Login,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Region")
 		os.Exit(1)
 	}
-	if err = (&pcov1alpha1.Project{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&pcocontroller.OrphanReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("orphan-controller"),
+		APIReader:         mgr.GetAPIReader(),
+		NewResellerClient: reseller.Login,
+		PlanMode:          planMode,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Orphan")
+		os.Exit(1)
+	}
+	if err = (&pcov1beta1.Project{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Project")
 		os.Exit(1)
 	}
-	if err = (&pcov1alpha1.Region{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&pcov1beta1.Region{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Region")
 		os.Exit(1)
 	}
-	if err = (&pcov1alpha1.User{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&pcov1beta1.User{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "User")
 		os.Exit(1)
 	}
-	if err = (&pcov1alpha1.UserProjectBinding{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&pcov1beta1.UserProjectBinding{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "UserProjectBinding")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
 
+	//Flush pending spans once the manager stops
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return shutdownTracing(context.Background())
+	})); err != nil {
+		setupLog.Error(err, "unable to set up tracing shutdown")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -156,3 +317,96 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// rekey renames the OpenStack projects and users of all regions to another controller id and returns the exit code
+func rekey(args []string) int {
+	var rekeyOpts pcocontroller.RekeyOptions
+	flags := flag.NewFlagSet("rekey", flag.ExitOnError)
+	flags.StringVar(&rekeyOpts.From, "from", "", "The current controller id.")
+	flags.StringVar(&rekeyOpts.To, "to", "", "The new controller id.")
+	flags.BoolVar(&rekeyOpts.DryRun, "dry-run", false, "Only log the renames without changing anything.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flags)
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		return 1
+	}
+
+	ctx := ctrl.LoggerInto(ctrl.SetupSignalHandler(), ctrl.Log.WithName("rekey"))
+	if err := pcocontroller.Rekey(ctx, c, rekeyOpts); err != nil {
+		setupLog.Error(err, "rekey failed")
+		return 1
+	}
+
+	return 0
+}
+
+// splitList splits a comma separated flag value, skipping empty items
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			items = append(items, k)
+		}
+	}
+
+	return items
+}
+
+// leaderElectionID returns the id of the leader election lease, which has to differ between the instances within a cluster
+func leaderElectionID(instanceName string) string {
+	if instanceName == "" {
+		return "d2def39d.plusserver.com"
+	}
+
+	return fmt.Sprintf("d2def39d-%s.plusserver.com", instanceName)
+}
+
+// cacheOptions restricts the cached regions, projects, users and userprojectbindings to the watched namespaces and the instance selector.
+// Secrets are read and written beyond the watched namespaces, so the namespace of the operator and the namespaces
+// credentials may be delivered into are cached as well
+func cacheOptions(watchNamespaces []string, secretNamespaces []string, instanceSelector string) (cache.Options, error) {
+	selector, err := labels.Parse(instanceSelector)
+	if err != nil {
+		return cache.Options{}, err
+	}
+
+	//Regions are cluster scoped and can only be restricted by the selector
+	opts := cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			&pcov1beta1.Region{}: {Label: selector},
+		},
+	}
+
+	var namespaces map[string]cache.Config
+	if len(watchNamespaces) > 0 {
+		namespaces = map[string]cache.Config{}
+		for _, k := range watchNamespaces {
+			namespaces[k] = cache.Config{}
+		}
+
+		opts.DefaultNamespaces = map[string]cache.Config{}
+		for _, k := range append(append(watchNamespaces, secretNamespaces...), os.Getenv("CONTROLLER_NAMESPACE")) {
+			if k != "" {
+				opts.DefaultNamespaces[k] = cache.Config{}
+			}
+		}
+
+		if slices.Contains(secretNamespaces, "*") {
+			opts.DefaultNamespaces = nil
+		}
+	}
+
+	for _, k := range []client.Object{&pcov1beta1.Project{}, &pcov1beta1.User{}, &pcov1beta1.UserProjectBinding{}} {
+		opts.ByObject[k] = cache.ByObject{Label: selector, Namespaces: namespaces}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,124 @@
+// Package apierror classifies the errors returned by the reseller API and OpenStack
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Class of an error, deciding whether and when a failed operation is retried
+type Class string
+
+const (
+	// Auth is returned if the credentials were rejected or lack permissions. Retrying doesn't help until the credentials change
+	Auth Class = "AuthenticationFailed"
+	// Validation is returned if the request was rejected as invalid. Retrying doesn't help until the spec changes
+	Validation Class = "InvalidRequest"
+	// NotFound is returned if the addressed object doesn't exist (anymore)
+	NotFound Class = "NotFound"
+	// Conflict is returned if the request conflicts with the current state, e.g. a concurrent modification
+	Conflict Class = "Conflict"
+	// RateLimit is returned if the API throttles the requests
+	RateLimit Class = "RateLimited"
+	// Transient is returned for server side errors, network errors and all unclassified errors
+	Transient Class = "TransientError"
+)
+
+// statusPattern extracts the status code of errors only reporting it within their message, like the errors of psos
+var statusPattern = regexp.MustCompile(`(?i)\bstatus(?:\s*code)?[\s:=]+([1-5]\d\d)\b`)
+
+// Error is an error of the reseller API or OpenStack with its class
+type Error struct {
+	Class      Class
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify wraps err into an Error, nil stays nil
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	statusCode := statusCodeOf(err)
+	return &Error{Class: classOf(statusCode), StatusCode: statusCode, Err: err}
+}
+
+// ClassOf returns the class of err. Unclassified errors are considered transient, nil has no class
+func ClassOf(err error) Class {
+	if err == nil {
+		return ""
+	}
+
+	var apiErr *Error
+	if errors.As(Classify(err), &apiErr) {
+		return apiErr.Class
+	}
+
+	return Transient
+}
+
+// IsRetryable returns whether retrying the failed operation with backoff may succeed
+func IsRetryable(err error) bool {
+	switch ClassOf(err) {
+	case Auth, Validation:
+		return false
+	default:
+		return true
+	}
+}
+
+// New returns an error of the given class, e.g. for failures detected before calling the API
+func New(class Class, format string, args ...any) error {
+	return &Error{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+func statusCodeOf(err error) int {
+	//Implemented by the errors of gophercloud
+	var statusCodeErr interface{ GetStatusCode() int }
+	if errors.As(err, &statusCodeErr) {
+		return statusCodeErr.GetStatusCode()
+	}
+
+	if match := statusPattern.FindStringSubmatch(err.Error()); match != nil {
+		statusCode, _ := strconv.Atoi(match[1])
+		return statusCode
+	}
+
+	return 0
+}
+
+func classOf(statusCode int) Class {
+	switch {
+	case statusCode == 401 || statusCode == 403:
+		return Auth
+	case statusCode == 404:
+		return NotFound
+	case statusCode == 409:
+		return Conflict
+	case statusCode == 429:
+		return RateLimit
+	case statusCode == 408 || statusCode >= 500:
+		return Transient
+	case statusCode >= 400:
+		return Validation
+	}
+
+	//Network errors, timeouts and everything else unknown is retried
+	return Transient
+}
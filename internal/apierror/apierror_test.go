@@ -0,0 +1,99 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+func TestClassOf(t *testing.T) {
+	gophercloudError := func(statusCode int) error {
+		return gophercloud.ErrUnexpectedResponseCode{Method: "GET", URL: "http://keystone/v3/users", Actual: statusCode}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		class      Class
+		statusCode int
+	}{
+		{"psos unauthorized", errors.New("unexpected status code: 401"), Auth, 401},
+		{"psos forbidden", errors.New("request failed with Status: 403 Forbidden"), Auth, 403},
+		{"psos not found", errors.New("status code 404, project not found"), NotFound, 404},
+		{"psos conflict", errors.New("status=409"), Conflict, 409},
+		{"psos rate limited", errors.New("STATUS 429"), RateLimit, 429},
+		{"psos bad request", errors.New("status: 400 invalid quota"), Validation, 400},
+		{"psos unprocessable", errors.New("status code: 422"), Validation, 422},
+		{"psos server error", errors.New("status code: 503"), Transient, 503},
+		{"psos timeout", errors.New("status code: 408"), Transient, 408},
+
+		{"gophercloud unauthorized", gophercloud.ErrDefault401{ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{Actual: 401}}, Auth, 401},
+		{"gophercloud not found", gophercloud.ErrDefault404{ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{Actual: 404}}, NotFound, 404},
+		{"gophercloud conflict", gophercloudError(409), Conflict, 409},
+		{"gophercloud bad request", gophercloudError(400), Validation, 400},
+		{"gophercloud server error", gophercloudError(500), Transient, 500},
+
+		{"wrapped psos error", fmt.Errorf("updating project: %w", errors.New("status code: 404")), NotFound, 404},
+		{"wrapped gophercloud error", fmt.Errorf("deleting credential: %w", gophercloudError(403)), Auth, 403},
+		{"wrapped classified error", fmt.Errorf("region: %w", New(Validation, "invalid endpoint")), Validation, 0},
+
+		{"number without status", errors.New("project 404 not in quota"), Transient, 0},
+		{"status out of range", errors.New("status code: 600"), Transient, 0},
+		{"status with more digits", errors.New("status code: 4040"), Transient, 0},
+		{"status as part of a word", errors.New("substatus 404"), Transient, 0},
+		{"network error", context.DeadlineExceeded, Transient, 0},
+	}
+
+	for _, k := range tests {
+		t.Run(k.name, func(t *testing.T) {
+			if class := ClassOf(k.err); class != k.class {
+				t.Errorf("ClassOf(%q) = %s, want %s", k.err, class, k.class)
+			}
+
+			var apiErr *Error
+			if !errors.As(Classify(k.err), &apiErr) {
+				t.Fatalf("Classify(%q) didn't return an Error", k.err)
+			}
+
+			if apiErr.StatusCode != k.statusCode {
+				t.Errorf("status code of %q = %d, want %d", k.err, apiErr.StatusCode, k.statusCode)
+			}
+
+			if classified := Classify(k.err); classified.Error() != k.err.Error() {
+				t.Errorf("Classify(%q) changed the message to %q", k.err, classified)
+			}
+		})
+	}
+}
+
+func TestClassifyNil(t *testing.T) {
+	if err := Classify(nil); err != nil {
+		t.Errorf("Classify(nil) = %v, want nil", err)
+	}
+
+	if class := ClassOf(nil); class != "" {
+		t.Errorf("ClassOf(nil) = %s, want no class", class)
+	}
+
+	if !IsRetryable(nil) {
+		t.Error("IsRetryable(nil) = false, want true")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for class, retryable := range map[Class]bool{
+		Auth:       false,
+		Validation: false,
+		NotFound:   true,
+		Conflict:   true,
+		RateLimit:  true,
+		Transient:  true,
+	} {
+		if got := IsRetryable(New(class, "failed")); got != retryable {
+			t.Errorf("IsRetryable(%s) = %t, want %t", class, got, retryable)
+		}
+	}
+}
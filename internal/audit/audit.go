@@ -0,0 +1,135 @@
+// Package audit records every mutation of OpenStack executed by the operator together with the resource which caused it
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Object references the resource whose reconcile executed a mutation
+type Object struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+	// Manager is the field manager which last changed the resource, e.g. kubectl or a GitOps controller.
+	// Changes of the status and by the operator itself are skipped
+	Manager string `json:"manager,omitempty"`
+}
+
+// Record is an audit record of a mutation sent to the reseller API or Keystone
+type Record struct {
+	Time      time.Time `json:"time"`
+	Region    string    `json:"region"`
+	Object    *Object   `json:"object,omitempty"`
+	Operation string    `json:"operation"`
+	// Target is the id of the changed OpenStack object or its name if it was created
+	Target string `json:"target"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+	// Error is set if the API rejected the mutation
+	Error string `json:"error,omitempty"`
+}
+
+// Writer persists audit records
+type Writer interface {
+	Write(record Record) error
+}
+
+var (
+	writerMu sync.Mutex
+	writer   Writer = NewLogWriter(logr.Discard())
+
+	//ownManager is the field manager of the operator, derived from its binary like the user agent of its client
+	ownManager = filepath.Base(os.Args[0])
+)
+
+// SetWriter sets the writer receiving all audit records
+func SetWriter(w Writer) {
+	writerMu.Lock()
+	defer writerMu.Unlock()
+
+	writer = w
+}
+
+type contextKey struct{}
+
+// WithObject returns a context attributing the mutations within it to the given resource
+func WithObject(ctx context.Context, kind string, obj client.Object) context.Context {
+	return context.WithValue(ctx, contextKey{}, &Object{
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       obj.GetUID(),
+		Manager:   lastManager(obj),
+	})
+}
+
+// Log records a mutation of the region executed within ctx. Failures of the writer are logged, as they mustn't fail the mutation.
+// Mutations rejected by the guard of the region never reached OpenStack and aren't recorded
+func Log(ctx context.Context, region string, operation string, target string, before any, after any, err error) {
+	if _, ok := throttle.RetryAfter(err); ok {
+		return
+	}
+
+	record := Record{
+		Time:      time.Now().UTC(),
+		Region:    region,
+		Operation: operation,
+		Target:    target,
+		Before:    valueOrNil(before),
+		After:     valueOrNil(after),
+	}
+
+	if obj, ok := ctx.Value(contextKey{}).(*Object); ok {
+		record.Object = obj
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	writerMu.Lock()
+	w := writer
+	writerMu.Unlock()
+
+	if err := w.Write(record); err != nil {
+		logr.FromContextOrDiscard(ctx).Error(err, "Writing audit record failed", "operation", operation, "target", target)
+	}
+}
+
+// valueOrNil unwraps nil pointers, which the clients return on failures, so records omit them
+func valueOrNil(v any) any {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+
+	return v
+}
+
+// lastManager returns the field manager which last changed the resource besides its status, skipping the operator itself
+func lastManager(obj client.Object) string {
+	var manager string
+	var last time.Time
+	for _, k := range obj.GetManagedFields() {
+		if k.Subresource != "" || k.Manager == ownManager || k.Time == nil {
+			continue
+		}
+
+		if manager == "" || k.Time.After(last) {
+			manager = k.Manager
+			last = k.Time.Time
+		}
+	}
+
+	return manager
+}
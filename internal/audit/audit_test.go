@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	w, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetWriter(w)
+	defer SetWriter(NewLogWriter(logr.Discard()))
+
+	now := metav1.NewTime(time.Now())
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+	obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "ns",
+		Name:      "project",
+		UID:       "uid",
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Time: &earlier},
+			{Manager: "argocd", Time: &now},
+			{Manager: "kube-controller-manager", Time: &now, Subresource: "status"},
+			{Manager: ownManager, Time: &now},
+		},
+	}}
+
+	ctx := WithObject(context.Background(), "Project", obj)
+	var missing *struct{}
+	Log(ctx, "region", "DeleteProject", "1", map[string]string{"name": "abc-ns-project"}, missing, errors.New("conflict"))
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 record, got %d records", len(lines))
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := record["after"]; ok {
+		t.Errorf("expected nil pointers to be omitted, got %v", record["after"])
+	}
+	if record["error"] != "conflict" || record["target"] != "1" {
+		t.Errorf("unexpected record %s", lines[0])
+	}
+
+	object, _ := record["object"].(map[string]any)
+	if object["kind"] != "Project" || object["namespace"] != "ns" || object["manager"] != "argocd" {
+		t.Errorf("unexpected object %v", object)
+	}
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// LogWriter writes audit records to a dedicated logger
+type LogWriter struct {
+	logger logr.Logger
+}
+
+// NewLogWriter returns a writer logging every record with its fields as key value pairs
+func NewLogWriter(logger logr.Logger) *LogWriter {
+	return &LogWriter{logger: logger}
+}
+
+func (w *LogWriter) Write(record Record) error {
+	keysAndValues := []any{"time", record.Time, "region", record.Region, "operation", record.Operation, "target", record.Target}
+	if record.Object != nil {
+		keysAndValues = append(keysAndValues, "object", record.Object)
+	}
+	if record.Before != nil {
+		keysAndValues = append(keysAndValues, "before", record.Before)
+	}
+	if record.After != nil {
+		keysAndValues = append(keysAndValues, "after", record.After)
+	}
+	if record.Error != "" {
+		keysAndValues = append(keysAndValues, "error", record.Error)
+	}
+
+	w.logger.Info("OpenStack mutation", keysAndValues...)
+	return nil
+}
+
+// FileWriter appends audit records as JSON lines to a file
+type FileWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileWriter opens the file for appending, creating it if it doesn't exist
+func NewFileWriter(path string) (*FileWriter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileWriter{file: file}, nil
+}
+
+func (w *FileWriter) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// pausable objects track the PausedAnnotation within their Paused condition
+type pausable interface {
+	client.Object
+	UpdatePausedCondition(ctx context.Context, reconcileClient client.Client, paused bool) error
+}
+
+// reconcilePaused updates the Paused condition of the object and returns whether the reconcile (including the finalizer) has to be skipped
+func reconcilePaused(ctx context.Context, c client.Client, obj pausable) (bool, error) {
+	paused := v1beta1.IsPaused(obj.GetAnnotations())
+	if err := obj.UpdatePausedCondition(ctx, c, paused); err != nil {
+		return false, err
+	}
+
+	if paused {
+		log.FromContext(ctx).Info(fmt.Sprintf("Reconciliation paused by annotation %s, skipping", v1beta1.PausedAnnotation))
+	}
+
+	return paused, nil
+}
+
+// reconcileTriggers passes creations, deletions and updates which change the generation, pause or unpause the object
+// or request a reconcile by changing the ReconcileAtAnnotation
+func reconcileTriggers() predicate.Predicate {
+	return predicate.Or(predicate.GenerationChangedPredicate{}, predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAnnotations, newAnnotations := e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()
+			return v1beta1.IsPaused(oldAnnotations) != v1beta1.IsPaused(newAnnotations) ||
+				oldAnnotations[v1beta1.ReconcileAtAnnotation] != newAnnotations[v1beta1.ReconcileAtAnnotation]
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	//Rejected credentials only recover once they are changed, which doesn't necessarily change the generation of the object
+	authFailureRequeueAfter = 15 * time.Minute
+	//Throttled requests are retried once the API had some time to recover instead of backing off exponentially from a few milliseconds
+	rateLimitRequeueAfter = time.Minute
+)
+
+// apiErrorResult returns the result of a reconcile which failed calling the reseller API or OpenStack.
+// Invalid requests are terminal until the object changes, rejected credentials and throttling requeue after a fixed delay,
+// requests stopped by the guard of the region once it lets them pass and everything else is retried with backoff
+func apiErrorResult(ctx context.Context, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	//Requests stopped by the rate limit or circuit breaker of the region are retried once they may pass
+	if retryAfter, ok := throttle.RetryAfter(err); ok {
+		logger.Info(fmt.Sprintf("Requests to the region are postponed, retrying in %s: %s", retryAfter, err))
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	switch apierror.ClassOf(err) {
+	case apierror.Validation:
+		return ctrl.Result{}, reconcile.TerminalError(err)
+	case apierror.Auth:
+		logger.Info(fmt.Sprintf("Credentials were rejected, retrying in %s: %s", authFailureRequeueAfter, err))
+		return ctrl.Result{RequeueAfter: authFailureRequeueAfter}, nil
+	case apierror.RateLimit:
+		logger.Info(fmt.Sprintf("Requests were throttled, retrying in %s: %s", rateLimitRequeueAfter, err))
+		return ctrl.Result{RequeueAfter: rateLimitRequeueAfter}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+}
+
+func regionErrorReason(err error) pcov1beta1.RegionReadyReasons {
+	switch apierror.ClassOf(err) {
+	case apierror.Auth:
+		return pcov1beta1.RegionAuthenticationFailed
+	case apierror.Validation:
+		return pcov1beta1.RegionInvalidRequest
+	case apierror.Conflict:
+		return pcov1beta1.RegionConflict
+	case apierror.RateLimit:
+		return pcov1beta1.RegionRateLimited
+	case apierror.Transient:
+		return pcov1beta1.RegionTransientError
+	default:
+		return pcov1beta1.RegionUnknown
+	}
+}
+
+func projectErrorReason(err error) pcov1beta1.ProjectReadyReasons {
+	switch apierror.ClassOf(err) {
+	case apierror.Auth:
+		return pcov1beta1.ProjectAuthenticationFailed
+	case apierror.Validation:
+		return pcov1beta1.ProjectInvalidRequest
+	case apierror.Conflict:
+		return pcov1beta1.ProjectConflict
+	case apierror.RateLimit:
+		return pcov1beta1.ProjectRateLimited
+	case apierror.Transient:
+		return pcov1beta1.ProjectTransientError
+	default:
+		return pcov1beta1.ProjectUnknown
+	}
+}
+
+func userProjectBindingErrorReason(err error) pcov1beta1.UserProjectBindingReadyReasons {
+	switch apierror.ClassOf(err) {
+	case apierror.Auth:
+		return pcov1beta1.UserProjectBindingAuthenticationFailed
+	case apierror.Validation:
+		return pcov1beta1.UserProjectBindingInvalidRequest
+	case apierror.Conflict:
+		return pcov1beta1.UserProjectBindingConflict
+	case apierror.RateLimit:
+		return pcov1beta1.UserProjectBindingRateLimited
+	case apierror.Transient:
+		return pcov1beta1.UserProjectBindingTransientError
+	default:
+		return pcov1beta1.UserProjectBindingUnknown
+	}
+}
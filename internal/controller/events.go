@@ -0,0 +1,50 @@
+package controller
+
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reasons of the events emitted for the side effects within OpenStack
+const (
+	eventReasonLoginFailed     = "LoginFailed"
+	eventReasonDeletionBlocked = "DeletionBlocked"
+	eventReasonChangePlanned   = "ChangePlanned"
+
+	eventReasonProjectCreated      = "ProjectCreated"
+	eventReasonProjectUpdated      = "ProjectUpdated"
+	eventReasonProjectDeleted      = "ProjectDeleted"
+	eventReasonProjectCreateFailed = "ProjectCreateFailed"
+	eventReasonProjectUpdateFailed = "ProjectUpdateFailed"
+	eventReasonProjectDeleteFailed = "ProjectDeleteFailed"
+	eventReasonQuotaUpdated        = "QuotaUpdated"
+	eventReasonQuotaUpdateFailed   = "QuotaUpdateFailed"
+
+	eventReasonSecretCreated     = "SecretCreated"
+	eventReasonSecretDeleted     = "SecretDeleted"
+	eventReasonSecretWriteFailed = "SecretWriteFailed"
+
+	eventReasonUserCreated      = "UserCreated"
+	eventReasonUserUpdated      = "UserUpdated"
+	eventReasonUserDeleted      = "UserDeleted"
+	eventReasonUserCreateFailed = "UserCreateFailed"
+	eventReasonUserUpdateFailed = "UserUpdateFailed"
+	eventReasonUserDeleteFailed = "UserDeleteFailed"
+
+	eventReasonMemberAdded        = "MemberAdded"
+	eventReasonMemberRemoved      = "MemberRemoved"
+	eventReasonMemberAddFailed    = "MemberAddFailed"
+	eventReasonMemberRemoveFailed = "MemberRemoveFailed"
+
+	eventReasonRoleAssigned         = "RoleAssigned"
+	eventReasonRoleUnassigned       = "RoleUnassigned"
+	eventReasonRoleAssignmentFailed = "RoleAssignmentFailed"
+	eventReasonUnknownRoles         = "UnknownRoles"
+
+	eventReasonApplicationCredentialCreated      = "ApplicationCredentialCreated"
+	eventReasonApplicationCredentialRotated      = "ApplicationCredentialRotated"
+	eventReasonApplicationCredentialDeleted      = "ApplicationCredentialDeleted"
+	eventReasonApplicationCredentialCreateFailed = "ApplicationCredentialCreateFailed"
+	eventReasonApplicationCredentialDeleteFailed = "ApplicationCredentialDeleteFailed"
+
+	eventReasonOrphansDetected    = "OrphansDetected"
+	eventReasonOrphanDeleted      = "OrphanDeleted"
+	eventReasonOrphanDeleteFailed = "OrphanDeleteFailed"
+)
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	projectRegionField             = "spec.region"
+	userProjectBindingUserField    = "spec.user"
+	userProjectBindingProjectField = "spec.project"
+)
+
+// SetupFieldIndexes registers the field indexes used to look up objects by the objects they reference.
+// It has to be called once before the reconcilers are set up.
+func SetupFieldIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+
+	if err := indexer.IndexField(ctx, &v1beta1.Project{}, projectRegionField, func(obj client.Object) []string {
+		return []string{obj.(*v1beta1.Project).Spec.Region}
+	}); err != nil {
+		return err
+	}
+
+	if err := indexer.IndexField(ctx, &v1beta1.UserProjectBinding{}, userProjectBindingUserField, func(obj client.Object) []string {
+		return []string{obj.(*v1beta1.UserProjectBinding).Spec.User}
+	}); err != nil {
+		return err
+	}
+
+	return indexer.IndexField(ctx, &v1beta1.UserProjectBinding{}, userProjectBindingProjectField, func(obj client.Object) []string {
+		return []string{obj.(*v1beta1.UserProjectBinding).Spec.Project}
+	})
+}
+
+// readinessChanged passes creations, deletions and updates which change the generation or the conditions of an object
+func readinessChanged(conditions func(client.Object) []metav1.Condition) predicate.Predicate {
+	return predicate.Or(predicate.GenerationChangedPredicate{}, predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			//Only compare the status and observed generation of the conditions, not timestamps or messages
+			return !reflect.DeepEqual(conditionStates(conditions(e.ObjectOld)), conditionStates(conditions(e.ObjectNew)))
+		},
+	})
+}
+
+type conditionState struct {
+	status             metav1.ConditionStatus
+	observedGeneration int64
+}
+
+func conditionStates(conditions []metav1.Condition) map[string]conditionState {
+	states := map[string]conditionState{}
+	for _, k := range conditions {
+		states[k.Type] = conditionState{status: k.Status, observedGeneration: k.ObservedGeneration}
+	}
+
+	return states
+}
+
+// regionToProjects enqueues all projects referencing the region
+func (r *ProjectReconciler) regionToProjects(ctx context.Context, obj client.Object) []reconcile.Request {
+	projects := &v1beta1.ProjectList{}
+	if err := r.List(ctx, projects, client.MatchingFields{projectRegionField: obj.GetName()}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Couldn't list projects of region", "region", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(projects.Items))
+	for _, k := range projects.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: k.Namespace,
+			Name:      k.Name,
+		}})
+	}
+
+	return requests
+}
+
+// userToUserProjectBindings enqueues all bindings referencing the user
+func (r *UserProjectBindingReconciler) userToUserProjectBindings(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.referencingUserProjectBindings(ctx, obj, userProjectBindingUserField)
+}
+
+// projectToUserProjectBindings enqueues all bindings referencing the project
+func (r *UserProjectBindingReconciler) projectToUserProjectBindings(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.referencingUserProjectBindings(ctx, obj, userProjectBindingProjectField)
+}
+
+func (r *UserProjectBindingReconciler) referencingUserProjectBindings(ctx context.Context, obj client.Object, field string) []reconcile.Request {
+	upbs := &v1beta1.UserProjectBindingList{}
+	if err := r.List(ctx, upbs, client.InNamespace(obj.GetNamespace()), client.MatchingFields{field: obj.GetName()}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Couldn't list referencing userprojectbindings", "object", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(upbs.Items))
+	for _, k := range upbs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: k.Namespace,
+			Name:      k.Name,
+		}})
+	}
+
+	return requests
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const resourceCollectorTimeout = 10 * time.Second
+
+var managedResourcesDesc = prometheus.NewDesc(
+	"pco_managed_resources",
+	"Number of managed projects, users and userprojectbindings by region and readiness",
+	[]string{"kind", "region", "ready"}, nil,
+)
+
+type resourceKey struct {
+	kind   string
+	region string
+	ready  bool
+}
+
+type resourceCollector struct {
+	client client.Reader
+}
+
+// NewResourceCollector returns a prometheus collector counting the managed resources per region at scrape time.
+// Users are counted once for every region they are bound to, unbound users have an empty region
+func NewResourceCollector(c client.Reader) prometheus.Collector {
+	return &resourceCollector{client: c}
+}
+
+func (c *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- managedResourcesDesc
+}
+
+func (c *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), resourceCollectorTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		logf.Log.WithName("metrics").Error(err, "Couldn't count managed resources")
+		ch <- prometheus.NewInvalidMetric(managedResourcesDesc, err)
+		return
+	}
+
+	for k, v := range counts {
+		ch <- prometheus.MustNewConstMetric(managedResourcesDesc, prometheus.GaugeValue, float64(v), k.kind, k.region, strconv.FormatBool(k.ready))
+	}
+}
+
+func (c *resourceCollector) count(ctx context.Context) (map[resourceKey]int, error) {
+	projects := &v1beta1.ProjectList{}
+	if err := c.client.List(ctx, projects); err != nil {
+		return nil, err
+	}
+
+	users := &v1beta1.UserList{}
+	if err := c.client.List(ctx, users); err != nil {
+		return nil, err
+	}
+
+	upbs := &v1beta1.UserProjectBindingList{}
+	if err := c.client.List(ctx, upbs); err != nil {
+		return nil, err
+	}
+
+	counts := map[resourceKey]int{}
+
+	projectRegions := map[types.NamespacedName]string{}
+	for _, k := range projects.Items {
+		projectRegions[types.NamespacedName{Namespace: k.Namespace, Name: k.Name}] = k.Spec.Region
+		counts[resourceKey{kind: "project", region: k.Spec.Region, ready: k.IsReady()}]++
+	}
+
+	userRegions := map[types.NamespacedName]map[string]bool{}
+	for _, k := range upbs.Items {
+		region := projectRegions[types.NamespacedName{Namespace: k.Namespace, Name: k.Spec.Project}]
+		counts[resourceKey{kind: "userprojectbinding", region: region, ready: k.IsReady()}]++
+
+		userName := types.NamespacedName{Namespace: k.Namespace, Name: k.Spec.User}
+		if userRegions[userName] == nil {
+			userRegions[userName] = map[string]bool{}
+		}
+		userRegions[userName][region] = true
+	}
+
+	for _, k := range users.Items {
+		regions := userRegions[types.NamespacedName{Namespace: k.Namespace, Name: k.Name}]
+		if len(regions) == 0 {
+			counts[resourceKey{kind: "user", ready: k.IsReady()}]++
+			continue
+		}
+
+		for region := range regions {
+			counts[resourceKey{kind: "user", region: region, ready: k.IsReady()}]++
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+)
+
+func TestResourceCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := pcov1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	ready := []metav1.Condition{{Type: string(pcov1beta1.Ready), Status: metav1.ConditionTrue, Reason: "Ready"}}
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: "ns", Name: name}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&pcov1beta1.Project{ObjectMeta: meta("north"), Spec: pcov1beta1.ProjectSpec{Region: "north"}, Status: pcov1beta1.ProjectStatus{Conditions: ready}},
+		&pcov1beta1.Project{ObjectMeta: meta("south"), Spec: pcov1beta1.ProjectSpec{Region: "south"}},
+		&pcov1beta1.User{ObjectMeta: meta("bound"), Status: pcov1beta1.UserStatus{Conditions: ready}},
+		&pcov1beta1.User{ObjectMeta: meta("unbound")},
+		&pcov1beta1.UserProjectBinding{ObjectMeta: meta("bound-north"), Spec: pcov1beta1.UserProjectBindingSpec{Project: "north", User: "bound"}, Status: pcov1beta1.UserProjectBindingStatus{Conditions: ready}},
+		&pcov1beta1.UserProjectBinding{ObjectMeta: meta("bound-south"), Spec: pcov1beta1.UserProjectBindingSpec{Project: "south", User: "bound"}},
+	).Build()
+
+	//Users are counted within every region they are bound to
+	expected := `
+# HELP pco_managed_resources Number of managed projects, users and userprojectbindings by region and readiness
+# TYPE pco_managed_resources gauge
+pco_managed_resources{kind="project",ready="false",region="south"} 1
+pco_managed_resources{kind="project",ready="true",region="north"} 1
+pco_managed_resources{kind="user",ready="false",region=""} 1
+pco_managed_resources{kind="user",ready="true",region="north"} 1
+pco_managed_resources{kind="user",ready="true",region="south"} 1
+pco_managed_resources{kind="userprojectbinding",ready="false",region="south"} 1
+pco_managed_resources{kind="userprojectbinding",ready="true",region="north"} 1
+`
+	if err := testutil.CollectAndCompare(NewResourceCollector(c), strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/metrics"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+)
+
+const (
+	orphanKindProject = "project"
+	orphanKindUser    = "user"
+)
+
+// OrphanReconciler periodically scans every region for OpenStack projects and users named after this operator,
+// which no resource exists for anymore, e.g. because the finalizers were removed
+type OrphanReconciler struct {
+	client.Client
+
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// APIReader lists the projects and users bypassing the cache, which only holds the watched namespaces and the resources
+	// matching the instance selector. Resources outside of them still own their OpenStack projects and users
+	APIReader client.Reader
+
+	// NewResellerClient logs into the reseller API of a region. Defaults to reseller.Login
+	NewResellerClient reseller.Factory
+
+	// PlanMode plans the deletion of orphans instead of executing it, unless the region overrides it
+	PlanMode bool
+}
+
+// Reconcile scans the region for orphans and deletes them once their grace period passed, if enabled
+func (r *OrphanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "Region.CollectOrphans", tracing.ObjectAttributes("Region", req.NamespacedName)...)
+	ctx = plan.NewContext(ctx)
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *OrphanReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	region := &pcov1beta1.Region{}
+	if err := r.Get(ctx, req.NamespacedName, region); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ctx = audit.WithObject(ctx, "Region", region)
+
+	if region.GetDeletionTimestamp() != nil {
+		metrics.DeleteOrphans(region.Name)
+		return ctrl.Result{}, nil
+	}
+
+	//Paused regions get scanned again once they are unpaused
+	if pcov1beta1.IsPaused(region.GetAnnotations()) {
+		return ctrl.Result{}, nil
+	}
+
+	//The region gets enqueued again once it becomes ready
+	if !region.IsReady() {
+		return ctrl.Result{}, nil
+	}
+
+	interval := region.Spec.OrphanCollection.IntervalDuration()
+	startPlan(ctx, r.PlanMode, *region)
+
+	psOsClient, err := regionResellerClient(ctx, r.Client, r.NewResellerClient, *region)
+	if err != nil {
+		return apiErrorResult(ctx, err)
+	}
+
+	controllerIdentifier, err := utils.ControllerIdentifier(ctx, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	//List OpenStack before the resources, so OpenStack projects and users created meanwhile already have their resource
+	openStackProjects, err := psOsClient.GetProjects(ctx)
+	if err != nil {
+		return apiErrorResult(ctx, err)
+	}
+
+	openStackUsers, err := psOsClient.GetUsers(ctx)
+	if err != nil {
+		return apiErrorResult(ctx, err)
+	}
+
+	projectNames, err := r.openStackProjectNames(ctx, *controllerIdentifier, region.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	userNames, err := r.openStackUserNames(ctx, *controllerIdentifier)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	previous := pcov1beta1.OrphanStatus{}
+	if region.Status.Orphans != nil {
+		previous = *region.Status.Orphans
+	}
+
+	now := metav1.Now()
+	orphans := pcov1beta1.OrphanStatus{LastScanTime: &now}
+
+	for _, k := range *openStackProjects {
+		if !utils.IsOpenStackProjectOfController(*controllerIdentifier, k.Name) || hasAnySuffix(k.Name, projectNames) {
+			continue
+		}
+
+		orphans.Projects = append(orphans.Projects, orphanedResource(previous.Projects, k.Id, k.Name, now))
+	}
+
+	for _, k := range *openStackUsers {
+		if !utils.IsOpenStackUserOfController(*controllerIdentifier, k.Name) || userNames[k.Name] {
+			continue
+		}
+
+		orphans.Users = append(orphans.Users, orphanedResource(previous.Users, k.Id, k.Name, now))
+	}
+
+	if detected := newOrphans(previous.Projects, orphans.Projects) + newOrphans(previous.Users, orphans.Users); detected > 0 {
+		logger.Info(fmt.Sprintf("Detected %d new orphans", detected))
+		r.Recorder.Eventf(region, v1.EventTypeWarning, eventReasonOrphansDetected, "Detected %d new orphaned OpenStack projects and users", detected)
+	}
+
+	if region.Spec.OrphanCollection.DeletionEnabled() {
+		gracePeriod := region.Spec.OrphanCollection.GracePeriodDuration()
+
+		orphans.Users = r.deleteOrphans(ctx, region, orphanKindUser, orphans.Users, gracePeriod, psOsClient.DeleteUser)
+		orphans.Projects = r.deleteOrphans(ctx, region, orphanKindProject, orphans.Projects, gracePeriod, psOsClient.DeleteProject)
+	}
+
+	if err := region.UpdateOrphans(ctx, r.Client, orphans); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	metrics.SetOrphans(region.Name, orphanKindProject, len(orphans.Projects))
+	metrics.SetOrphans(region.Name, orphanKindUser, len(orphans.Users))
+
+	logger.Info(fmt.Sprintf("Orphan scan finished with %d projects and %d users, scanning again in %s", len(orphans.Projects), len(orphans.Users), interval))
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// deleteOrphans deletes the orphans detected for longer than the grace period and returns the remaining ones
+func (r *OrphanReconciler) deleteOrphans(ctx context.Context, region *pcov1beta1.Region, kind string, orphans []pcov1beta1.OrphanedResource, gracePeriod time.Duration, deleteFunc func(context.Context, string) error) []pcov1beta1.OrphanedResource {
+	logger := log.FromContext(ctx)
+
+	remaining := make([]pcov1beta1.OrphanedResource, 0, len(orphans))
+	for _, k := range orphans {
+		if time.Since(k.FirstSeen.Time) < gracePeriod {
+			remaining = append(remaining, k)
+			continue
+		}
+
+		//Planned deletions keep the orphan, so it is planned again by the next scan
+		if plan.Active(ctx) {
+			r.Recorder.Eventf(region, v1.EventTypeNormal, eventReasonChangePlanned, "Planned: Delete orphaned OpenStack %s %s (%s)", kind, k.Name, k.ID)
+			remaining = append(remaining, k)
+			continue
+		}
+
+		if err := deleteFunc(ctx, k.ID); err != nil && apierror.ClassOf(err) != apierror.NotFound {
+			logger.Error(err, fmt.Sprintf("Deleting orphaned %s %s failed", kind, k.ID))
+			r.Recorder.Eventf(region, v1.EventTypeWarning, eventReasonOrphanDeleteFailed, "Deleting orphaned OpenStack %s %s (%s) failed: %s", kind, k.Name, k.ID, err)
+			remaining = append(remaining, k)
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Deleted orphaned %s %s", kind, k.ID))
+		r.Recorder.Eventf(region, v1.EventTypeNormal, eventReasonOrphanDeleted, "Deleted orphaned OpenStack %s %s (%s)", kind, k.Name, k.ID)
+		metrics.ObserveOrphanDeleted(region.Name, kind)
+	}
+
+	return remaining
+}
+
+// openStackProjectNames returns the OpenStack project names of all projects within the region
+func (r *OrphanReconciler) openStackProjectNames(ctx context.Context, controllerId string, regionName string) ([]string, error) {
+	//The field index of the region is only available within the cache
+	projects := &pcov1beta1.ProjectList{}
+	if err := r.APIReader.List(ctx, projects); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(projects.Items))
+	for _, k := range projects.Items {
+		if k.Spec.Region != regionName {
+			continue
+		}
+
+		names = append(names, utils.GetOpenStackProjectName(controllerId, types.NamespacedName{Namespace: k.Namespace, Name: k.Name}))
+	}
+
+	return names, nil
+}
+
+// openStackUserNames returns the OpenStack usernames of all users
+func (r *OrphanReconciler) openStackUserNames(ctx context.Context, controllerId string) (map[string]bool, error) {
+	users := &pcov1beta1.UserList{}
+	if err := r.APIReader.List(ctx, users); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, k := range users.Items {
+		names[k.MailFor(controllerId)] = true
+	}
+
+	return names, nil
+}
+
+// orphanedResource keeps the time an orphan was first seen across scans
+func orphanedResource(previous []pcov1beta1.OrphanedResource, id string, name string, now metav1.Time) pcov1beta1.OrphanedResource {
+	for _, k := range previous {
+		if k.ID == id {
+			return pcov1beta1.OrphanedResource{ID: id, Name: name, FirstSeen: k.FirstSeen}
+		}
+	}
+
+	return pcov1beta1.OrphanedResource{ID: id, Name: name, FirstSeen: now}
+}
+
+func newOrphans(previous []pcov1beta1.OrphanedResource, current []pcov1beta1.OrphanedResource) int {
+	known := map[string]bool{}
+	for _, k := range previous {
+		known[k.ID] = true
+	}
+
+	count := 0
+	for _, k := range current {
+		if !known[k.ID] {
+			count++
+		}
+	}
+
+	return count
+}
+
+// hasAnySuffix matches OpenStack project names against templated ones, which lack the domain prepended by the reseller API
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, k := range suffixes {
+		if strings.HasSuffix(s, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OrphanReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("region-orphans").
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: 5,
+		}).
+		For(&pcov1beta1.Region{}, builder.WithPredicates(predicate.Or(reconcileTriggers(), readinessChanged(func(obj client.Object) []metav1.Condition {
+			return obj.(*pcov1beta1.Region).Status.Conditions
+		})))).
+		Complete(r)
+}
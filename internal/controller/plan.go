@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// planRequeueAfter keeps the plan of an object current and executes it once plan mode gets disabled
+const planRequeueAfter = 5 * time.Minute
+
+// startPlan switches the reconcile into plan mode if the region plans its mutations, falling back to the flag of the manager
+func startPlan(ctx context.Context, planMode bool, region v1beta1.Region) {
+	if region.Spec.PlanModeEnabled(planMode) {
+		plan.Activate(ctx)
+	}
+}
+
+// reportPlan emits an event for every mutation planned by the reconcile and returns them. Outside plan mode it returns nil
+func reportPlan(ctx context.Context, recorder record.EventRecorder, obj runtime.Object) []string {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return nil
+	}
+
+	changes := p.Changes()
+	for _, k := range changes {
+		recorder.Eventf(obj, v1.EventTypeNormal, eventReasonChangePlanned, "Planned: %s", k)
+	}
+
+	return changes
+}
@@ -18,23 +18,30 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
-	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 )
 
@@ -42,10 +49,15 @@ import (
 type ProjectReconciler struct {
 	client.Client
 
-	Scheme *runtime.Scheme
-}
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// NewResellerClient logs into the reseller API of a region. Defaults to reseller.Login
+	NewResellerClient reseller.Factory
 
-const regionTimeout = 1 * time.Minute
+	// PlanMode plans the mutations of OpenStack instead of executing them, unless the region overrides it
+	PlanMode bool
+}
 
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=projects,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=projects/status,verbs=get;update;patch
@@ -61,11 +73,20 @@ const regionTimeout = 1 * time.Minute
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "Project.Reconcile", tracing.ObjectAttributes("Project", req.NamespacedName)...)
+	ctx = plan.NewContext(ctx)
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *ProjectReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling Project")
 
 	// Fetch the Memcached instance
-	project := &pcov1alpha1.Project{}
+	project := &pcov1beta1.Project{}
 	err := r.Get(ctx, req.NamespacedName, project)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -80,6 +101,12 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		logger.Error(err, "Failed to get project.")
 		return ctrl.Result{}, err
 	}
+	ctx = audit.WithObject(ctx, "Project", project)
+
+	//Paused objects are neither reconciled nor finalized
+	if paused, err := reconcilePaused(ctx, r.Client, project); err != nil || paused {
+		return ctrl.Result{}, err
+	}
 
 	// Check if the region is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
@@ -89,7 +116,9 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			// Run finalization logic for controllerFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeProject(ctx, logger, *project); err != nil {
+			if err := tracing.Phase(ctx, "Project.Finalize", func(ctx context.Context) error {
+				return r.finalizeProject(ctx, logger, *project)
+			}); err != nil {
 				if err != errUserStillReferencingProject {
 					return ctrl.Result{}, err
 				}
@@ -98,10 +127,19 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				return ctrl.Result{RequeueAfter: time.Duration(3) * time.Second}, nil
 			}
 
+			//A planned deletion keeps the finalizer, as the OpenStack project still exists
+			pending, err := r.updatePlan(ctx, project)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if pending {
+				return ctrl.Result{RequeueAfter: planRequeueAfter}, nil
+			}
+
 			// Remove controllerFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
 			controllerutil.RemoveFinalizer(project, controllerFinalizer)
-			err := r.Update(ctx, project)
+			err = r.Update(ctx, project)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -123,38 +161,48 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
-	region := &pcov1alpha1.Region{}
+	region := &pcov1beta1.Region{}
 	if err := r.Get(ctx, types.NamespacedName{Name: project.Spec.Region}, region); err != nil {
-		reason := pcov1alpha1.RegionUnknown
-		if errors.IsNotFound(err) {
-			reason = pcov1alpha1.RegionNotFound
+		if !errors.IsNotFound(err) {
+			if err := project.UpdateRegionCondition(ctx, r.Client, pcov1beta1.RegionUnknown, err.Error()); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, err
 		}
 
-		if err := project.UpdateRegionCondition(ctx, r.Client, reason, err.Error()); err != nil {
+		if err := project.UpdateRegionCondition(ctx, r.Client, pcov1beta1.RegionNotFound, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, err
+		//The project gets enqueued again once the region appears
+		logger.Info(fmt.Sprintf("Region %s not found, waiting for region to appear", project.Spec.Region))
+		return ctrl.Result{}, nil
 	}
 
-	//Check if region is ready
+	tracing.SetAttributes(ctx, tracing.RegionKey.String(region.Name))
 
-	if awaitErr := region.AwaitReady(ctx, regionTimeout, r.Client, logger); awaitErr != nil {
-		if err := project.UpdateRegionCondition(ctx, r.Client, pcov1alpha1.RegionIsUnready, "Referenced region isn't ready"); err != nil {
+	//Check if region is ready
+	if !region.IsReady() {
+		if err := project.UpdateRegionCondition(ctx, r.Client, pcov1beta1.RegionIsUnready, "Referenced region isn't ready"); err != nil {
 			return ctrl.Result{}, err
 		}
 
-		//Wait for region to become ready -> Requeue request
-		return ctrl.Result{}, awaitErr
+		//The project gets enqueued again once the region becomes ready
+		logger.Info(fmt.Sprintf("Region %s isn't ready, waiting for region to become ready", region.Name))
+		return ctrl.Result{}, nil
 	}
 
-	if err := project.UpdateRegionCondition(ctx, r.Client, pcov1alpha1.RegionIsReady, fmt.Sprintf("Region %s is ready", region.Name)); err != nil {
+	if err := project.UpdateRegionCondition(ctx, r.Client, pcov1beta1.RegionIsReady, fmt.Sprintf("Region %s is ready", region.Name)); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	psOsClient, err := psos.Login(region.Spec.Endpoint, region.Spec.Username, region.Spec.Password)
+	startPlan(ctx, r.PlanMode, *region)
+
+	psOsClient, err := regionResellerClient(ctx, r.Client, r.NewResellerClient, *region)
 	if err != nil {
-		return ctrl.Result{}, err
+		r.Recorder.Eventf(project, v1.EventTypeWarning, eventReasonLoginFailed, "Login to reseller API of region %s failed: %s", region.Name, err)
+		return r.projectAPIError(ctx, project, err)
 	}
 
 	controllerIdentifier, err := utils.ControllerIdentifier(ctx, r.Client)
@@ -168,7 +216,7 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	openStackProject, err := utils.GetOpenStackProject(ctx, psOsClient, openStackProjectName)
 	if err != nil {
 		if err != utils.ErrOpenStackProjectNotFound {
-			return ctrl.Result{}, err
+			return r.projectAPIError(ctx, project, err)
 		}
 	}
 
@@ -185,11 +233,13 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		})
 
 		if err != nil {
-			if err := r.setProjectReadyError(ctx, project, err); err != nil {
-				return ctrl.Result{}, err
-			}
+			r.Recorder.Eventf(project, v1.EventTypeWarning, eventReasonProjectCreateFailed, "Creating OpenStack project %s failed: %s", openStackProjectName, err)
 
-			return ctrl.Result{}, err
+			return r.projectAPIError(ctx, project, err)
+		}
+
+		if !plan.Active(ctx) {
+			r.Recorder.Eventf(project, v1.EventTypeNormal, eventReasonProjectCreated, "OpenStack project %s created with id %s", openStackProjectName, openStackProject.Id)
 		}
 	} else {
 		isTrue := true
@@ -197,13 +247,14 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		//Update the project within openstack, if it already exists, to reflect the current state of the kubernetes object
 		//Check if the project within openstack matches the kubernetes object
 		projectMatches := true
-		if openStackProject.Name != openStackProjectName {
+		//The domain gets prepended to our project name
+		if !strings.HasSuffix(openStackProject.Name, openStackProjectName) {
 			projectMatches = false
 		}
 		if openStackProject.Description != project.Spec.Description {
 			projectMatches = false
 		}
-		if openStackProject.Enabled != &isTrue {
+		if openStackProject.Enabled == nil || !*openStackProject.Enabled {
 			projectMatches = false
 		}
 		if !projectMatches {
@@ -213,56 +264,53 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				Enabled:     &isTrue,
 			})
 			if err != nil {
-				if err := r.setProjectReadyError(ctx, project, err); err != nil {
-					return ctrl.Result{}, err
-				}
+				r.Recorder.Eventf(project, v1.EventTypeWarning, eventReasonProjectUpdateFailed, "Updating OpenStack project %s failed: %s", openStackProjectName, err)
 
-				return ctrl.Result{}, err
+				return r.projectAPIError(ctx, project, err)
+			}
+
+			if !plan.Active(ctx) {
+				r.Recorder.Eventf(project, v1.EventTypeNormal, eventReasonProjectUpdated, "OpenStack project %s updated", openStackProject.Id)
 			}
 		}
 	}
 
 	//At this stage, the current representation of the openstack project is stored in openStackProject
 	logger.Info(fmt.Sprintf("OpenStack Project %s ensured", openStackProject.Id))
+	tracing.SetAttributes(ctx, tracing.OpenStackProjectIDKey.String(openStackProject.Id))
 
-	//The local v1alpha1.QuotaCollection matches the openapi definition in openapi.UpdateQuota -> We use json marshalling to convert the objects
-	jsonBytes, err := json.Marshal(project.Spec.Quotas)
-	if err != nil {
-		if err := r.setProjectReadyError(ctx, project, err); err != nil {
-			return ctrl.Result{}, err
-		}
-
-		return ctrl.Result{}, err
-	}
-
-	projectQuota := openapi.UpdateQuota{}
-	if err := json.Unmarshal(jsonBytes, &projectQuota); err != nil {
-		if err := r.setProjectReadyError(ctx, project, err); err != nil {
-			return ctrl.Result{}, err
-		}
-
-		return ctrl.Result{}, err
-	}
+	projectQuota := resellerQuota(project.Spec.Quotas)
 
 	currentProjectQuota, err := psOsClient.GetProjectQuota(ctx, openStackProject.Id)
 	if err != nil {
-		return ctrl.Result{}, err
+		return r.projectAPIError(ctx, project, err)
 	}
 
 	//Ensure quotas are set correctly
-	if projectQuota != *currentProjectQuota {
+	if !reseller.QuotaMatches(projectQuota, *currentProjectQuota) {
 		if _, err := psOsClient.UpdateProjectQuota(ctx, openStackProject.Id, projectQuota); err != nil {
-			if err := r.setProjectReadyError(ctx, project, err); err != nil {
-				return ctrl.Result{}, err
-			}
+			r.Recorder.Eventf(project, v1.EventTypeWarning, eventReasonQuotaUpdateFailed, "Updating quota of OpenStack project %s failed: %s", openStackProject.Id, err)
 
-			return ctrl.Result{}, err
+			return r.projectAPIError(ctx, project, err)
+		}
+
+		if !plan.Active(ctx) {
+			r.Recorder.Eventf(project, v1.EventTypeNormal, eventReasonQuotaUpdated, "Quota of OpenStack project %s updated", openStackProject.Id)
 		}
 	}
 
 	logger.Info(fmt.Sprintf("Quota for project %s ensured", openStackProject.Id))
 
-	if err := project.UpdateProjectCondition(ctx, r.Client, pcov1alpha1.ProjectIsReady, fmt.Sprintf("Project %s ensured", openStackProject.Id)); err != nil {
+	pending, err := r.updatePlan(ctx, project)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending {
+		logger.Info(fmt.Sprintf("Changes to project %s planned, will plan again in %s", openStackProject.Id, planRequeueAfter))
+		return ctrl.Result{RequeueAfter: planRequeueAfter}, nil
+	}
+
+	if err := project.UpdateProjectCondition(ctx, r.Client, pcov1beta1.ProjectIsReady, fmt.Sprintf("Project %s ensured", openStackProject.Id)); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -272,12 +320,18 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	pred := predicate.GenerationChangedPredicate{}
+	pred := reconcileTriggers()
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
 		}).
-		WithEventFilter(pred).
-		For(&pcov1alpha1.Project{}).
+		For(&pcov1beta1.Project{}, builder.WithPredicates(pred)).
+		//Continue projects waiting for their region
+		Watches(&pcov1beta1.Region{},
+			handler.EnqueueRequestsFromMapFunc(r.regionToProjects),
+			builder.WithPredicates(readinessChanged(func(obj client.Object) []metav1.Condition {
+				return obj.(*pcov1beta1.Region).Status.Conditions
+			})),
+		).
 		Complete(r)
 }
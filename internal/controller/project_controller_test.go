@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/fakeopenstack"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+)
+
+var _ = Describe("Project controller", Ordered, func() {
+	ctx := context.Background()
+	const namespace = "project-controller"
+
+	var region *pcov1beta1.Region
+
+	BeforeAll(func() {
+		createNamespace(ctx, namespace)
+		region = createReadyRegion(ctx, "project-controller")
+	})
+
+	openStackProject := func(g Gomega, project *pcov1beta1.Project) fakeopenstack.Project {
+		openStackProject, ok := cloud.ProjectBySuffix(utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: project.Namespace, Name: project.Name}))
+		g.Expect(ok).To(BeTrue())
+
+		return openStackProject
+	}
+
+	It("creates, updates and deletes the OpenStack project", func() {
+		cores := 4
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "lifecycle"},
+			Spec: pcov1beta1.ProjectSpec{
+				Region:      region.Name,
+				Description: "created",
+				Quotas: &pcov1beta1.QuotaCollection{
+					Compute: &pcov1beta1.ComputeQuotas{Cores: &cores, Instances: 2, KeyPairs: 7},
+					Volume:  &pcov1beta1.VolumeQuotas{BackupGigabytes: 30, Volumes: 3},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+
+		expectReady(ctx, project)
+		Expect(controllerutil.ContainsFinalizer(project, controllerFinalizer)).To(BeTrue())
+
+		created := openStackProject(Default, project)
+		Expect(created.Name).To(Equal(cloud.Domain + "-" + utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: namespace, Name: "lifecycle"})))
+		Expect(created.Description).To(Equal("created"))
+		Expect(created.Enabled).To(BeTrue())
+
+		//The quota is decoded in the format of the reseller API, which names multi-word quotas in snake_case
+		quota := openapi.UpdateQuota{}
+		Expect(json.Unmarshal(created.Quota, &quota)).To(Succeed())
+		Expect(quota.Compute).NotTo(BeNil())
+		Expect(*quota.Compute.Cores).To(Equal(4))
+		Expect(quota.Compute.KeyPairs).To(Equal(7))
+		Expect(quota.Volume).NotTo(BeNil())
+		Expect(quota.Volume.BackupGigabytes).To(Equal(30))
+
+		By("updating the description and quotas")
+		cores = 8
+		project.Spec.Description = "updated"
+		project.Spec.Quotas.Compute.Cores = &cores
+		project.Spec.Quotas.Compute.KeyPairs = 9
+		Expect(k8sClient.Update(ctx, project)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			updated := openStackProject(g, project)
+			g.Expect(updated.ID).To(Equal(created.ID))
+			g.Expect(updated.Description).To(Equal("updated"))
+
+			quota := openapi.UpdateQuota{}
+			g.Expect(json.Unmarshal(updated.Quota, &quota)).To(Succeed())
+			g.Expect(*quota.Compute.Cores).To(Equal(8))
+			g.Expect(quota.Compute.KeyPairs).To(Equal(9))
+		}, timeout, interval).Should(Succeed())
+		expectReady(ctx, project)
+
+		By("sending neither a quota update nor its event without a change")
+		quotaEvents := func(g Gomega) int {
+			events := &v1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace(namespace), client.MatchingFields{"involvedObject.name": project.Name})).To(Succeed())
+
+			count := 0
+			for _, event := range events.Items {
+				if event.Reason == eventReasonQuotaUpdated {
+					count++
+				}
+			}
+			return count
+		}
+
+		//The event of the previous update may still be on its way
+		var quotaUpdated int
+		Eventually(func(g Gomega) {
+			quotaUpdated = quotaEvents(g)
+			g.Expect(quotaUpdated).To(BeNumerically(">", 0))
+		}, timeout, interval).Should(Succeed())
+		quotaUpdates := cloud.Requests("PUT /v1/projects/{project_id}/quota")
+
+		old := project.DeepCopy()
+		project.SetAnnotations(map[string]string{pcov1beta1.ReconcileAtAnnotation: time.Now().Format(time.RFC3339Nano)})
+		Expect(k8sClient.Patch(ctx, project, client.MergeFrom(old))).To(Succeed())
+
+		Consistently(func(g Gomega) {
+			g.Expect(quotaEvents(g)).To(Equal(quotaUpdated))
+		}, 2*time.Second, interval).Should(Succeed())
+		Expect(cloud.Requests("PUT /v1/projects/{project_id}/quota")).To(Equal(quotaUpdates))
+
+		By("deleting the OpenStack project")
+		expectGone(ctx, project)
+
+		_, ok := cloud.ProjectBySuffix(utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: namespace, Name: "lifecycle"}))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("adopts an existing OpenStack project instead of creating another one", func() {
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "adopted"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+		expectReady(ctx, project)
+		created := openStackProject(Default, project)
+
+		//Removing the finalizer leaves the OpenStack project behind
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(project), project)).To(Succeed())
+		controllerutil.RemoveFinalizer(project, controllerFinalizer)
+		Expect(k8sClient.Update(ctx, project)).To(Succeed())
+		expectGone(ctx, project)
+
+		project = &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "adopted"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+		expectReady(ctx, project)
+		Expect(openStackProject(Default, project).ID).To(Equal(created.ID))
+
+		expectGone(ctx, project)
+	})
+
+	It("plans the mutations of a region in plan mode without executing them", func() {
+		planned := createReadyRegion(ctx, "project-controller-plan")
+		setPlanMode := func(enabled bool) {
+			old := planned.DeepCopy()
+			planned.Spec.PlanMode = &enabled
+			Expect(k8sClient.Patch(ctx, planned, client.MergeFrom(old))).To(Succeed())
+		}
+		setPlanMode(true)
+
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "planned"},
+			Spec:       pcov1beta1.ProjectSpec{Region: planned.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+
+		openStackProjectName := utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: namespace, Name: "planned"})
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(project), project)).To(Succeed())
+
+			condition := meta.FindStatusCondition(project.Status.Conditions, string(pcov1beta1.ProjectReady))
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Reason).To(Equal(string(pcov1beta1.ProjectChangesPlanned)))
+			g.Expect(project.Status.PlannedChanges).To(ContainElement("Create project " + openStackProjectName))
+		}, timeout, interval).Should(Succeed())
+
+		_, ok := cloud.ProjectBySuffix(openStackProjectName)
+		Expect(ok).To(BeFalse())
+
+		By("executing the plan once plan mode is disabled")
+		setPlanMode(false)
+
+		expectReady(ctx, project)
+		Expect(project.Status.PlannedChanges).To(BeEmpty())
+		openStackProject(Default, project)
+
+		expectGone(ctx, project)
+		expectGone(ctx, planned)
+	})
+
+	It("plans no quota update in plan mode once the quotas match", func() {
+		planned := createReadyRegion(ctx, "project-controller-quota-plan")
+		setPlanMode := func(enabled bool) {
+			old := planned.DeepCopy()
+			planned.Spec.PlanMode = &enabled
+			Expect(k8sClient.Patch(ctx, planned, client.MergeFrom(old))).To(Succeed())
+		}
+
+		cores := 4
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "quota-planned"},
+			Spec: pcov1beta1.ProjectSpec{
+				Region: planned.Name,
+				Quotas: &pcov1beta1.QuotaCollection{
+					Compute: &pcov1beta1.ComputeQuotas{Cores: &cores, Instances: 2},
+					Volume:  &pcov1beta1.VolumeQuotas{Volumes: 3},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+		expectReady(ctx, project)
+		quotaUpdates := cloud.Requests("PUT /v1/projects/{project_id}/quota")
+
+		setPlanMode(true)
+		old := project.DeepCopy()
+		project.SetAnnotations(map[string]string{pcov1beta1.ReconcileAtAnnotation: time.Now().Format(time.RFC3339Nano)})
+		Expect(k8sClient.Patch(ctx, project, client.MergeFrom(old))).To(Succeed())
+
+		//The quotas in OpenStack already match, so there's nothing to plan
+		Consistently(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(project), project)).To(Succeed())
+			g.Expect(project.IsReady()).To(BeTrue())
+			g.Expect(project.Status.PlannedChanges).To(BeEmpty())
+		}, 2*time.Second, interval).Should(Succeed())
+		Expect(cloud.Requests("PUT /v1/projects/{project_id}/quota")).To(Equal(quotaUpdates))
+
+		setPlanMode(false)
+		expectGone(ctx, project)
+		expectGone(ctx, planned)
+	})
+
+	It("rejects a changed region and quotas out of range without the webhook", func() {
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "validated"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+
+		moved := project.DeepCopy()
+		moved.Spec.Region = "project-controller-other"
+		Expect(k8sClient.Update(ctx, moved)).To(MatchError(ContainSubstring("region is immutable")))
+
+		cores := -2
+		exceeded := project.DeepCopy()
+		exceeded.Spec.Quotas = &pcov1beta1.QuotaCollection{Compute: &pcov1beta1.ComputeQuotas{Cores: &cores}}
+		Expect(k8sClient.Update(ctx, exceeded)).To(MatchError(ContainSubstring("spec.quotas.compute.cores")))
+
+		cores = 501
+		Expect(k8sClient.Update(ctx, exceeded)).To(MatchError(ContainSubstring("spec.quotas.compute.cores")))
+
+		exceeded.Spec.Quotas = &pcov1beta1.QuotaCollection{Compute: &pcov1beta1.ComputeQuotas{Instances: 2147483648}}
+		Expect(k8sClient.Update(ctx, exceeded)).To(MatchError(ContainSubstring("spec.quotas.compute.instances")))
+
+		expectGone(ctx, project)
+	})
+
+	It("waits for the referenced region to appear", func() {
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "waiting"},
+			Spec:       pcov1beta1.ProjectSpec{Region: "project-controller-late"},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(project), project)).To(Succeed())
+
+			condition := meta.FindStatusCondition(project.Status.Conditions, string(pcov1beta1.RegionReady))
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Reason).To(Equal(string(pcov1beta1.RegionNotFound)))
+		}, timeout, interval).Should(Succeed())
+
+		lateRegion := createReadyRegion(ctx, "project-controller-late")
+		expectReady(ctx, project)
+
+		expectGone(ctx, project)
+		expectGone(ctx, lateRegion)
+	})
+})
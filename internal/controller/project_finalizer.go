@@ -6,9 +6,11 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	v1 "k8s.io/api/core/v1"
 	k8errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,28 +18,27 @@ import (
 
 var errUserStillReferencingProject = errors.New("users are still referencing project")
 
-func (r *ProjectReconciler) finalizeProject(ctx context.Context, logger logr.Logger, project v1alpha1.Project) error {
+func (r *ProjectReconciler) finalizeProject(ctx context.Context, logger logr.Logger, project v1beta1.Project) error {
 	//We wanna keep the finalizer as simple as possible
 	//Don't check region availablility etc...
 
 	//Check if users (UPB) still reference the project
-	userProjectBindings := &v1alpha1.UserProjectBindingList{}
-	if err := r.List(ctx, userProjectBindings, &client.ListOptions{Namespace: project.Namespace}); err != nil {
+	userProjectBindings := &v1beta1.UserProjectBindingList{}
+	if err := r.List(ctx, userProjectBindings, client.InNamespace(project.Namespace), client.MatchingFields{userProjectBindingProjectField: project.Name}); err != nil {
 		return err
 	}
 
-	for _, k := range userProjectBindings.Items {
-		if k.Spec.Project == project.Name {
-			logger.Info("Users are still referencing project. Blocking deletion")
-			return errUserStillReferencingProject
-		}
+	if len(userProjectBindings.Items) > 0 {
+		logger.Info("Users are still referencing project. Blocking deletion")
+		r.Recorder.Eventf(&project, v1.EventTypeWarning, eventReasonDeletionBlocked, "%d userprojectbindings are still referencing project", len(userProjectBindings.Items))
+		return errUserStillReferencingProject
 	}
 
-	region := &v1alpha1.Region{}
+	region := &v1beta1.Region{}
 	if err := r.Get(ctx, types.NamespacedName{Name: project.Spec.Region}, region); err != nil {
-		reason := v1alpha1.RegionUnknown
+		reason := v1beta1.RegionUnknown
 		if k8errors.IsNotFound(err) {
-			reason = v1alpha1.RegionNotFound
+			reason = v1beta1.RegionNotFound
 		}
 
 		if err := project.UpdateRegionCondition(ctx, r.Client, reason, err.Error()); err != nil {
@@ -47,10 +48,14 @@ func (r *ProjectReconciler) finalizeProject(ctx context.Context, logger logr.Log
 		return err
 	}
 
-	psOsClient, err := psos.Login(region.Spec.Endpoint, region.Spec.Username, region.Spec.Password)
+	startPlan(ctx, r.PlanMode, *region)
+
+	psOsClient, err := regionResellerClient(ctx, r.Client, r.NewResellerClient, *region)
 	if err != nil {
+		r.Recorder.Eventf(&project, v1.EventTypeWarning, eventReasonLoginFailed, "Login to reseller API of region %s failed: %s", region.Name, err)
+
 		//Set region condition unready if login fails
-		if err := project.UpdateRegionCondition(ctx, r.Client, v1alpha1.RegionUnknown, err.Error()); err != nil {
+		if err := project.UpdateRegionCondition(ctx, r.Client, v1beta1.RegionUnknown, err.Error()); err != nil {
 			return err
 		}
 
@@ -78,10 +83,20 @@ func (r *ProjectReconciler) finalizeProject(ctx context.Context, logger logr.Log
 		return nil
 	}
 
+	tracing.SetAttributes(ctx, tracing.RegionKey.String(region.Name), tracing.OpenStackProjectIDKey.String(openStackProject.Id))
+
 	if err := psOsClient.DeleteProject(ctx, openStackProject.Id); err != nil {
+		r.Recorder.Eventf(&project, v1.EventTypeWarning, eventReasonProjectDeleteFailed, "Deleting OpenStack project %s failed: %s", openStackProject.Id, err)
 		return err
 	}
 
+	if plan.Active(ctx) {
+		logger.Info(fmt.Sprintf("Deletion of OpenStack Project %s planned", openStackProject.Id))
+		return nil
+	}
+
+	r.Recorder.Eventf(&project, v1.EventTypeNormal, eventReasonProjectDeleted, "OpenStack project %s deleted", openStackProject.Id)
+
 	logger.Info(fmt.Sprintf("OpenStack Project %s deleted", openStackProject.Id))
 	return nil
 }
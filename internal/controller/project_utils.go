@@ -2,29 +2,115 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func (r *ProjectReconciler) initializeConditions(ctx context.Context, project *v1alpha1.Project) error {
-	if meta.FindStatusCondition(project.Status.Conditions, string(v1alpha1.RegionReady)) == nil {
-		if err := project.UpdateRegionCondition(ctx, r.Client, v1alpha1.RegionUnknown, "Operation not initialized"); err != nil {
+func (r *ProjectReconciler) initializeConditions(ctx context.Context, project *v1beta1.Project) error {
+	if meta.FindStatusCondition(project.Status.Conditions, string(v1beta1.RegionReady)) == nil {
+		if err := project.UpdateRegionCondition(ctx, r.Client, v1beta1.RegionUnknown, "Operation not initialized"); err != nil {
 			return err
 		}
 	}
 
-	if meta.FindStatusCondition(project.Status.Conditions, string(v1alpha1.ProjectReady)) == nil {
-		if err := project.UpdateProjectCondition(ctx, r.Client, v1alpha1.ProjectUnknown, "Operation not initialized"); err != nil {
+	if meta.FindStatusCondition(project.Status.Conditions, string(v1beta1.ProjectReady)) == nil {
+		if err := project.UpdateProjectCondition(ctx, r.Client, v1beta1.ProjectUnknown, "Operation not initialized"); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (r *ProjectReconciler) setProjectReadyError(ctx context.Context, project *v1alpha1.Project, err error) error {
-	if err := project.UpdateProjectCondition(ctx, r.Client, v1alpha1.ProjectUnknown, err.Error()); err != nil {
+func (r *ProjectReconciler) setProjectReadyError(ctx context.Context, project *v1beta1.Project, err error) error {
+	if err := project.UpdateProjectCondition(ctx, r.Client, projectErrorReason(err), err.Error()); err != nil {
 		return err
 	}
 	return nil
 }
+
+// projectAPIError records the failed call to the reseller API within the ProjectReady condition and returns the result of the reconcile
+func (r *ProjectReconciler) projectAPIError(ctx context.Context, project *v1beta1.Project, err error) (ctrl.Result, error) {
+	if err := r.setProjectReadyError(ctx, project, err); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return apiErrorResult(ctx, err)
+}
+
+// updatePlan records the mutations planned by the reconcile within the status and events of the project.
+// It returns true if changes are pending, which are reported by the ProjectReady condition
+func (r *ProjectReconciler) updatePlan(ctx context.Context, project *v1beta1.Project) (bool, error) {
+	changes := reportPlan(ctx, r.Recorder, project)
+	if err := project.UpdatePlannedChanges(ctx, r.Client, changes); err != nil {
+		return false, err
+	}
+
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	if err := project.UpdateProjectCondition(ctx, r.Client, v1beta1.ProjectChangesPlanned, fmt.Sprintf("%d changes planned as region %s is in plan mode", len(changes), project.Spec.Region)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resellerQuota maps the quotas of the project to the quota model of the reseller API
+func resellerQuota(quotas *v1beta1.QuotaCollection) openapi.UpdateQuota {
+	quota := openapi.UpdateQuota{}
+	if quotas == nil {
+		return quota
+	}
+
+	if k := quotas.Compute; k != nil {
+		quota.Compute = &openapi.ComputeQuotas{
+			Cores:                    k.Cores,
+			FixedIps:                 k.FixedIps,
+			FloatingIps:              k.FloatingIps,
+			InjectedFileContentBytes: k.InjectedFileContentBytes,
+			InjectedFilePathBytes:    k.InjectedFilePathBytes,
+			InjectedFiles:            k.InjectedFiles,
+			Instances:                k.Instances,
+			KeyPairs:                 k.KeyPairs,
+			MetadataItems:            k.MetadataItems,
+			Ram:                      k.Ram,
+			SecurityGroupRules:       k.SecurityGroupRules,
+			SecurityGroups:           k.SecurityGroups,
+			ServerGroupMembers:       k.ServerGroupMembers,
+			ServerGroups:             k.ServerGroups,
+		}
+	}
+
+	if k := quotas.Network; k != nil {
+		quota.Network = &openapi.NetworkQuotas{
+			Floatingip:        k.Floatingip,
+			Network:           k.Network,
+			Port:              k.Port,
+			RbacPolicy:        k.RbacPolicy,
+			Router:            k.Router,
+			SecurityGroup:     k.SecurityGroup,
+			SecurityGroupRule: k.SecurityGroupRule,
+			Subnet:            k.Subnet,
+			Subnetpool:        k.Subnetpool,
+		}
+	}
+
+	if k := quotas.Volume; k != nil {
+		quota.Volume = &openapi.VolumeQuotas{
+			BackupGigabytes:    k.BackupGigabytes,
+			Backups:            k.Backups,
+			Gigabytes:          k.Gigabytes,
+			Groups:             k.Groups,
+			PerVolumeGigabytes: k.PerVolumeGigabytes,
+			Snapshots:          k.Snapshots,
+			Volumes:            k.Volumes,
+		}
+	}
+
+	return quota
+}
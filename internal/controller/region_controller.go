@@ -18,28 +18,39 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
-	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 )
 
 // RegionReconciler reconciles a Region object
 type RegionReconciler struct {
 	client.Client
 
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// NewResellerClient logs into the reseller API of a region. Defaults to reseller.Login
+	NewResellerClient reseller.Factory
 }
 
 const controllerFinalizer = "pco.plusserver.com/finalizer"
@@ -58,11 +69,19 @@ const controllerFinalizer = "pco.plusserver.com/finalizer"
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *RegionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "Region.Reconcile", tracing.ObjectAttributes("Region", req.NamespacedName)...)
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *RegionReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling Region")
 
 	// Fetch the Region instance
-	region := &pcov1alpha1.Region{}
+	region := &pcov1beta1.Region{}
 	err := r.Get(ctx, req.NamespacedName, region)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -78,6 +97,11 @@ func (r *RegionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
+	//Paused objects are neither reconciled nor finalized
+	if paused, err := reconcilePaused(ctx, r.Client, region); err != nil || paused {
+		return ctrl.Result{}, err
+	}
+
 	// Check if the region is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
 	isRegionMarkedToBeDelted := region.GetDeletionTimestamp() != nil
@@ -86,7 +110,9 @@ func (r *RegionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			// Run finalization logic for controllerFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeRegion(ctx, logger, *region); err != nil {
+			if err := tracing.Phase(ctx, "Region.Finalize", func(ctx context.Context) error {
+				return r.finalizeRegion(ctx, logger, *region)
+			}); err != nil {
 				if err != errProjectStillReferencingRegion {
 					return ctrl.Result{}, err
 				}
@@ -102,6 +128,9 @@ func (r *RegionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+
+			throttle.Forget(region.Name)
+			reseller.ForgetInventory(region.Name)
 		}
 		return ctrl.Result{}, nil
 	}
@@ -116,55 +145,97 @@ func (r *RegionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	// Get Endpoint Username and Password either from the Secret or from the CRD
-	var endpoint, username, password string
-	if region.Spec.SecretRef != nil {
-		credentialSecret := corev1.Secret{}
-		err = r.Get(ctx, types.NamespacedName{
-			Name:      region.Spec.SecretRef.Name,
-			Namespace: region.Spec.SecretRef.Namespace,
-		}, &credentialSecret)
-		if err != nil {
-			return ctrl.Result{}, err
+	endpoint, username, password, err := regionCredentials(ctx, r.Client, *region)
+	if err != nil {
+		r.Recorder.Eventf(region, v1.EventTypeWarning, eventReasonLoginFailed, "Reseller API credentials unavailable: %s", err)
+		return ctrl.Result{}, err
+	}
+	tracing.SetAttributes(ctx, tracing.RegionKey.String(region.Name))
+	_, err = loginReseller(ctx, r.NewResellerClient, *region, endpoint, username, password)
+
+	apiRequeueAfter, conditionErr := r.updateAPIConditions(ctx, region)
+	if conditionErr != nil {
+		return ctrl.Result{}, conditionErr
+	}
+
+	if err != nil {
+		//Logins stopped by the rate limit or circuit breaker don't tell anything about the credentials
+		if retryAfter, ok := throttle.RetryAfter(err); ok {
+			logger.Info(fmt.Sprintf("Login postponed for %s: %s", retryAfter, err))
+			return ctrl.Result{RequeueAfter: retryAfter}, nil
 		}
-		endpoint = string(credentialSecret.Data["endpoint"])
-		username = string(credentialSecret.Data["username"])
-		password = string(credentialSecret.Data["password"])
-	} else {
-		if region.Spec.Endpoint == "" || region.Spec.Username == "" || region.Spec.Password == "" {
-			log.Log.Error(err, "region without secretRef must define all of endpoint, username, password")
+
+		r.Recorder.Eventf(region, v1.EventTypeWarning, eventReasonLoginFailed, "Login to reseller API failed: %s", err)
+
+		if err := region.UpdateRegionCondition(ctx, r.Client, regionErrorReason(err), err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
-		endpoint = region.Spec.Endpoint
-		username = region.Spec.Username
-		password = region.Spec.Password
-		log.Log.Info("specifying region credentials within CR is deprecated, please consider moving to secretRef")
+
+		return apiErrorResult(ctx, err)
 	}
-	_, err = psos.Login(endpoint, username, password)
 
+	controllerIdentifier, err := utils.ControllerIdentifier(ctx, r.Client)
 	if err != nil {
-		if err := region.UpdateRegionCondition(ctx, r.Client, pcov1alpha1.RegionIsUnready, err.Error()); err != nil {
-			return ctrl.Result{}, err
-		}
+		return ctrl.Result{}, err
+	}
 
+	if err := region.UpdateControllerID(ctx, r.Client, *controllerIdentifier); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := region.UpdateRegionCondition(ctx, r.Client, pcov1alpha1.RegionIsReady, "API of region is reachable"); err != nil {
+	if err := region.UpdateRegionCondition(ctx, r.Client, pcov1beta1.RegionIsReady, "API of region is reachable"); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Reconciling finished")
+	if apiRequeueAfter > 0 && apiRequeueAfter < time.Minute*15 {
+		return ctrl.Result{RequeueAfter: apiRequeueAfter}, nil
+	}
 	return ctrl.Result{RequeueAfter: time.Minute * 15}, nil
 }
 
+// updateAPIConditions reflects the state of the rate limit and circuit breaker of the region within its conditions.
+// It returns the duration after which the state expires, zero if requests currently pass
+func (r *RegionReconciler) updateAPIConditions(ctx context.Context, region *pcov1beta1.Region) (time.Duration, error) {
+	state := regionGuard(*region).State()
+	now := time.Now()
+
+	var requeueAfter time.Duration
+	throttledMessage, unavailableMessage := "", ""
+
+	if state.Throttled(now) {
+		throttledMessage = "Requests to the APIs of the region exceed its rate limit"
+		requeueAfter = state.ThrottledUntil.Sub(now)
+	}
+
+	if state.Open(now) {
+		unavailableMessage = fmt.Sprintf("Requests to the APIs of the region are stopped after repeated failures: %s", state.LastError)
+		if openFor := state.OpenUntil.Sub(now); requeueAfter == 0 || openFor < requeueAfter {
+			requeueAfter = openFor
+		}
+	}
+
+	return requeueAfter, region.UpdateAPIConditions(ctx, r.Client, throttledMessage, unavailableMessage)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *RegionReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	pred := predicate.GenerationChangedPredicate{}
+	//Regions get reconciled once they are throttled or their circuit opens or closes, so the API conditions follow
+	apiChanges := make(chan event.GenericEvent, 64)
+	throttle.OnChange(func(region string) {
+		select {
+		case apiChanges <- event.GenericEvent{Object: &pcov1beta1.Region{ObjectMeta: metav1.ObjectMeta{Name: region}}}:
+		default:
+		}
+	})
+
+	pred := reconcileTriggers()
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
 		}).
 		WithEventFilter(pred).
-		For(&pcov1alpha1.Region{}).
+		For(&pcov1beta1.Region{}).
+		WatchesRawSource(source.Channel(apiChanges, &handler.EnqueueRequestForObject{})).
 		Complete(r)
 }
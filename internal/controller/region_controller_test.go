@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+)
+
+var _ = Describe("Region controller", func() {
+	ctx := context.Background()
+
+	It("logs into the reseller API with the credentials of the referenced secret", func() {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testControllerNamespace, Name: "region-secretref"},
+			StringData: map[string]string{
+				"endpoint": cloud.ResellerURL(),
+				"username": testResellerUsername,
+				"password": testResellerPassword,
+			},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+		region := &pcov1beta1.Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-secretref"},
+			Spec: pcov1beta1.RegionSpec{
+				SecretRef: &pcov1beta1.SecretRef{Namespace: secret.Namespace, Name: secret.Name},
+			},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(Succeed())
+
+		expectReady(ctx, region)
+		Expect(controllerutil.ContainsFinalizer(region, controllerFinalizer)).To(BeTrue())
+		Expect(region.Status.ControllerID).To(Equal(testControllerId))
+
+		By("tracking the generation of updates")
+		region.Spec.OrphanCollection = &pcov1beta1.OrphanCollectionSpec{Interval: &metav1.Duration{Duration: timeout}}
+		Expect(k8sClient.Update(ctx, region)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(region), region)).To(Succeed())
+			g.Expect(region.Status.ObservedGeneration).To(Equal(region.Generation))
+			g.Expect(region.IsReady()).To(BeTrue())
+		}, timeout, interval).Should(Succeed())
+
+		By("removing the finalizer on deletion")
+		expectGone(ctx, region)
+	})
+
+	It("reports rejected credentials", func() {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testControllerNamespace, Name: "region-rejected"},
+			StringData: map[string]string{
+				"endpoint": cloud.ResellerURL(),
+				"username": testResellerUsername,
+				"password": "wrong",
+			},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+		By("looking up the secret within the namespace of the operator")
+		region := &pcov1beta1.Region{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-rejected"},
+			Spec: pcov1beta1.RegionSpec{
+				SecretRef: &pcov1beta1.SecretRef{Name: secret.Name},
+			},
+		}
+		Expect(k8sClient.Create(ctx, region)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(region), region)).To(Succeed())
+			g.Expect(meta.IsStatusConditionFalse(region.Status.Conditions, string(pcov1beta1.RegionReady))).To(BeTrue())
+		}, timeout, interval).Should(Succeed())
+		Expect(region.IsReady()).To(BeFalse())
+
+		By("recovering once the credentials are fixed")
+		secret.StringData = map[string]string{"password": testResellerPassword}
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+		region.Annotations = map[string]string{pcov1beta1.ReconcileAtAnnotation: "fixed"}
+		Expect(k8sClient.Update(ctx, region)).To(Succeed())
+		expectReady(ctx, region)
+
+		expectGone(ctx, region)
+	})
+
+	It("blocks the deletion while projects reference the region", func() {
+		region := createReadyRegion(ctx, "region-referenced")
+		createNamespace(ctx, "region-referenced")
+
+		project := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "region-referenced", Name: "project"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+		expectReady(ctx, project)
+
+		Expect(k8sClient.Delete(ctx, region)).To(Succeed())
+		Consistently(func() error {
+			return k8sClient.Get(ctx, client.ObjectKeyFromObject(region), region)
+		}, "2s", interval).Should(Succeed())
+
+		expectGone(ctx, project)
+		expectGone(ctx, region)
+	})
+})
@@ -5,29 +5,23 @@ import (
 	"errors"
 
 	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var errProjectStillReferencingRegion = errors.New("projects are still referencing region")
 
-func (r *RegionReconciler) finalizeRegion(ctx context.Context, logger logr.Logger, region v1alpha1.Region) error {
+func (r *RegionReconciler) finalizeRegion(ctx context.Context, logger logr.Logger, region v1beta1.Region) error {
 	//Check if any projects reference region
-	//Namespace: "" = All namespaces
-	projectList := &v1alpha1.ProjectList{}
-	if err := r.List(ctx, projectList, &client.ListOptions{Namespace: ""}); err != nil {
+	projectList := &v1beta1.ProjectList{}
+	if err := r.List(ctx, projectList, client.MatchingFields{projectRegionField: region.Name}); err != nil {
 		return err
 	}
 
-	referenceExists := false
-	for _, k := range projectList.Items {
-		if k.Spec.Region == region.Name {
-			referenceExists = true
-		}
-	}
-
-	if referenceExists {
+	if len(projectList.Items) > 0 {
 		logger.Info("Projects are still referencing region. Blocking deletion")
+		r.Recorder.Eventf(&region, v1.EventTypeWarning, eventReasonDeletionBlocked, "%d projects are still referencing region", len(projectList.Items))
 		return errProjectStillReferencingRegion
 	}
 
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var errRegionCredentialsMissing = errors.New("region without secretRef must define all of endpoint, username, password")
+
+// regionCredentials returns the endpoint, username and password of the reseller API either from the referenced Secret
+// or from the inline credentials the region was created with in v1alpha1
+func regionCredentials(ctx context.Context, c client.Client, region v1beta1.Region) (string, string, string, error) {
+	if region.Spec.SecretRef != nil {
+		credentialSecret := corev1.Secret{}
+		if err := c.Get(ctx, region.Spec.SecretRef.NamespacedName(utils.ControllerNamespace()), &credentialSecret); err != nil {
+			return "", "", "", err
+		}
+
+		return string(credentialSecret.Data["endpoint"]), string(credentialSecret.Data["username"]), string(credentialSecret.Data["password"]), nil
+	}
+
+	credentials, err := region.LegacyCredentials()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if credentials == nil || credentials.Endpoint == "" || credentials.Username == "" || credentials.Password == "" {
+		return "", "", "", errRegionCredentialsMissing
+	}
+
+	log.FromContext(ctx).Info("specifying region credentials within CR is deprecated, please consider moving to secretRef")
+	return credentials.Endpoint, credentials.Username, credentials.Password, nil
+}
+
+// regionGuard returns the rate limit and circuit breaker shared by all requests to the APIs of the region
+func regionGuard(region v1beta1.Region) *throttle.Guard {
+	return throttle.For(region.Name, throttle.Options{
+		RequestsPerSecond: float64(region.Spec.RateLimit.RequestsPerSecondValue()),
+		Burst:             int(region.Spec.RateLimit.BurstValue()),
+		MaxWait:           region.Spec.RateLimit.MaxWaitDuration(),
+		FailureThreshold:  int(region.Spec.CircuitBreaker.FailureThresholdValue()),
+		OpenDuration:      region.Spec.CircuitBreaker.OpenDurationValue(),
+	})
+}
+
+// loginReseller logs into the reseller API of the region with the factory of the reconciler, defaulting to reseller.Login.
+// The login and all requests of the returned client pass the guard of the region, projects and users are looked up within its inventory.
+// Writes are audited and only planned while the reconcile is in plan mode
+func loginReseller(ctx context.Context, factory reseller.Factory, region v1beta1.Region, endpoint string, username string, password string) (reseller.ResellerClient, error) {
+	if factory == nil {
+		factory = reseller.Login
+	}
+
+	guard := regionGuard(region)
+
+	var client reseller.ResellerClient
+	if err := guard.Do(ctx, func() (err error) {
+		client, err = factory(ctx, region.Name, endpoint, username, password)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	inventory := reseller.InventoryFor(region.Name, endpoint+"/"+username)
+	return reseller.Plan(reseller.Cache(reseller.Audit(reseller.Guard(client, guard), region.Name, inventory), inventory)), nil
+}
+
+// regionResellerClient logs into the reseller API of the region with the credentials resolved by regionCredentials
+func regionResellerClient(ctx context.Context, c client.Client, factory reseller.Factory, region v1beta1.Region) (reseller.ResellerClient, error) {
+	endpoint, username, password, err := regionCredentials(ctx, c, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginReseller(ctx, factory, region, endpoint, username, password)
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// RekeyOptions configure the migration to another controller identifier
+type RekeyOptions struct {
+	// From is the current controller identifier
+	From string
+	// To is the new controller identifier
+	To string
+	// DryRun only logs the renames without changing anything
+	DryRun bool
+}
+
+// Rekey renames the OpenStack projects and users of all regions from the controller identifier From to To,
+// rewrites the usernames within the credential secrets of the users and finally stores To as controller identifier.
+// The operator has to be stopped meanwhile. If Rekey fails midway, it can be repeated with the same options
+func Rekey(ctx context.Context, c client.Client, opts RekeyOptions) error {
+	logger := log.FromContext(ctx)
+
+	for _, k := range []string{opts.From, opts.To} {
+		if err := utils.ValidateControllerIdentifier(k); err != nil {
+			return err
+		}
+	}
+
+	if opts.From == opts.To {
+		return fmt.Errorf("controller ids to migrate from and to are both %s", opts.From)
+	}
+
+	if !opts.DryRun {
+		if err := utils.ResumeControllerIdentifierReplacement(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	stored, err := utils.ControllerIdentifier(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	//The OpenStack names are migrated before the controller id gets stored, so there's nothing left to repeat
+	if *stored == opts.To {
+		logger.Info(fmt.Sprintf("Controller id already changed to %s", opts.To))
+		return nil
+	}
+
+	if *stored != opts.From {
+		return fmt.Errorf("stored controller id is %s, not %s", *stored, opts.From)
+	}
+
+	regions := &v1beta1.RegionList{}
+	if err := c.List(ctx, regions); err != nil {
+		return err
+	}
+
+	for _, k := range regions.Items {
+		if err := rekeyRegion(ctx, c, k, opts); err != nil {
+			return fmt.Errorf("region %s: %w", k.Name, err)
+		}
+	}
+
+	users := &v1beta1.UserList{}
+	if err := c.List(ctx, users); err != nil {
+		return err
+	}
+
+	for _, k := range users.Items {
+		if err := rekeyUserSecret(ctx, c, k, opts); err != nil {
+			return fmt.Errorf("user %s/%s: %w", k.Namespace, k.Name, err)
+		}
+	}
+
+	if opts.DryRun {
+		logger.Info(fmt.Sprintf("Dry run finished, controller id stays %s", opts.From))
+		return nil
+	}
+
+	if err := utils.ReplaceControllerIdentifier(ctx, c, opts.To); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Controller id changed from %s to %s", opts.From, opts.To))
+	return nil
+}
+
+func rekeyRegion(ctx context.Context, c client.Client, region v1beta1.Region, opts RekeyOptions) error {
+	logger := log.FromContext(ctx).WithValues("region", region.Name)
+
+	endpoint, username, password, err := regionCredentials(ctx, c, region)
+	if err != nil {
+		return err
+	}
+
+	psOsClient, err := loginReseller(ctx, nil, region, endpoint, username, password)
+	if err != nil {
+		return err
+	}
+
+	projects, err := psOsClient.GetProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range *projects {
+		if !utils.IsOpenStackProjectOfController(opts.From, k.Name) {
+			continue
+		}
+
+		name := rekeyProjectName(k.Name, opts.From, opts.To)
+		logger.Info(fmt.Sprintf("Renaming project %s from %s to %s", k.Id, k.Name, name))
+		if opts.DryRun {
+			continue
+		}
+
+		if _, err := psOsClient.UpdateProject(ctx, k.Id, openapi.ProjectUpdate{Name: &name}); err != nil {
+			return err
+		}
+	}
+
+	users, err := psOsClient.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range *users {
+		if !utils.IsOpenStackUserOfController(opts.From, k.Name) {
+			continue
+		}
+
+		name := strings.TrimSuffix(k.Name, fmt.Sprintf("@%s.k8s", opts.From)) + fmt.Sprintf("@%s.k8s", opts.To)
+		logger.Info(fmt.Sprintf("Renaming user %s from %s to %s", k.Id, k.Name, name))
+		if opts.DryRun {
+			continue
+		}
+
+		if _, err := psOsClient.UpdateUser(ctx, k.Id, openapi.UpdateOpenStackUser{Name: &name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rekeyProjectName replaces the controller id within a project name, which may be prefixed by its domain
+func rekeyProjectName(name string, from string, to string) string {
+	//The domain prepended by the reseller API is dropped, as on creation
+	return to + "-" + strings.TrimPrefix(utils.TrimOpenStackProjectDomain(name), from+"-")
+}
+
+// rekeySecretSuffix names the replacement of a user secret, which holds the rewritten credentials while the immutable secret gets recreated
+const rekeySecretSuffix = "-rekey"
+
+// rekeyUserSecret recreates the immutable credential secret of the user with the new username, keeping the password.
+// The rewritten credentials are stored within a replacement secret first, so an interrupted rewrite can be completed by repeating it
+func rekeyUserSecret(ctx context.Context, c client.Client, user v1beta1.User, opts RekeyOptions) error {
+	logger := log.FromContext(ctx)
+	accessSecretName := user.UserAccessSecretName()
+
+	replacement := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: accessSecretName.Namespace, Name: accessSecretName.Name + rekeySecretSuffix}, replacement); err == nil {
+		logger.Info(fmt.Sprintf("Completing interrupted rewrite of secret %s", accessSecretName))
+		if opts.DryRun {
+			return nil
+		}
+
+		return replaceUserSecret(ctx, c, accessSecretName, replacement)
+	} else if !k8errors.IsNotFound(err) {
+		return err
+	}
+
+	accessSecret := &v1.Secret{}
+	if err := c.Get(ctx, accessSecretName, accessSecret); err != nil {
+		if k8errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if string(accessSecret.Data[secretUsernameKey]) != user.MailFor(opts.From) {
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("Rewriting username within secret %s to %s", accessSecretName, user.MailFor(opts.To)))
+	if opts.DryRun {
+		return nil
+	}
+
+	isTrue := true
+	replacement = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       accessSecret.Namespace,
+			Name:            accessSecret.Name + rekeySecretSuffix,
+			Labels:          accessSecret.Labels,
+			Annotations:     accessSecret.Annotations,
+			OwnerReferences: accessSecret.OwnerReferences,
+		},
+		Type:      accessSecret.Type,
+		Immutable: &isTrue,
+		Data: map[string][]byte{
+			secretUsernameKey: []byte(user.MailFor(opts.To)),
+			secretPasswordKey: accessSecret.Data[secretPasswordKey],
+		},
+	}
+
+	if err := c.Create(ctx, replacement); err != nil {
+		return err
+	}
+
+	return replaceUserSecret(ctx, c, accessSecretName, replacement)
+}
+
+// replaceUserSecret recreates the user secret from its replacement and deletes the replacement afterwards
+func replaceUserSecret(ctx context.Context, c client.Client, accessSecretName types.NamespacedName, replacement *v1.Secret) error {
+	if err := c.Delete(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: accessSecretName.Namespace, Name: accessSecretName.Name}}); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	isTrue := true
+	if err := c.Create(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       accessSecretName.Namespace,
+			Name:            accessSecretName.Name,
+			Labels:          replacement.Labels,
+			Annotations:     replacement.Annotations,
+			OwnerReferences: replacement.OwnerReferences,
+		},
+		Type:      replacement.Type,
+		Immutable: &isTrue,
+		Data:      replacement.Data,
+	}); err != nil {
+		return err
+	}
+
+	return client.IgnoreNotFound(c.Delete(ctx, replacement))
+}
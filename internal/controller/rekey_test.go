@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+)
+
+func TestRekeyProjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		want    string
+	}{
+		{"with domain", "reseller-abc-ns-project", "xyz-ns-project"},
+		{"without domain", "abc-ns-project", "xyz-ns-project"},
+		{"controller id within the namespace", "reseller-abc-abc-project", "xyz-abc-project"},
+		{"hyphenated namespace", "reseller-abc-my-ns-project", "xyz-my-ns-project"},
+	}
+
+	for _, k := range tests {
+		t.Run(k.name, func(t *testing.T) {
+			if got := rekeyProjectName(k.project, "abc", "xyz"); got != k.want {
+				t.Errorf("rekeyProjectName(%q) = %q, want %q", k.project, got, k.want)
+			}
+		})
+	}
+}
+
+func TestRekeyUserSecret(t *testing.T) {
+	ctx := context.Background()
+	opts := RekeyOptions{From: "abc", To: "xyz"}
+	user := pcov1beta1.User{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "user"}}
+	accessSecretName := user.UserAccessSecretName()
+
+	credentials := func(name string, username string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: accessSecretName.Namespace, Name: name, Labels: map[string]string{userLabel: user.Name}},
+			Data: map[string][]byte{
+				secretUsernameKey: []byte(username),
+				secretPasswordKey: []byte("password"),
+			},
+		}
+	}
+
+	expectRekeyed := func(t *testing.T, c *fake.ClientBuilder) {
+		t.Helper()
+
+		cl := c.Build()
+		if err := rekeyUserSecret(ctx, cl, user, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		accessSecret := &v1.Secret{}
+		if err := cl.Get(ctx, accessSecretName, accessSecret); err != nil {
+			t.Fatal(err)
+		}
+
+		if string(accessSecret.Data[secretUsernameKey]) != user.MailFor("xyz") || string(accessSecret.Data[secretPasswordKey]) != "password" {
+			t.Errorf("unexpected credentials %v", accessSecret.Data)
+		}
+
+		if accessSecret.Labels[userLabel] != user.Name {
+			t.Errorf("expected the labels to be kept, got %v", accessSecret.Labels)
+		}
+
+		err := cl.Get(ctx, types.NamespacedName{Namespace: accessSecretName.Namespace, Name: accessSecretName.Name + rekeySecretSuffix}, &v1.Secret{})
+		if !k8errors.IsNotFound(err) {
+			t.Errorf("expected the replacement secret to be deleted, got %v", err)
+		}
+	}
+
+	t.Run("rewrites the username", func(t *testing.T) {
+		expectRekeyed(t, fake.NewClientBuilder().WithObjects(credentials(accessSecretName.Name, user.MailFor("abc"))))
+	})
+
+	t.Run("completes a rewrite interrupted after deleting the secret", func(t *testing.T) {
+		expectRekeyed(t, fake.NewClientBuilder().WithObjects(credentials(accessSecretName.Name+rekeySecretSuffix, user.MailFor("xyz"))))
+	})
+
+	t.Run("completes a rewrite interrupted before deleting the secret", func(t *testing.T) {
+		expectRekeyed(t, fake.NewClientBuilder().WithObjects(
+			credentials(accessSecretName.Name, user.MailFor("abc")),
+			credentials(accessSecretName.Name+rekeySecretSuffix, user.MailFor("xyz")),
+		))
+	})
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/sink"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// allNamespaces allows the delivery of credentials into every namespace
+const allNamespaces = "*"
+
+// managedByLabel marks all secrets generated by the operator, so they can be discovered with a label selector
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+const managedByValue = "pco-reseller-operator"
+
+// errSecretNotManaged is returned when a secret occupies the name of a generated secret without being generated for its owner
+var errSecretNotManaged = errors.New("secret isn't managed by the operator")
+
+// secretNamespaceAllowed checks if credentials of a resource within ownerNamespace may be delivered into targetNamespace
+func secretNamespaceAllowed(allowedNamespaces []string, ownerNamespace string, targetNamespace string) bool {
+	if ownerNamespace == targetNamespace {
+		return true
+	}
+
+	for _, k := range allowedNamespaces {
+		if k == allNamespaces || k == targetNamespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applySecretTemplate adds the labels and annotations of the template to the secret
+func applySecretTemplate(secret *v1.Secret, template *v1beta1.SecretTemplate) {
+	if template == nil {
+		return
+	}
+
+	for k, v := range template.Labels {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[k] = v
+	}
+
+	for k, v := range template.Annotations {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[k] = v
+	}
+}
+
+// ownSecret labels the secret as generated for the owner and makes the owner its controller.
+// Owner references can't cross namespaces, so secrets delivered into other namespaces are only tracked by their labels
+func ownSecret(secret *v1.Secret, owner client.Object, scheme *runtime.Scheme, nameLabel string, namespaceLabel string) error {
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[managedByLabel] = managedByValue
+	secret.Labels[nameLabel] = owner.GetName()
+	secret.Labels[namespaceLabel] = owner.GetNamespace()
+
+	if secret.Namespace != owner.GetNamespace() {
+		return nil
+	}
+
+	return controllerutil.SetControllerReference(owner, secret, scheme)
+}
+
+// secretManagedFor checks whether the secret got labeled by ownSecret for the owner.
+// Secrets of older operator versions aren't labeled yet, so unlabeled secrets within the namespace of the owner are adopted
+// unless another object controls them. Secrets of others are never modified or deleted, even if they occupy the name of the generated secret
+func secretManagedFor(secret *v1.Secret, owner client.Object, nameLabel string, namespaceLabel string) bool {
+	labels := secret.GetLabels()
+	if labels[managedByLabel] == managedByValue {
+		return labels[nameLabel] == owner.GetName() && labels[namespaceLabel] == owner.GetNamespace()
+	}
+
+	if secret.Namespace != owner.GetNamespace() {
+		return false
+	}
+
+	if len(secret.OwnerReferences) == 0 {
+		return true
+	}
+
+	controller := metav1.GetControllerOf(secret)
+	return controller != nil && controller.UID == owner.GetUID()
+}
+
+// secretToOwner enqueues the owner referenced by the labels of a secret delivered into another namespace.
+// Secrets within the namespace of their owner are watched via their owner reference
+func secretToOwner(nameLabel string, namespaceLabel string) handler.MapFunc {
+	return func(_ context.Context, obj client.Object) []reconcile.Request {
+		name, ok := obj.GetLabels()[nameLabel]
+		if !ok {
+			return nil
+		}
+
+		//Fall back to the namespace of the secret
+		namespace, ok := obj.GetLabels()[namespaceLabel]
+		if !ok {
+			namespace = obj.GetNamespace()
+		}
+
+		//Secrets of older operator versions have no owner reference yet
+		if namespace == obj.GetNamespace() && metav1.GetControllerOf(obj) != nil {
+			return nil
+		}
+
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		}}}
+	}
+}
+
+// writeToSinks delivers the credentials to all external sinks of the template of a resource within namespace
+func writeToSinks(ctx context.Context, c client.Client, template *v1beta1.SecretTemplate, namespace string, data map[string]string) error {
+	sinks, err := sink.FromTemplate(ctx, c, template, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range sinks {
+		if err := k.Write(ctx, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteFromSinks removes the credentials from all external sinks of the template of a resource within namespace
+func deleteFromSinks(ctx context.Context, c client.Client, template *v1beta1.SecretTemplate, namespace string) error {
+	sinks, err := sink.FromTemplate(ctx, c, template, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range sinks {
+		if err := k.Delete(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// secretStringData returns the data of the secret as strings
+func secretStringData(secret *v1.Secret) map[string]string {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+
+	return data
+}
@@ -17,20 +17,31 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
-	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/fakeopenstack"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -40,6 +51,15 @@ import (
 var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
+var cloud *fakeopenstack.Cloud
+var cancel context.CancelFunc
+
+const (
+	testControllerNamespace = "pco-reseller-operator-system"
+	testControllerId        = "envtest"
+	testResellerUsername    = "reseller"
+	testResellerPassword    = "reseller-password"
+)
 
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -62,7 +82,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = pcov1alpha1.AddToScheme(scheme.Scheme)
+	err = pcov1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:scheme
@@ -71,10 +91,126 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
 
+	By("starting the fake reseller API and Keystone")
+	cloud = fakeopenstack.New(testResellerUsername, testResellerPassword)
+
+	By("storing the controller id")
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+
+	Expect(os.Setenv("CONTROLLER_NAMESPACE", testControllerNamespace)).To(Succeed())
+	Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testControllerNamespace}})).To(Succeed())
+	_, err = utils.EnsureControllerIdentifier(ctx, k8sClient, testControllerId)
+	Expect(err).NotTo(HaveOccurred())
+
+	By("starting the manager")
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme.Scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(SetupFieldIndexes(ctx, mgr)).To(Succeed())
+	Expect((&RegionReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("region-controller"),
+		NewResellerClient: reseller.Login,
+	}).SetupWithManager(mgr)).To(Succeed())
+	Expect((&ProjectReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("project-controller"),
+		NewResellerClient: reseller.Login,
+	}).SetupWithManager(mgr)).To(Succeed())
+	Expect((&UserReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("user-controller"),
+	}).SetupWithManager(mgr)).To(Succeed())
+	Expect((&UserProjectBindingReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		Recorder:          mgr.GetEventRecorderFor("userprojectbinding-controller"),
+		NewResellerClient: reseller.Login,
+		KeystoneEndpoint: func(string) (string, error) {
+			return cloud.KeystoneURL(), nil
+		},
+	}).SetupWithManager(mgr)).To(Succeed())
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(ctx)).To(Succeed())
+	}()
 })
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
+	if cancel != nil {
+		cancel()
+	}
+	if cloud != nil {
+		cloud.Close()
+	}
+
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
+
+const (
+	timeout  = 30 * time.Second
+	interval = 250 * time.Millisecond
+)
+
+// createNamespace creates the namespace of a spec, which is left behind as envtest doesn't delete namespaces
+func createNamespace(ctx context.Context, name string) {
+	Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})).To(Succeed())
+}
+
+// createReadyRegion creates a region logging into the fake reseller API with the credentials of a secret and waits for it to become ready
+func createReadyRegion(ctx context.Context, name string) *pcov1beta1.Region {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testControllerNamespace, Name: name},
+		StringData: map[string]string{
+			"endpoint": cloud.ResellerURL(),
+			"username": testResellerUsername,
+			"password": testResellerPassword,
+		},
+	}
+	Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+	region := &pcov1beta1.Region{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: pcov1beta1.RegionSpec{
+			SecretRef: &pcov1beta1.SecretRef{Namespace: secret.Namespace, Name: secret.Name},
+		},
+	}
+	Expect(k8sClient.Create(ctx, region)).To(Succeed())
+
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(region), region)).To(Succeed())
+		g.Expect(region.IsReady()).To(BeTrue())
+	}, timeout, interval).Should(Succeed())
+
+	return region
+}
+
+// expectReady waits until the object is ready for its current generation
+func expectReady(ctx context.Context, obj interface {
+	client.Object
+	IsReady() bool
+}) {
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		g.Expect(obj.IsReady()).To(BeTrue())
+	}, timeout, interval).Should(Succeed())
+}
+
+// expectGone deletes the object and waits until its finalizer has been removed
+func expectGone(ctx context.Context, obj client.Object) {
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+
+	Eventually(func() bool {
+		return k8errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj))
+	}, timeout, interval).Should(BeTrue())
+}
@@ -22,17 +22,21 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
-	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	"github.com/sethvargo/go-password/password"
 )
 
@@ -40,12 +44,22 @@ import (
 type UserReconciler struct {
 	client.Client
 
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// AllowedSecretNamespaces are the namespaces into which credentials of other namespaces may be delivered
+	AllowedSecretNamespaces []string
 }
 
 const secretUsernameKey = "username"
 const secretPasswordKey = "password"
 
+// userLabel references the user owning the labeled access secret
+const userLabel = "pco.plusserver.com/user"
+
+// userNamespaceLabel references the namespace of the user, as secrets may be delivered into other namespaces
+const userNamespaceLabel = "pco.plusserver.com/user-namespace"
+
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=users,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=users/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=users/finalizers,verbs=update
@@ -61,11 +75,19 @@ const secretPasswordKey = "password"
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "User.Reconcile", tracing.ObjectAttributes("User", req.NamespacedName)...)
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *UserReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling User")
 
 	// Fetch the User instance
-	user := &pcov1alpha1.User{}
+	user := &pcov1beta1.User{}
 	err := r.Get(ctx, req.NamespacedName, user)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -81,6 +103,11 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, err
 	}
 
+	//Paused objects are neither reconciled nor finalized
+	if paused, err := reconcilePaused(ctx, r.Client, user); err != nil || paused {
+		return ctrl.Result{}, err
+	}
+
 	// Check if the user is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
 	isUserMarkedToBeDelted := user.GetDeletionTimestamp() != nil
@@ -89,7 +116,9 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 			// Run finalization logic for controllerFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeUser(ctx, logger, *user); err != nil {
+			if err := tracing.Phase(ctx, "User.Finalize", func(ctx context.Context) error {
+				return r.finalizeUser(ctx, logger, *user)
+			}); err != nil {
 				if err != errUserProjectBindingPresent {
 					return ctrl.Result{}, err
 				}
@@ -121,6 +150,15 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	accessSecretName := user.UserAccessSecretName()
 
+	if !secretNamespaceAllowed(r.AllowedSecretNamespaces, user.Namespace, accessSecretName.Namespace) {
+		if err := user.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserSecretNamespaceNotAllowed, fmt.Sprintf("Secrets may not be delivered into namespace %s", accessSecretName.Namespace)); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		logger.Info("Secret namespace not allowed")
+		return ctrl.Result{}, nil
+	}
+
 	//Ensure secret with access information
 	accessSecret := &v1.Secret{}
 	err = r.Get(ctx, accessSecretName, accessSecret)
@@ -131,7 +169,7 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 
 		//As we need to generate the secret -> Update status to not ready
-		if err := user.UpdateUserCondition(ctx, r.Client, pcov1alpha1.UserHasNoSecret, fmt.Sprintf("Secret %s missing", accessSecretName.Name)); err != nil {
+		if err := user.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserHasNoSecret, fmt.Sprintf("Secret %s missing", accessSecretName.Name)); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -158,15 +196,52 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 				secretPasswordKey: generatedPassword,
 			},
 		}
+		applySecretTemplate(accessSecret, user.Spec.SecretTemplate)
+		if err := ownSecret(accessSecret, user, r.Scheme, userLabel, userNamespaceLabel); err != nil {
+			return ctrl.Result{}, err
+		}
 
 		if err := r.Create(ctx, accessSecret); err != nil {
+			r.Recorder.Eventf(user, v1.EventTypeWarning, eventReasonSecretWriteFailed, "Creating secret %s failed: %s", accessSecretName, err)
 			return ctrl.Result{}, err
 		}
 
 		logger.Info("User secret created")
+		r.Recorder.Eventf(user, v1.EventTypeNormal, eventReasonSecretCreated, "Secret %s created", accessSecretName)
+	} else if !secretManagedFor(accessSecret, user, userLabel, userNamespaceLabel) {
+		//The password of a foreign secret is neither adopted nor overwritten
+		if err := user.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserSecretNotManaged, fmt.Sprintf("Secret %s isn't managed by the operator for this user", accessSecretName)); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		logger.Info("Secret not managed")
+		return ctrl.Result{}, nil
+	} else {
+		//Labels, annotations and owner references of immutable secrets can still be changed
+		oldAccessSecret := accessSecret.DeepCopy()
+		applySecretTemplate(accessSecret, user.Spec.SecretTemplate)
+		if err := ownSecret(accessSecret, user, r.Scheme, userLabel, userNamespaceLabel); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !equality.Semantic.DeepEqual(oldAccessSecret.ObjectMeta, accessSecret.ObjectMeta) {
+			if err := r.Patch(ctx, accessSecret, client.MergeFrom(oldAccessSecret)); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if err := writeToSinks(ctx, r.Client, user.Spec.SecretTemplate, user.Namespace, secretStringData(accessSecret)); err != nil {
+		r.Recorder.Eventf(user, v1.EventTypeWarning, eventReasonSecretWriteFailed, "Writing credentials to external store failed: %s", err)
+
+		if err := user.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserIsUnready, err.Error()); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
 	}
 
-	if err := user.UpdateUserCondition(ctx, r.Client, pcov1alpha1.UserIsReady, fmt.Sprintf("Secret %s ensured", accessSecretName.Name)); err != nil {
+	if err := user.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserIsReady, fmt.Sprintf("Secret %s ensured", accessSecretName.Name)); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -176,12 +251,14 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	pred := predicate.GenerationChangedPredicate{}
+	pred := reconcileTriggers()
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
 		}).
-		WithEventFilter(pred).
-		For(&pcov1alpha1.User{}).
+		For(&pcov1beta1.User{}, builder.WithPredicates(pred)).
+		//Recreate access secrets which got deleted
+		Owns(&v1.Secret{}).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(secretToOwner(userLabel, userNamespaceLabel))).
 		Complete(r)
 }
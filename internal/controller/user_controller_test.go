@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+)
+
+var _ = Describe("User controller", Ordered, func() {
+	ctx := context.Background()
+	const namespace = "user-controller"
+
+	BeforeAll(func() {
+		createNamespace(ctx, namespace)
+	})
+
+	It("creates, updates and deletes the credential secret", func() {
+		user := &pcov1beta1.User{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "lifecycle"},
+			Spec:       pcov1beta1.UserSpec{Description: "created"},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		expectReady(ctx, user)
+
+		secret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, user.UserAccessSecretName(), secret)).To(Succeed())
+		Expect(string(secret.Data[secretUsernameKey])).To(Equal(user.MailFor(testControllerId)))
+		Expect(secret.Data[secretPasswordKey]).To(HaveLen(32))
+		password := secret.Data[secretPasswordKey]
+
+		By("applying the secret template to the existing secret")
+		user.Spec.SecretTemplate = &pcov1beta1.SecretTemplate{Labels: map[string]string{"team": "a"}}
+		Expect(k8sClient.Update(ctx, user)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, user.UserAccessSecretName(), secret)).To(Succeed())
+			g.Expect(secret.Labels).To(HaveKeyWithValue("team", "a"))
+		}, timeout, interval).Should(Succeed())
+		Expect(secret.Data[secretPasswordKey]).To(Equal(password))
+		expectReady(ctx, user)
+
+		By("deleting the secret")
+		expectGone(ctx, user)
+		Expect(k8errors.IsNotFound(k8sClient.Get(ctx, user.UserAccessSecretName(), secret))).To(BeTrue())
+	})
+
+	It("owns the credential secret and only deletes its own bindings", func() {
+		user := &pcov1beta1.User{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "owner"}}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		expectReady(ctx, user)
+
+		secret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, user.UserAccessSecretName(), secret)).To(Succeed())
+		Expect(metav1.IsControlledBy(secret, user)).To(BeTrue())
+		Expect(secret.Labels).To(HaveKeyWithValue(managedByLabel, managedByValue))
+		Expect(secret.Labels).To(HaveKeyWithValue(userLabel, user.Name))
+
+		By("recreating the deleted secret")
+		uid := secret.UID
+		Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, user.UserAccessSecretName(), secret)).To(Succeed())
+			g.Expect(secret.UID).NotTo(Equal(uid))
+		}, timeout, interval).Should(Succeed())
+
+		By("keeping bindings of other users")
+		upb := &pcov1beta1.UserProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "other-user"},
+			Spec:       pcov1beta1.UserProjectBindingSpec{User: "other", Project: "unknown"},
+		}
+		Expect(k8sClient.Create(ctx, upb)).To(Succeed())
+
+		expectGone(ctx, user)
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(upb), upb)).To(Succeed())
+		Expect(upb.GetDeletionTimestamp()).To(BeNil())
+
+		expectGone(ctx, upb)
+	})
+
+	It("rejects secrets delivered into other namespaces", func() {
+		user := &pcov1beta1.User{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "foreign"},
+			Spec: pcov1beta1.UserSpec{
+				SecretTemplate: &pcov1beta1.SecretTemplate{Namespace: "default"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)).To(Succeed())
+
+			condition := meta.FindStatusCondition(user.Status.Conditions, string(pcov1beta1.UserReady))
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Reason).To(Equal(string(pcov1beta1.UserSecretNamespaceNotAllowed)))
+		}, timeout, interval).Should(Succeed())
+
+		Expect(k8errors.IsNotFound(k8sClient.Get(ctx, user.UserAccessSecretName(), &v1.Secret{}))).To(BeTrue())
+
+		expectGone(ctx, user)
+	})
+
+	It("adopts the unlabeled secret of an older operator version", func() {
+		user := &pcov1beta1.User{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "legacy"}}
+
+		isTrue := true
+		legacy := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: user.UserAccessSecretName().Name},
+			Immutable:  &isTrue,
+			StringData: map[string]string{
+				secretUsernameKey: user.MailFor(testControllerId),
+				secretPasswordKey: "legacy",
+			},
+		}
+		Expect(k8sClient.Create(ctx, legacy)).To(Succeed())
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+
+		expectReady(ctx, user)
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(legacy), legacy)).To(Succeed())
+		Expect(legacy.Labels).To(HaveKeyWithValue(managedByLabel, managedByValue))
+		Expect(legacy.Labels).To(HaveKeyWithValue(userLabel, user.Name))
+		Expect(string(legacy.Data[secretPasswordKey])).To(Equal("legacy"))
+
+		expectGone(ctx, user)
+	})
+
+	It("neither adopts nor deletes secrets labeled for another user", func() {
+		user := &pcov1beta1.User{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "occupied"}}
+		foreign := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      user.UserAccessSecretName().Name,
+				Labels:    map[string]string{managedByLabel: managedByValue, userLabel: "other", userNamespaceLabel: namespace},
+			},
+			StringData: map[string]string{secretPasswordKey: "foreign"},
+		}
+		Expect(k8sClient.Create(ctx, foreign)).To(Succeed())
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)).To(Succeed())
+
+			condition := meta.FindStatusCondition(user.Status.Conditions, string(pcov1beta1.UserReady))
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Reason).To(Equal(string(pcov1beta1.UserSecretNotManaged)))
+		}, timeout, interval).Should(Succeed())
+
+		expectGone(ctx, user)
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreign), foreign)).To(Succeed())
+		Expect(foreign.Labels).To(HaveKeyWithValue(userLabel, "other"))
+		Expect(string(foreign.Data[secretPasswordKey])).To(Equal("foreign"))
+
+		Expect(k8sClient.Delete(ctx, foreign)).To(Succeed())
+	})
+})
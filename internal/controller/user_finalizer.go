@@ -6,7 +6,7 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	k8errors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,12 +14,10 @@ import (
 
 var errUserProjectBindingPresent = errors.New("UserProjectBinding still referencing user")
 
-func (r *UserReconciler) finalizeUser(ctx context.Context, logger logr.Logger, user v1alpha1.User) error {
-	//CLeanup all UserProjectBindings
-	userProjectBindings := &v1alpha1.UserProjectBindingList{}
-	if err := r.List(ctx, userProjectBindings, &client.ListOptions{
-		Namespace: user.Namespace,
-	}); err != nil {
+func (r *UserReconciler) finalizeUser(ctx context.Context, logger logr.Logger, user v1beta1.User) error {
+	//Cleanup all UserProjectBindings of the user
+	userProjectBindings := &v1beta1.UserProjectBindingList{}
+	if err := r.List(ctx, userProjectBindings, client.InNamespace(user.Namespace), client.MatchingFields{userProjectBindingUserField: user.Name}); err != nil {
 		return err
 	}
 
@@ -30,6 +28,8 @@ func (r *UserReconciler) finalizeUser(ctx context.Context, logger logr.Logger, u
 			}
 		}
 
+		r.Recorder.Eventf(&user, v1.EventTypeNormal, eventReasonDeletionBlocked, "Waiting for %d userprojectbindings to be deleted", len(userProjectBindings.Items))
+
 		return errUserProjectBindingPresent
 	}
 
@@ -40,12 +40,20 @@ func (r *UserReconciler) finalizeUser(ctx context.Context, logger logr.Logger, u
 		}
 
 		logger.Info(fmt.Sprintf("Secret %s already gone", user.UserAccessSecretName()))
+	} else if !secretManagedFor(accessSecret, &user, userLabel, userNamespaceLabel) {
+		logger.Info(fmt.Sprintf("Secret %s isn't managed by the operator for this user, keeping it", user.UserAccessSecretName()))
 	} else {
 		//Secret is still present
 
 		if err := r.Delete(ctx, accessSecret); err != nil {
 			return err
 		}
+
+		r.Recorder.Eventf(&user, v1.EventTypeNormal, eventReasonSecretDeleted, "Secret %s deleted", user.UserAccessSecretName())
+	}
+
+	if err := deleteFromSinks(ctx, r.Client, user.Spec.SecretTemplate, user.Namespace); err != nil {
+		return err
 	}
 
 	logger.Info("User finalized")
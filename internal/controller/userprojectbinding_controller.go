@@ -21,20 +21,27 @@ import (
 	"fmt"
 	"time"
 
-	pcov1alpha1 "github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 )
 
@@ -42,11 +49,21 @@ import (
 type UserProjectBindingReconciler struct {
 	client.Client
 
-	Scheme *runtime.Scheme
-}
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// AllowedSecretNamespaces are the namespaces into which credentials of other namespaces may be delivered
+	AllowedSecretNamespaces []string
+
+	// NewResellerClient logs into the reseller API of a region. Defaults to reseller.Login
+	NewResellerClient reseller.Factory
 
-const userTimeout = 1 * time.Minute
-const projectTimeout = 1 * time.Minute
+	// KeystoneEndpoint resolves the Keystone URL of a region. Defaults to OpenStackIdentityEndpoint
+	KeystoneEndpoint KeystoneEndpointResolver
+
+	// PlanMode plans the mutations of OpenStack instead of executing them, unless the region overrides it
+	PlanMode bool
+}
 
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=userprojectbindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=pco.plusserver.com,resources=userprojectbindings/status,verbs=get;update;patch
@@ -62,11 +79,20 @@ const projectTimeout = 1 * time.Minute
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "UserProjectBinding.Reconcile", tracing.ObjectAttributes("UserProjectBinding", req.NamespacedName)...)
+	ctx = plan.NewContext(ctx)
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *UserProjectBindingReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling UserProjectBinding")
 
 	// Fetch the UBP instance
-	upb := &pcov1alpha1.UserProjectBinding{}
+	upb := &pcov1beta1.UserProjectBinding{}
 	err := r.Get(ctx, req.NamespacedName, upb)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -81,6 +107,12 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		logger.Error(err, "Failed to get ubp.")
 		return ctrl.Result{}, err
 	}
+	ctx = audit.WithObject(ctx, "UserProjectBinding", upb)
+
+	//Paused objects are neither reconciled nor finalized
+	if paused, err := reconcilePaused(ctx, r.Client, upb); err != nil || paused {
+		return ctrl.Result{}, err
+	}
 
 	// Check if the user is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
@@ -90,14 +122,25 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 			// Run finalization logic for controllerFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeUPB(ctx, logger, *upb); err != nil {
+			if err := tracing.Phase(ctx, "UserProjectBinding.Finalize", func(ctx context.Context) error {
+				return r.finalizeUPB(ctx, logger, *upb)
+			}); err != nil {
 				return ctrl.Result{}, err
 			}
 
+			//A planned deprovisioning keeps the finalizer, as the membership and user still exist
+			pending, err := r.updatePlan(ctx, upb)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if pending {
+				return ctrl.Result{RequeueAfter: planRequeueAfter}, nil
+			}
+
 			// Remove controllerFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
 			controllerutil.RemoveFinalizer(upb, controllerFinalizer)
-			err := r.Update(ctx, upb)
+			err = r.Update(ctx, upb)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -114,7 +157,7 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
-	user := &pcov1alpha1.User{}
+	user := &pcov1beta1.User{}
 	if err := r.Get(ctx, types.NamespacedName{
 		Namespace: upb.Namespace,
 		Name:      upb.Spec.User,
@@ -123,24 +166,27 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 			return ctrl.Result{}, err
 		}
 
-		if err := upb.UpdateUserCondition(ctx, r.Client, pcov1alpha1.UserNotFound, err.Error()); err != nil {
+		if err := upb.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserNotFound, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 
+		//The binding gets enqueued again once the user appears
 		logger.Info(fmt.Sprintf("User %s not found, waiting for user to appear", upb.Spec.User))
-		return ctrl.Result{RequeueAfter: time.Duration(3) * time.Second}, nil
+		return ctrl.Result{}, nil
 	}
 
-	if awaitErr := user.AwaitReady(ctx, userTimeout, r.Client, logger); awaitErr != nil {
-		if err := upb.UpdateUserCondition(ctx, r.Client, pcov1alpha1.UserIsUnready, "User isn't ready"); err != nil {
+	if !user.IsReady() {
+		if err := upb.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserIsUnready, "User isn't ready"); err != nil {
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, awaitErr
+		//The binding gets enqueued again once the user becomes ready
+		logger.Info(fmt.Sprintf("User %s isn't ready, waiting for user to become ready", user.Name))
+		return ctrl.Result{}, nil
 	}
 
 	//Set user condition ready
-	if err := upb.UpdateUserCondition(ctx, r.Client, pcov1alpha1.UserIsReady, "User is ready"); err != nil {
+	if err := upb.UpdateUserCondition(ctx, r.Client, pcov1beta1.UserIsReady, "User is ready"); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -149,7 +195,7 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	project := &pcov1alpha1.Project{}
+	project := &pcov1beta1.Project{}
 	if err := r.Get(ctx, types.NamespacedName{
 		Namespace: upb.Namespace,
 		Name:      upb.Spec.Project,
@@ -158,35 +204,41 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 			return ctrl.Result{}, err
 		}
 
-		if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1alpha1.ProjectNotFound, err.Error()); err != nil {
+		if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1beta1.ProjectNotFound, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 
+		//The binding gets enqueued again once the project appears
 		logger.Info(fmt.Sprintf("Project %s not found, waiting for project to appear", upb.Spec.Project))
-		return ctrl.Result{RequeueAfter: time.Duration(3) * time.Second}, nil
+		return ctrl.Result{}, nil
 	}
 
-	if awaitErr := project.AwaitReady(ctx, projectTimeout, r.Client, logger); awaitErr != nil {
-		if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1alpha1.ProjectIsUnready, "Project isn't ready"); err != nil {
+	if !project.IsReady() {
+		if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1beta1.ProjectIsUnready, "Project isn't ready"); err != nil {
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, awaitErr
+		//The binding gets enqueued again once the project becomes ready
+		logger.Info(fmt.Sprintf("Project %s isn't ready, waiting for project to become ready", project.Name))
+		return ctrl.Result{}, nil
 	}
 
 	//Set project condition ready
-	if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1alpha1.ProjectIsReady, "Project is ready"); err != nil {
+	if err := upb.UpdateProjectCondition(ctx, r.Client, pcov1beta1.ProjectIsReady, "Project is ready"); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	region := &pcov1alpha1.Region{}
+	region := &pcov1beta1.Region{}
 	if err := r.Get(ctx, types.NamespacedName{Name: project.Spec.Region}, region); err != nil {
 		return ctrl.Result{}, err
 	}
+	tracing.SetAttributes(ctx, tracing.RegionKey.String(region.Name))
+	startPlan(ctx, r.PlanMode, *region)
 
-	psOsClient, err := psos.Login(region.Spec.Endpoint, region.Spec.Username, region.Spec.Password)
+	psOsClient, err := regionResellerClient(ctx, r.Client, r.NewResellerClient, *region)
 	if err != nil {
-		return ctrl.Result{}, err
+		r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonLoginFailed, "Login to reseller API of region %s failed: %s", region.Name, err)
+		return r.userProjectBindingAPIError(ctx, upb, err)
 	}
 
 	controllerIdentifier, err := utils.ControllerIdentifier(ctx, r.Client)
@@ -201,7 +253,7 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}))
 
 	if err != nil {
-		return ctrl.Result{}, err
+		return r.userProjectBindingAPIError(ctx, upb, err)
 	}
 
 	mail, err := user.Mail(ctx, r.Client)
@@ -213,7 +265,7 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 	openStackUser, err := utils.GetOpenStackUser(ctx, psOsClient, *mail)
 	if err != nil {
 		if err != utils.ErrOpenStackUserNotFound {
-			return ctrl.Result{}, err
+			return r.userProjectBindingAPIError(ctx, upb, err)
 		}
 	}
 
@@ -227,28 +279,51 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		})
 
 		if err != nil {
-			return ctrl.Result{}, err
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonUserCreateFailed, "Creating OpenStack user %s failed: %s", *mail, err)
+			return r.userProjectBindingAPIError(ctx, upb, err)
 		}
-	} else {
-		password := string(userAccessSecret.Data[secretPasswordKey])
-		openStackUser, err = psOsClient.UpdateUser(ctx, openStackUser.Id, openapi.UpdateOpenStackUser{
+
+		if !plan.Active(ctx) {
+			r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonUserCreated, "OpenStack user %s created with id %s", *mail, openStackUser.Id)
+			r.Recorder.Eventf(user, v1.EventTypeNormal, eventReasonUserCreated, "OpenStack user %s created in region %s with id %s", *mail, region.Name, openStackUser.Id)
+		}
+	} else if passwordChanged := upb.Status.UserSecretUID != userAccessSecret.UID; passwordChanged || !openStackUserMatches(*openStackUser, *mail, user.Spec) {
+		//Update the user within openstack only if it doesn't reflect the current state of the kubernetes object
+		update := openapi.UpdateOpenStackUser{
 			Name:        mail,
 			Description: &user.Spec.Description,
 			Enabled:     user.Spec.Enabled,
 			//DefaultProject: &openStackProject.Id, <- Don't update the default project
-			Password: &password,
-		})
+		}
+		//The password is only sent once the secret of the user got replaced, as Keystone may reject reusing it
+		if passwordChanged {
+			password := string(userAccessSecret.Data[secretPasswordKey])
+			update.Password = &password
+		}
 
+		openStackUser, err = psOsClient.UpdateUser(ctx, openStackUser.Id, update)
 		if err != nil {
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonUserUpdateFailed, "Updating OpenStack user %s failed: %s", *mail, err)
+			return r.userProjectBindingAPIError(ctx, upb, err)
+		}
+
+		if !plan.Active(ctx) {
+			r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonUserUpdated, "OpenStack user %s updated", openStackUser.Id)
+		}
+	}
+
+	if upb.Status.UserSecretUID != userAccessSecret.UID && !plan.Active(ctx) {
+		if err := upb.UpdateUserSecretUID(ctx, r.Client, userAccessSecret.UID); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
 	logger.Info(fmt.Sprintf("Ensured user %s", openStackUser.Id))
+	tracing.SetAttributes(ctx, tracing.OpenStackProjectIDKey.String(openStackProject.Id), tracing.OpenStackUserIDKey.String(openStackUser.Id))
 
 	usersInOpenStackProject, err := psOsClient.GetUsersInProject(ctx, openStackProject.Id)
 	if err != nil {
-		return ctrl.Result{}, err
+		return r.userProjectBindingAPIError(ctx, upb, err)
 	}
 
 	userAlreadyAdded := false
@@ -263,38 +338,167 @@ func (r *UserProjectBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 	if !userAlreadyAdded {
 		if err := psOsClient.AddUserToProject(ctx, openStackProject.Id, openStackUser.Id); err != nil {
-			return ctrl.Result{}, err
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonMemberAddFailed, "Adding user %s to project %s failed: %s", openStackUser.Id, openStackProject.Id, err)
+			return r.userProjectBindingAPIError(ctx, upb, err)
 		}
 
-		logger.Info(fmt.Sprintf("Added user %s to project %s", openStackUser.Id, openStackProject.Id))
+		if !plan.Active(ctx) {
+			logger.Info(fmt.Sprintf("Added user %s to project %s", openStackUser.Id, openStackProject.Id))
+			r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonMemberAdded, "Added user %s to project %s", openStackUser.Id, openStackProject.Id)
+		}
 	}
 
-	if upb.Spec.ApplicationCredential {
-		if err := r.ensureApplicationCredential(ctx, logger, upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey])); err != nil {
+	if err := tracing.Phase(ctx, "UserProjectBinding.EnsureProjectRoles", func(ctx context.Context) error {
+		return r.ensureProjectRoles(ctx, logger, upb, *region, *openStackProject, openStackUser.Id)
+	}); err != nil {
+		if err != errUnknownRoles {
+			return r.userProjectBindingAPIError(ctx, upb, err)
+		}
+
+		//Wait for the spec or the roles of the region to change
+		logger.Info("Requested roles are not available in region")
+		return ctrl.Result{RequeueAfter: 15 * time.Minute}, nil
+	}
+
+	var requeueAfter time.Duration
+	if upb.Spec.ApplicationCredential != nil {
+		if secretNamespace := upb.ApplicationCredentialSecretName().Namespace; !secretNamespaceAllowed(r.AllowedSecretNamespaces, upb.Namespace, secretNamespace) {
+			if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, pcov1beta1.UserProjectBindingSecretNamespaceNotAllowed, fmt.Sprintf("Secrets may not be delivered into namespace %s", secretNamespace)); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			logger.Info("Secret namespace not allowed")
+			return ctrl.Result{}, nil
+		}
+
+		//Checked ahead of issuing a credential, which couldn't be delivered
+		managed, err := r.applicationCredentialSecretManaged(ctx, upb)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
+		if !managed {
+			return r.secretNotManaged(ctx, logger, upb)
+		}
+
+		if err := tracing.Phase(ctx, "UserProjectBinding.EnsureApplicationCredential", func(ctx context.Context) error {
+			requeueAfter, err = r.ensureApplicationCredential(ctx, logger, upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey]))
+			return err
+		}); err != nil {
+			if err == errSecretNotManaged {
+				return r.secretNotManaged(ctx, logger, upb)
+			}
+
+			return r.userProjectBindingAPIError(ctx, upb, err)
+		}
 	} else {
-		if err := r.deprovisionApplicationCredential(ctx, logger, upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey])); err != nil {
-			return ctrl.Result{}, err
+		if err := tracing.Phase(ctx, "UserProjectBinding.DeprovisionApplicationCredential", func(ctx context.Context) error {
+			return r.deprovisionApplicationCredential(ctx, logger, upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey]))
+		}); err != nil {
+			return r.userProjectBindingAPIError(ctx, upb, err)
 		}
 	}
 
-	if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, pcov1alpha1.UserProjectBindingIsReady, "UPB ensured"); err != nil {
+	pending, err := r.updatePlan(ctx, upb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending {
+		logger.Info(fmt.Sprintf("Changes planned, will plan again in %s", planRequeueAfter))
+		return ctrl.Result{RequeueAfter: planRequeueAfter}, nil
+	}
+
+	if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, pcov1beta1.UserProjectBindingIsReady, "UPB ensured"); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Reconciling finished")
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// openStackUserMatches checks if the OpenStack user reflects the name, description and enabled state of the user
+func openStackUserMatches(openStackUser openapi.CreatedOpenStackUser, name string, spec pcov1beta1.UserSpec) bool {
+	if openStackUser.Name != name {
+		return false
+	}
+
+	description := ""
+	if openStackUser.Description != nil {
+		description = *openStackUser.Description
+	}
+	if description != spec.Description {
+		return false
+	}
+
+	//Users without an explicit enabled state keep the one of OpenStack
+	if spec.Enabled != nil && (openStackUser.Enabled == nil || *openStackUser.Enabled != *spec.Enabled) {
+		return false
+	}
+
+	return true
+}
+
+// userProjectBindingAPIError records the failed call to the reseller API or OpenStack within the UserProjectBindingReady condition
+// and returns the result of the reconcile
+func (r *UserProjectBindingReconciler) userProjectBindingAPIError(ctx context.Context, upb *pcov1beta1.UserProjectBinding, err error) (ctrl.Result, error) {
+	if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, userProjectBindingErrorReason(err), err.Error()); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return apiErrorResult(ctx, err)
+}
+
+// secretNotManaged stops the reconcile of a binding, whose secret is occupied by a secret which wasn't generated for it
+func (r *UserProjectBindingReconciler) secretNotManaged(ctx context.Context, logger logr.Logger, upb *pcov1beta1.UserProjectBinding) (ctrl.Result, error) {
+	if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, pcov1beta1.UserProjectBindingSecretNotManaged, fmt.Sprintf("Secret %s isn't managed by the operator for this binding", upb.ApplicationCredentialSecretName())); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Secret not managed")
 	return ctrl.Result{}, nil
 }
 
+// updatePlan records the mutations planned by the reconcile within the status and events of the binding.
+// It returns true if changes are pending, which are reported by the UserProjectBindingReady condition
+func (r *UserProjectBindingReconciler) updatePlan(ctx context.Context, upb *pcov1beta1.UserProjectBinding) (bool, error) {
+	changes := reportPlan(ctx, r.Recorder, upb)
+	if err := upb.UpdatePlannedChanges(ctx, r.Client, changes); err != nil {
+		return false, err
+	}
+
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, pcov1beta1.UserProjectBindingChangesPlanned, fmt.Sprintf("%d changes planned as the region is in plan mode", len(changes))); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *UserProjectBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	pred := predicate.GenerationChangedPredicate{}
+	pred := reconcileTriggers()
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
 		}).
-		WithEventFilter(pred).
-		For(&pcov1alpha1.UserProjectBinding{}).
+		For(&pcov1beta1.UserProjectBinding{}, builder.WithPredicates(pred)).
+		//Continue bindings waiting for their user or project
+		Watches(&pcov1beta1.User{},
+			handler.EnqueueRequestsFromMapFunc(r.userToUserProjectBindings),
+			builder.WithPredicates(readinessChanged(func(obj client.Object) []metav1.Condition {
+				return obj.(*pcov1beta1.User).Status.Conditions
+			})),
+		).
+		Watches(&pcov1beta1.Project{},
+			handler.EnqueueRequestsFromMapFunc(r.projectToUserProjectBindings),
+			builder.WithPredicates(readinessChanged(func(obj client.Object) []metav1.Condition {
+				return obj.(*pcov1beta1.Project).Status.Conditions
+			})),
+		).
+		//Recover application credential secrets which got deleted or modified
+		Owns(&v1.Secret{}).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(secretToOwner(userProjectBindingLabel, userProjectBindingNamespaceLabel))).
 		Complete(r)
 }
@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pcov1beta1 "github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/fakeopenstack"
+	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
+)
+
+var _ = Describe("UserProjectBinding controller", Ordered, func() {
+	ctx := context.Background()
+	const namespace = "userprojectbinding-controller"
+
+	var region *pcov1beta1.Region
+	var project *pcov1beta1.Project
+	var user *pcov1beta1.User
+
+	BeforeAll(func() {
+		createNamespace(ctx, namespace)
+		region = createReadyRegion(ctx, "userprojectbinding-controller")
+
+		project = &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "project"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, project)).To(Succeed())
+
+		user = &pcov1beta1.User{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "user"},
+			Spec:       pcov1beta1.UserSpec{Description: "binding user"},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+
+		expectReady(ctx, project)
+		expectReady(ctx, user)
+	})
+
+	AfterAll(func() {
+		expectGone(ctx, user)
+		expectGone(ctx, project)
+		expectGone(ctx, region)
+	})
+
+	It("creates, updates and deletes the OpenStack user, membership, roles and application credential", func() {
+		upb := &pcov1beta1.UserProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "lifecycle"},
+			Spec: pcov1beta1.UserProjectBindingSpec{
+				Project:               project.Name,
+				User:                  user.Name,
+				Roles:                 []string{"reader"},
+				ApplicationCredential: &pcov1beta1.ApplicationCredentialSpec{},
+			},
+		}
+		Expect(k8sClient.Create(ctx, upb)).To(Succeed())
+		expectReady(ctx, upb)
+
+		openStackProject, ok := cloud.ProjectBySuffix(utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: namespace, Name: project.Name}))
+		Expect(ok).To(BeTrue())
+
+		openStackUser, ok := cloud.UserByName(user.MailFor(testControllerId))
+		Expect(ok).To(BeTrue())
+		Expect(openStackUser.Description).To(Equal("binding user"))
+		Expect(openStackUser.DefaultProject).To(Equal(openStackProject.ID))
+		Expect(openStackProject.Members).To(ConsistOf(openStackUser.ID))
+		Expect(cloud.AssignedRoles(openStackProject.ID, openStackUser.ID)).To(Equal([]string{"reader"}))
+
+		By("issuing the application credential with the password of the user secret")
+		userSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, user.UserAccessSecretName(), userSecret)).To(Succeed())
+		Expect(openStackUser.Password).To(Equal(string(userSecret.Data[secretPasswordKey])))
+
+		Expect(upb.Status.ApplicationCredential).NotTo(BeNil())
+		credentials := cloud.ApplicationCredentials(openStackUser.ID)
+		Expect(credentials).To(HaveLen(1))
+		Expect(credentials[0].ID).To(Equal(upb.Status.ApplicationCredential.CurrentID))
+		Expect(credentials[0].ProjectID).To(Equal(openStackProject.ID))
+
+		credentialSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, upb.ApplicationCredentialSecretName(), credentialSecret)).To(Succeed())
+		Expect(string(credentialSecret.Data[applicationCredentialIdKey])).To(Equal(credentials[0].ID))
+		Expect(string(credentialSecret.Data[applicationCredentialSecretKey])).To(Equal(credentials[0].Secret))
+
+		Expect(upb.Status.UserSecretUID).To(Equal(userSecret.UID))
+		userUpdates := cloud.Requests("PATCH /v1/users/{user_id}")
+
+		By("reconciling changed roles")
+		upb.Spec.Roles = []string{"member", "reader"}
+		Expect(k8sClient.Update(ctx, upb)).To(Succeed())
+
+		Eventually(func() []string {
+			return cloud.AssignedRoles(openStackProject.ID, openStackUser.ID)
+		}, timeout, interval).Should(Equal([]string{"member", "reader"}))
+		expectReady(ctx, upb)
+		Expect(cloud.ApplicationCredentials(openStackUser.ID)).To(HaveLen(1))
+
+		//The OpenStack user already matches the user, so neither an update nor its event is sent
+		Expect(cloud.Requests("PATCH /v1/users/{user_id}")).To(Equal(userUpdates))
+		Consistently(func(g Gomega) {
+			events := &v1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace(namespace), client.MatchingFields{"involvedObject.name": upb.Name})).To(Succeed())
+			for _, event := range events.Items {
+				g.Expect(event.Reason).NotTo(Equal(eventReasonUserUpdated))
+			}
+		}, time.Second, interval).Should(Succeed())
+
+		By("adopting a pending application credential whose secret got written")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(upb), upb)).To(Succeed())
+		issued := upb.Status.ApplicationCredential.CurrentID
+
+		//The reconciliation got interrupted after writing the secret
+		old := upb.DeepCopy()
+		upb.Status.ApplicationCredential.PendingID = issued
+		upb.Status.ApplicationCredential.CurrentID = ""
+		upb.Status.ApplicationCredential.CurrentRoles = nil
+		Expect(k8sClient.Status().Patch(ctx, upb, client.MergeFrom(old))).To(Succeed())
+
+		old = upb.DeepCopy()
+		upb.SetAnnotations(map[string]string{pcov1beta1.ReconcileAtAnnotation: time.Now().Format(time.RFC3339Nano)})
+		Expect(k8sClient.Patch(ctx, upb, client.MergeFrom(old))).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(upb), upb)).To(Succeed())
+			g.Expect(upb.Status.ApplicationCredential.PendingID).To(BeEmpty())
+			g.Expect(upb.Status.ApplicationCredential.CurrentID).To(Equal(issued))
+		}, timeout, interval).Should(Succeed())
+		Expect(cloud.ApplicationCredentials(openStackUser.ID)).To(HaveLen(1))
+
+		By("reporting roles unavailable in the region")
+		upb.Spec.Roles = []string{"admin"}
+		Expect(k8sClient.Update(ctx, upb)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: upb.Name}, upb)).To(Succeed())
+			g.Expect(upb.Status.ObservedGeneration).To(Equal(upb.Generation))
+			g.Expect(upb.IsReady()).To(BeFalse())
+		}, timeout, interval).Should(Succeed())
+		Expect(cloud.AssignedRoles(openStackProject.ID, openStackUser.ID)).To(Equal([]string{"member", "reader"}))
+
+		By("removing the membership and the OpenStack user on deletion")
+		expectGone(ctx, upb)
+
+		_, ok = cloud.UserByName(user.MailFor(testControllerId))
+		Expect(ok).To(BeFalse())
+		openStackProject, _ = cloud.ProjectBySuffix(openStackProject.Name)
+		Expect(openStackProject.Members).To(BeEmpty())
+		Expect(k8errors.IsNotFound(k8sClient.Get(ctx, upb.ApplicationCredentialSecretName(), credentialSecret))).To(BeTrue())
+	})
+
+	It("recreates the immutable application credential secret of an older operator version", func() {
+		upb := &pcov1beta1.UserProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "legacy"},
+			Spec: pcov1beta1.UserProjectBindingSpec{
+				Project:               project.Name,
+				User:                  user.Name,
+				ApplicationCredential: &pcov1beta1.ApplicationCredentialSpec{},
+			},
+		}
+
+		isTrue := true
+		legacy := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: upb.ApplicationCredentialSecretName().Name},
+			Immutable:  &isTrue,
+			StringData: map[string]string{
+				applicationCredentialIdKey:     "revoked",
+				applicationCredentialSecretKey: "legacy",
+			},
+		}
+		Expect(k8sClient.Create(ctx, legacy)).To(Succeed())
+		Expect(k8sClient.Create(ctx, upb)).To(Succeed())
+
+		expectReady(ctx, upb)
+
+		credentialSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, upb.ApplicationCredentialSecretName(), credentialSecret)).To(Succeed())
+		Expect(credentialSecret.UID).NotTo(Equal(legacy.UID))
+		Expect(credentialSecret.Immutable).To(BeNil())
+		Expect(credentialSecret.Labels).To(HaveKeyWithValue(userProjectBindingLabel, upb.Name))
+		Expect(string(credentialSecret.Data[applicationCredentialIdKey])).To(Equal(upb.Status.ApplicationCredential.CurrentID))
+
+		expectGone(ctx, upb)
+	})
+
+	It("keeps the OpenStack user while other bindings in the region need it", func() {
+		second := &pcov1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "second"},
+			Spec:       pcov1beta1.ProjectSpec{Region: region.Name},
+		}
+		Expect(k8sClient.Create(ctx, second)).To(Succeed())
+		expectReady(ctx, second)
+
+		bindings := []*pcov1beta1.UserProjectBinding{}
+		for _, k := range []string{project.Name, second.Name} {
+			upb := &pcov1beta1.UserProjectBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "shared-" + k},
+				Spec:       pcov1beta1.UserProjectBindingSpec{Project: k, User: user.Name},
+			}
+			Expect(k8sClient.Create(ctx, upb)).To(Succeed())
+			bindings = append(bindings, upb)
+		}
+
+		for _, k := range bindings {
+			expectReady(ctx, k)
+		}
+
+		var openStackUser fakeopenstack.User
+		Eventually(func() bool {
+			var ok bool
+			openStackUser, ok = cloud.UserByName(user.MailFor(testControllerId))
+			return ok
+		}, timeout, interval).Should(BeTrue())
+
+		expectGone(ctx, bindings[0])
+		_, ok := cloud.UserByName(user.MailFor(testControllerId))
+		Expect(ok).To(BeTrue())
+
+		openStackProject, _ := cloud.ProjectBySuffix(utils.GetOpenStackProjectName(testControllerId, types.NamespacedName{Namespace: namespace, Name: project.Name}))
+		Expect(openStackProject.Members).NotTo(ContainElement(openStackUser.ID))
+
+		expectGone(ctx, bindings[1])
+		_, ok = cloud.UserByName(user.MailFor(testControllerId))
+		Expect(ok).To(BeFalse())
+
+		expectGone(ctx, second)
+	})
+})
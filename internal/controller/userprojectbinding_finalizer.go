@@ -5,8 +5,9 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	"github.com/pluscontainer/pco-reseller-operator/internal/utils"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -14,8 +15,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger logr.Logger, upb v1alpha1.UserProjectBinding) error {
-	user := &v1alpha1.User{}
+func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger logr.Logger, upb v1beta1.UserProjectBinding) error {
+	user := &v1beta1.User{}
 	if err := r.Get(ctx, types.NamespacedName{
 		Namespace: upb.Namespace,
 		Name:      upb.Spec.User,
@@ -28,7 +29,7 @@ func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger l
 		return err
 	}
 
-	project := &v1alpha1.Project{}
+	project := &v1beta1.Project{}
 	if err := r.Get(ctx, types.NamespacedName{
 		Namespace: upb.Namespace,
 		Name:      upb.Spec.Project,
@@ -40,13 +41,16 @@ func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger l
 		return err
 	}
 
-	region := &v1alpha1.Region{}
+	region := &v1beta1.Region{}
 	if err := r.Get(ctx, types.NamespacedName{Name: project.Spec.Region}, region); err != nil {
 		return err
 	}
 
-	psOsClient, err := psos.Login(region.Spec.Endpoint, region.Spec.Username, region.Spec.Password)
+	startPlan(ctx, r.PlanMode, *region)
+
+	psOsClient, err := regionResellerClient(ctx, r.Client, r.NewResellerClient, *region)
 	if err != nil {
+		r.Recorder.Eventf(&upb, v1.EventTypeWarning, eventReasonLoginFailed, "Login to reseller API of region %s failed: %s", region.Name, err)
 		return err
 	}
 
@@ -85,6 +89,8 @@ func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger l
 		return err
 	}
 
+	tracing.SetAttributes(ctx, tracing.RegionKey.String(region.Name), tracing.OpenStackProjectIDKey.String(openStackProject.Id), tracing.OpenStackUserIDKey.String(openStackUser.Id))
+
 	//Try to delete application credential
 	userAccessSecret := &v1.Secret{}
 	if err := r.Get(ctx, user.UserAccessSecretName(), userAccessSecret); err != nil {
@@ -94,25 +100,32 @@ func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger l
 
 		logger.Info("Can't delete application credential as user access credentials are gone")
 	} else {
-		if err := r.deprovisionApplicationCredential(ctx, logger, &upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey])); err != nil {
+		if err := tracing.Phase(ctx, "UserProjectBinding.DeprovisionApplicationCredential", func(ctx context.Context) error {
+			return r.deprovisionApplicationCredential(ctx, logger, &upb, *region, *openStackProject, openStackUser.Id, string(userAccessSecret.Data[secretUsernameKey]), string(userAccessSecret.Data[secretPasswordKey]))
+		}); err != nil {
 			return err
 		}
 	}
 
 	if err := psOsClient.RemoveUserFromProject(ctx, openStackProject.Id, openStackUser.Id); err != nil {
+		r.Recorder.Eventf(&upb, v1.EventTypeWarning, eventReasonMemberRemoveFailed, "Removing user %s from project %s failed: %s", openStackUser.Id, openStackProject.Id, err)
 		return err
 	}
 
+	if !plan.Active(ctx) {
+		r.Recorder.Eventf(&upb, v1.EventTypeNormal, eventReasonMemberRemoved, "Removed user %s from project %s", openStackUser.Id, openStackProject.Id)
+	}
+
 	//Check if user is still needed in region
-	userProjectBindings := &v1alpha1.UserProjectBindingList{}
+	userProjectBindings := &v1beta1.UserProjectBindingList{}
 	if err := r.List(ctx, userProjectBindings, &client.ListOptions{Namespace: upb.Namespace}); err != nil {
 		return err
 	}
 
-	userProjects := make([]v1alpha1.Project, 0)
+	userProjects := make([]v1beta1.Project, 0)
 	for _, k := range userProjectBindings.Items {
 		if k.Spec.User == upb.Spec.User {
-			userProject := &v1alpha1.Project{}
+			userProject := &v1beta1.Project{}
 
 			if err := r.Get(ctx, types.NamespacedName{
 				Namespace: k.Namespace,
@@ -136,8 +149,14 @@ func (r *UserProjectBindingReconciler) finalizeUPB(ctx context.Context, logger l
 		logger.Info("User has no projects in region left. Gonna delete user in region.")
 
 		if err := psOsClient.DeleteUser(ctx, openStackUser.Id); err != nil {
+			r.Recorder.Eventf(&upb, v1.EventTypeWarning, eventReasonUserDeleteFailed, "Deleting OpenStack user %s failed: %s", openStackUser.Id, err)
 			return err
 		}
+
+		if !plan.Active(ctx) {
+			r.Recorder.Eventf(&upb, v1.EventTypeNormal, eventReasonUserDeleted, "OpenStack user %s deleted as it has no projects in region %s left", openStackUser.Id, region.Name)
+			r.Recorder.Eventf(user, v1.EventTypeNormal, eventReasonUserDeleted, "OpenStack user %s deleted in region %s", openStackUser.Id, region.Name)
+		}
 	} else {
 		logger.Info("User has projects in region left. Gonna skip deletion.")
 	}
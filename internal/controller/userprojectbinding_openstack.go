@@ -3,156 +3,745 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
 	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
 	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
-	"github.com/pluscontainer/pco-reseller-operator/api/v1alpha1"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/metrics"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const applicationCredentialIdKey = "application-credential-id"
 const applicationCredentialSecretKey = "application-credential-secret"
 
-func (r *UserProjectBindingReconciler) ensureApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1alpha1.UserProjectBinding, region v1alpha1.Region, project openapi.ProjectCreatedResponse, userId string, username string, password string) error {
-	svc, err := openStackIdentityClient(region, project, username, password)
+// userProjectBindingLabel references the binding which issued the labeled application credential secret
+const userProjectBindingLabel = "pco.plusserver.com/userprojectbinding"
+
+// userProjectBindingNamespaceLabel references the namespace of the binding, as secrets may be delivered into other namespaces
+const userProjectBindingNamespaceLabel = "pco.plusserver.com/userprojectbinding-namespace"
+
+// ensureApplicationCredential issues the application credential of the binding and rotates it ahead of its expiry.
+// It returns the duration after which the binding needs to be reconciled again to rotate or revoke credentials
+func (r *UserProjectBindingReconciler) ensureApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, project openapi.ProjectCreatedResponse, userId string, username string, password string) (time.Duration, error) {
+	if p := plan.FromContext(ctx); p != nil {
+		return r.planApplicationCredential(ctx, p, upb, region, project, userId, username, password)
+	}
+
+	svc, err := r.openStackIdentityClient(ctx, region, project, username, password)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	appCredName := getApplicationCredentialName(project.Name, username)
+	spec := *upb.Spec.ApplicationCredential
+	now := time.Now()
+
+	status := &v1beta1.ApplicationCredentialStatus{}
+	if upb.Status.ApplicationCredential != nil {
+		status = upb.Status.ApplicationCredential.DeepCopy()
+	}
+
+	if status.PendingID != "" {
+		if err := r.resolvePendingApplicationCredential(ctx, logger, upb, region, svc, userId, appCredName, status, spec, now); err != nil {
+			return 0, err
+		}
+	}
+
+	//Revoke the replaced application credential once its grace period is over
+	if status.PreviousID != "" && (status.PreviousExpiresAt == nil || !now.Before(status.PreviousExpiresAt.Time)) {
+		if err := deleteApplicationCredential(ctx, region.Name, svc, userId, status.PreviousID); err != nil {
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Revoking replaced application credential %s failed: %s", status.PreviousID, err)
+			return 0, err
+		}
 
-	pager := applicationcredentials.List(svc, userId, applicationcredentials.ListOpts{Name: appCredName})
-	if pager.Err != nil {
-		return pager.Err
+		logger.Info(fmt.Sprintf("Replaced application credential %s revoked", status.PreviousID))
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialDeleted, "Replaced application credential %s revoked", status.PreviousID)
+
+		status.PreviousID = ""
+		status.PreviousExpiresAt = nil
+		if err := upb.UpdateApplicationCredentialStatus(ctx, r.Client, status); err != nil {
+			return 0, err
+		}
 	}
-	pages, err := pager.AllPages()
+
+	current, err := currentApplicationCredential(svc, userId, appCredName, status)
+	if err != nil {
+		return 0, err
+	}
+
+	if current != nil {
+		secretMatches, err := r.applicationCredentialSecretMatches(ctx, upb, current.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		//The secret of an application credential can't be retrieved again, so the orphaned credential gets replaced
+		if !secretMatches {
+			if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, v1beta1.UserProjectBindingHasNoSecret, fmt.Sprintf("Secret %s missing or not matching application credential %s, issuing a new one", upb.ApplicationCredentialSecretName().Name, current.ID)); err != nil {
+				return 0, err
+			}
+
+			if err := deleteApplicationCredential(ctx, region.Name, svc, userId, current.ID); err != nil {
+				r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Revoking orphaned application credential %s failed: %s", current.ID, err)
+				return 0, err
+			}
+
+			logger.Info(fmt.Sprintf("Orphaned application credential %s revoked", current.ID))
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleted, "Application credential %s revoked as secret %s got lost", current.ID, upb.ApplicationCredentialSecretName())
+
+			current = nil
+			status.CurrentID = ""
+			status.CurrentExpiresAt = nil
+			status.CurrentRoles = nil
+		}
+	}
+
+	if current != nil && status.CurrentID == "" {
+		status.CurrentID = current.ID
+		if !current.ExpiresAt.IsZero() {
+			status.CurrentExpiresAt = &metav1.Time{Time: current.ExpiresAt}
+		}
+
+		if err := upb.UpdateApplicationCredentialStatus(ctx, r.Client, status); err != nil {
+			return 0, err
+		}
+	}
+
+	if current != nil && !applicationCredentialNeedsRotation(spec, status, current, now) {
+		tracing.SetAttributes(ctx, tracing.ApplicationCredentialIDKey.String(current.ID))
+		logger.Info(fmt.Sprintf("Application credential %s already exists", current.Name))
+		return nextApplicationCredentialEvent(spec, status, now), nil
+	}
+
+	//Keystone requires unique names per user, so every issued credential gets a timestamp suffix
+	createOpts := applicationcredentials.CreateOpts{
+		Name:         fmt.Sprintf("%s-%d", appCredName, now.Unix()),
+		Description:  "Generated by PCO Reseller Operator",
+		Unrestricted: spec.Unrestricted,
+	}
+
+	for _, k := range spec.Roles {
+		createOpts.Roles = append(createOpts.Roles, applicationcredentials.Role{Name: k})
+	}
+
+	for _, k := range spec.AccessRules {
+		createOpts.AccessRules = append(createOpts.AccessRules, applicationcredentials.AccessRule{
+			Service: k.Service,
+			Method:  k.Method,
+			Path:    k.Path,
+		})
+	}
+
+	if spec.ExpiresAfter != nil {
+		expiresAt := now.Add(spec.ExpiresAfter.Duration).UTC()
+		createOpts.ExpiresAt = &expiresAt
+	}
+
+	result := applicationcredentials.Create(svc, userId, createOpts)
+	if result.Err != nil {
+		audit.Log(ctx, region.Name, "CreateApplicationCredential", createOpts.Name, nil, nil, result.Err)
+		r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialCreateFailed, "Creating application credential %s failed: %s", createOpts.Name, result.Err)
+		return 0, result.Err
+	}
+
+	ac, err := result.Extract()
+	if err != nil {
+		return 0, err
+	}
+
+	//The secret of the application credential is never part of the audit record
+	audit.Log(ctx, region.Name, "CreateApplicationCredential", createOpts.Name, nil, newApplicationCredentialAudit(userId, ac), nil)
+
+	//The credential is tracked before its secret gets written, so it can't leak if the reconciliation gets interrupted
+	status.PendingID = ac.ID
+	if err := upb.UpdateApplicationCredentialStatus(ctx, r.Client, status); err != nil {
+		if revokeErr := deleteApplicationCredential(ctx, region.Name, svc, userId, ac.ID); revokeErr != nil {
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Revoking untracked application credential %s failed: %s", ac.ID, revokeErr)
+		}
+
+		return 0, err
+	}
+
+	if err := r.writeApplicationCredentialSecret(ctx, upb, ac); err != nil {
+		r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonSecretWriteFailed, "Writing application credential %s failed: %s", ac.ID, err)
+
+		//A failed revocation is retried by the next reconciliation, as the credential is still pending
+		if revokeErr := r.revokePendingApplicationCredential(ctx, logger, upb, region, svc, userId, status); revokeErr != nil {
+			logger.Error(revokeErr, fmt.Sprintf("Failed to revoke application credential %s", ac.ID))
+		}
+
+		return 0, err
+	}
+
+	if err := r.recordApplicationCredential(ctx, logger, upb, region, svc, userId, status, spec, current, ac, now); err != nil {
+		return 0, err
+	}
+
+	logger.Info(fmt.Sprintf("Application credential %s created", createOpts.Name))
+	return nextApplicationCredentialEvent(spec, status, now), nil
+}
+
+// recordApplicationCredential records the issued application credential, whose secret got written, as the current one of the binding.
+// The replaced credential stays valid during the grace period
+func (r *UserProjectBindingReconciler) recordApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, svc *gophercloud.ServiceClient, userId string, status *v1beta1.ApplicationCredentialStatus, spec v1beta1.ApplicationCredentialSpec, current *applicationcredentials.ApplicationCredential, ac *applicationcredentials.ApplicationCredential, now time.Time) error {
+	if current != nil {
+		//A rotation faster than the grace period leaves no room for a third credential
+		if status.PreviousID != "" {
+			if err := deleteApplicationCredential(ctx, region.Name, svc, userId, status.PreviousID); err != nil {
+				r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Revoking replaced application credential %s failed: %s", status.PreviousID, err)
+				return err
+			}
+
+			r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialDeleted, "Replaced application credential %s revoked ahead of its grace period", status.PreviousID)
+		}
+
+		status.PreviousID = current.ID
+		status.PreviousExpiresAt = &metav1.Time{Time: now.Add(spec.GracePeriodDuration())}
+
+		logger.Info(fmt.Sprintf("Application credential %s replaced by %s, stays valid until %s", current.ID, ac.ID, status.PreviousExpiresAt.Format(time.RFC3339)))
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialRotated, "Application credential %s replaced by %s, stays valid until %s", current.ID, ac.ID, status.PreviousExpiresAt.Format(time.RFC3339))
+	} else {
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialCreated, "Application credential %s created", ac.ID)
+	}
+
+	tracing.SetAttributes(ctx, tracing.ApplicationCredentialIDKey.String(ac.ID))
+
+	status.CurrentID = ac.ID
+	status.CurrentRoles = spec.Roles
+	status.CurrentExpiresAt = nil
+	if !ac.ExpiresAt.IsZero() {
+		status.CurrentExpiresAt = &metav1.Time{Time: ac.ExpiresAt}
+	}
+	status.PendingID = ""
+
+	return upb.UpdateApplicationCredentialStatus(ctx, r.Client, status)
+}
+
+// resolvePendingApplicationCredential completes the issuance of an application credential which got interrupted.
+// The credential is adopted if its secret got written and revoked otherwise
+func (r *UserProjectBindingReconciler) resolvePendingApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, svc *gophercloud.ServiceClient, userId string, appCredName string, status *v1beta1.ApplicationCredentialStatus, spec v1beta1.ApplicationCredentialSpec, now time.Time) error {
+	secretMatches, err := r.applicationCredentialSecretMatches(ctx, upb, status.PendingID)
 	if err != nil {
 		return err
 	}
-	isEmpty, err := pages.IsEmpty()
+
+	if !secretMatches {
+		return r.revokePendingApplicationCredential(ctx, logger, upb, region, svc, userId, status)
+	}
+
+	ac, err := applicationcredentials.Get(svc, userId, status.PendingID).Extract()
+	if err != nil {
+		if !isOpenStackNotFound(err) {
+			return err
+		}
+
+		//The secret references a credential which is gone, so it gets replaced like any other orphaned secret
+		status.PendingID = ""
+		return upb.UpdateApplicationCredentialStatus(ctx, r.Client, status)
+	}
+
+	current, err := currentApplicationCredential(svc, userId, appCredName, status)
 	if err != nil {
 		return err
 	}
 
-	//Create application credential if it doesn't exist
-	if isEmpty {
-		result := applicationcredentials.Create(svc, userId, applicationcredentials.CreateOpts{
-			Name:        appCredName,
-			Description: "Generated by PCO Reseller Operator",
-		})
+	logger.Info(fmt.Sprintf("Adopting application credential %s as its secret was written", ac.ID))
+	return r.recordApplicationCredential(ctx, logger, upb, region, svc, userId, status, spec, current, ac, now)
+}
 
-		if result.Err != nil {
-			return result.Err
+// revokePendingApplicationCredential revokes the pending application credential of the binding, whose secret wasn't written
+func (r *UserProjectBindingReconciler) revokePendingApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, svc *gophercloud.ServiceClient, userId string, status *v1beta1.ApplicationCredentialStatus) error {
+	if err := deleteApplicationCredential(ctx, region.Name, svc, userId, status.PendingID); err != nil {
+		r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Revoking application credential %s without secret failed: %s", status.PendingID, err)
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Application credential %s revoked as its secret wasn't written", status.PendingID))
+	r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialDeleted, "Application credential %s revoked as its secret wasn't written", status.PendingID)
+
+	status.PendingID = ""
+	return upb.UpdateApplicationCredentialStatus(ctx, r.Client, status)
+}
+
+// planApplicationCredential plans the revocations and the issuance ensureApplicationCredential would execute.
+// Neither the secret nor the status of the binding are changed
+func (r *UserProjectBindingReconciler) planApplicationCredential(ctx context.Context, p *plan.Plan, upb *v1beta1.UserProjectBinding, region v1beta1.Region, project openapi.ProjectCreatedResponse, userId string, username string, password string) (time.Duration, error) {
+	appCredName := getApplicationCredentialName(project.Name, username)
+
+	//A user whose creation was planned can't be authenticated as
+	if plan.IsPlaceholder(userId) {
+		p.Record("Issue application credential %s for user %s in project %s", appCredName, userId, project.Id)
+		return 0, nil
+	}
+
+	svc, err := r.openStackIdentityClient(ctx, region, project, username, password)
+	if err != nil {
+		return 0, err
+	}
+
+	spec := *upb.Spec.ApplicationCredential
+	now := time.Now()
+
+	status := &v1beta1.ApplicationCredentialStatus{}
+	if upb.Status.ApplicationCredential != nil {
+		status = upb.Status.ApplicationCredential.DeepCopy()
+	}
+
+	if status.PendingID != "" {
+		secretMatches, err := r.applicationCredentialSecretMatches(ctx, upb, status.PendingID)
+		if err != nil {
+			return 0, err
+		}
+
+		//Adopting a pending credential only changes the status of the binding
+		if !secretMatches {
+			p.Record("Revoke application credential %s of user %s", status.PendingID, userId)
 		}
+	}
+
+	previousDue := status.PreviousID != "" && (status.PreviousExpiresAt == nil || !now.Before(status.PreviousExpiresAt.Time))
+	if previousDue {
+		p.Record("Revoke application credential %s of user %s", status.PreviousID, userId)
+	}
+
+	current, err := currentApplicationCredential(svc, userId, appCredName, status)
+	if err != nil {
+		return 0, err
+	}
+
+	if current != nil {
+		secretMatches, err := r.applicationCredentialSecretMatches(ctx, upb, current.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		if !secretMatches {
+			p.Record("Revoke application credential %s of user %s", current.ID, userId)
+			current = nil
+		}
+	}
 
-		ac, err := result.Extract()
+	if current != nil && !applicationCredentialNeedsRotation(spec, status, current, now) {
+		return nextApplicationCredentialEvent(spec, status, now), nil
+	}
+
+	p.Record("Issue application credential %s for user %s in project %s", appCredName, userId, project.Id)
+
+	//A rotation faster than the grace period revokes the replaced credential right away
+	if current != nil && status.PreviousID != "" && !previousDue {
+		p.Record("Revoke application credential %s of user %s", status.PreviousID, userId)
+	}
+
+	return 0, nil
+}
+
+// currentApplicationCredential returns the application credential tracked by the binding or nil if it doesn't exist anymore.
+// Bindings created before credentials were tracked in the status get their unsuffixed credential adopted by name.
+// The credential isn't guaranteed to match the secret of the binding
+func currentApplicationCredential(svc *gophercloud.ServiceClient, userId string, appCredName string, status *v1beta1.ApplicationCredentialStatus) (*applicationcredentials.ApplicationCredential, error) {
+	if status.CurrentID != "" {
+		ac, err := applicationcredentials.Get(svc, userId, status.CurrentID).Extract()
 		if err != nil {
+			if isOpenStackNotFound(err) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		return ac, nil
+	}
+
+	pages, err := applicationcredentials.List(svc, userId, applicationcredentials.ListOpts{Name: appCredName}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := applicationcredentials.ExtractApplicationCredentials(pages)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	//Names are unique per user
+	return &existing[0], nil
+}
+
+// applicationCredentialSecretManaged checks that the secret of the binding is either absent or was generated for it
+func (r *UserProjectBindingReconciler) applicationCredentialSecretManaged(ctx context.Context, upb *v1beta1.UserProjectBinding) (bool, error) {
+	if upb.Spec.SecretTemplate != nil && upb.Spec.SecretTemplate.ExternalOnly {
+		return true, nil
+	}
+
+	accessSecret := &v1.Secret{}
+	if err := r.Get(ctx, upb.ApplicationCredentialSecretName(), accessSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return secretManagedFor(accessSecret, upb, userProjectBindingLabel, userProjectBindingNamespaceLabel), nil
+}
+
+// applicationCredentialSecretMatches checks whether the secret of the binding holds the given application credential
+func (r *UserProjectBindingReconciler) applicationCredentialSecretMatches(ctx context.Context, upb *v1beta1.UserProjectBinding, applicationCredentialId string) (bool, error) {
+	//Credentials delivered to external sinks only can't be verified
+	if upb.Spec.SecretTemplate != nil && upb.Spec.SecretTemplate.ExternalOnly {
+		return true, nil
+	}
+
+	accessSecret := &v1.Secret{}
+	if err := r.Get(ctx, upb.ApplicationCredentialSecretName(), accessSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	return string(accessSecret.Data[applicationCredentialIdKey]) == applicationCredentialId && len(accessSecret.Data[applicationCredentialSecretKey]) > 0, nil
+}
+
+func (r *UserProjectBindingReconciler) writeApplicationCredentialSecret(ctx context.Context, upb *v1beta1.UserProjectBinding, ac *applicationcredentials.ApplicationCredential) error {
+	data := map[string]string{
+		applicationCredentialIdKey:     ac.ID,
+		applicationCredentialSecretKey: ac.Secret,
+	}
+
+	if upb.Spec.SecretTemplate == nil || !upb.Spec.SecretTemplate.ExternalOnly {
+		if err := r.writeApplicationCredentialKubernetesSecret(ctx, upb, data); err != nil {
 			return err
 		}
+	}
 
-		isTrue := true
+	return writeToSinks(ctx, r.Client, upb.Spec.SecretTemplate, upb.Namespace, data)
+}
 
-		accessSecret := &v1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: upb.Namespace,
-				Name:      upb.ApplicationCredentialName(),
-			},
-			Immutable: &isTrue,
-			StringData: map[string]string{
-				applicationCredentialIdKey:     ac.ID,
-				applicationCredentialSecretKey: ac.Secret,
-			},
+func (r *UserProjectBindingReconciler) writeApplicationCredentialKubernetesSecret(ctx context.Context, upb *v1beta1.UserProjectBinding, data map[string]string) error {
+	accessSecretName := upb.ApplicationCredentialSecretName()
+
+	accessSecret := &v1.Secret{}
+	if err := r.Get(ctx, accessSecretName, accessSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
 		}
 
-		if err := r.createOrPatch(ctx, accessSecret); err != nil {
+		accessSecret = nil
+	} else if !secretManagedFor(accessSecret, upb, userProjectBindingLabel, userProjectBindingNamespaceLabel) {
+		return errSecretNotManaged
+	} else if accessSecret.Immutable != nil && *accessSecret.Immutable {
+		//Adopted secrets of older operator versions are immutable and have to be recreated
+		if err := r.Delete(ctx, accessSecret); err != nil && !errors.IsNotFound(err) {
 			return err
 		}
 
-		logger.Info(fmt.Sprintf("Application credential %s created", appCredName))
-	} else {
-		logger.Info(fmt.Sprintf("Application credential %s already exists", appCredName))
+		accessSecret = nil
 	}
 
-	return nil
+	if accessSecret == nil {
+		accessSecret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: accessSecretName.Namespace,
+				Name:      accessSecretName.Name,
+			},
+		}
+	}
+
+	oldAccessSecret := accessSecret.DeepCopy()
+
+	applySecretTemplate(accessSecret, upb.Spec.SecretTemplate)
+	if err := ownSecret(accessSecret, upb, r.Scheme, userProjectBindingLabel, userProjectBindingNamespaceLabel); err != nil {
+		return err
+	}
+	accessSecret.Data = nil
+	accessSecret.StringData = data
+
+	if accessSecret.ResourceVersion == "" {
+		return r.Create(ctx, accessSecret)
+	}
+
+	return r.Patch(ctx, accessSecret, client.MergeFrom(oldAccessSecret))
+}
+
+func applicationCredentialNeedsRotation(spec v1beta1.ApplicationCredentialSpec, status *v1beta1.ApplicationCredentialStatus, ac *applicationcredentials.ApplicationCredential, now time.Time) bool {
+	//Application credentials can't be modified, so changed restrictions require a new credential
+	if !applicationCredentialMatchesRestrictions(spec, status, ac) {
+		return true
+	}
+
+	if spec.ExpiresAfter == nil {
+		return false
+	}
+
+	//Credentials issued without expiry don't satisfy the requested lifetime
+	if ac.ExpiresAt.IsZero() {
+		return true
+	}
+
+	return !now.Before(ac.ExpiresAt.Add(-spec.RotateBeforeDuration()))
 }
 
-func (r *UserProjectBindingReconciler) deprovisionApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1alpha1.UserProjectBinding, region v1alpha1.Region, project openapi.ProjectCreatedResponse, userId string, username string, password string) error {
-	accessSecretName := types.NamespacedName{
-		Name:      upb.ApplicationCredentialName(),
-		Namespace: upb.Namespace,
+func applicationCredentialMatchesRestrictions(spec v1beta1.ApplicationCredentialSpec, status *v1beta1.ApplicationCredentialStatus, ac *applicationcredentials.ApplicationCredential) bool {
+	if spec.Unrestricted != ac.Unrestricted {
+		return false
 	}
+
+	//Keystone lists the inherited roles of credentials issued without roles, so compare with the roles requested at issuance
+	if !sets.New(spec.Roles...).Equal(sets.New(status.CurrentRoles...)) {
+		return false
+	}
+
+	wantedRules := sets.New[v1beta1.ApplicationCredentialAccessRule](spec.AccessRules...)
+	currentRules := sets.New[v1beta1.ApplicationCredentialAccessRule]()
+	for _, k := range ac.AccessRules {
+		currentRules.Insert(v1beta1.ApplicationCredentialAccessRule{
+			Service: k.Service,
+			Method:  k.Method,
+			Path:    k.Path,
+		})
+	}
+
+	return wantedRules.Equal(currentRules)
+}
+
+// nextApplicationCredentialEvent returns the duration until the next rotation or revocation, 0 if there is none
+func nextApplicationCredentialEvent(spec v1beta1.ApplicationCredentialSpec, status *v1beta1.ApplicationCredentialStatus, now time.Time) time.Duration {
+	var next time.Duration
+
+	if spec.ExpiresAfter != nil && status.CurrentExpiresAt != nil {
+		next = status.CurrentExpiresAt.Add(-spec.RotateBeforeDuration()).Sub(now)
+	}
+
+	if status.PreviousID != "" && status.PreviousExpiresAt != nil {
+		untilRevocation := status.PreviousExpiresAt.Sub(now)
+		if next <= 0 || untilRevocation < next {
+			next = untilRevocation
+		}
+	}
+
+	if next < 0 {
+		return time.Second
+	}
+
+	return next
+}
+
+func (r *UserProjectBindingReconciler) deprovisionApplicationCredential(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, project openapi.ProjectCreatedResponse, userId string, username string, password string) error {
+	accessSecretName := upb.ApplicationCredentialSecretName()
 	accessSecret := &v1.Secret{}
 
+	secretFound := true
 	if err := r.Get(ctx, accessSecretName, accessSecret); err != nil {
 		if !errors.IsNotFound(err) {
 			return err
 		}
 
+		secretFound = false
+	} else if !secretManagedFor(accessSecret, upb, userProjectBindingLabel, userProjectBindingNamespaceLabel) {
+		//Foreign secrets neither reference a credential of the binding nor get deleted
+		logger.Info(fmt.Sprintf("Secret %s isn't managed by the operator for this binding, keeping it", accessSecretName))
+		secretFound = false
+	}
+
+	applicationCredentialIds := make([]string, 0)
+	if secretFound {
+		applicationCredentialIds = append(applicationCredentialIds, string(accessSecret.Data[applicationCredentialIdKey]))
+	}
+	if status := upb.Status.ApplicationCredential; status != nil {
+		applicationCredentialIds = append(applicationCredentialIds, status.CurrentID, status.PreviousID, status.PendingID)
+	}
+
+	if !secretFound && upb.Status.ApplicationCredential == nil {
 		logger.Info("Application Credential already gone")
 		return nil
 	}
 
-	svc, err := openStackIdentityClient(region, project, username, password)
+	//The secret is kept as long as the planned revocations weren't executed
+	if p := plan.FromContext(ctx); p != nil {
+		for _, k := range applicationCredentialIds {
+			if k != "" {
+				p.Record("Revoke application credential %s of user %s", k, userId)
+			}
+		}
+
+		return nil
+	}
+
+	svc, err := r.openStackIdentityClient(ctx, region, project, username, password)
 	if err != nil {
 		logger.Error(err, "Failed to get OpenStack identity client, will not delete openstack application credential")
 	} else {
-		result := applicationcredentials.Delete(svc, userId, string(accessSecret.Data[applicationCredentialIdKey]))
-		if result.Err != nil {
-			if !strings.Contains(result.Err.Error(), "Resource not found") {
-				return result.Err
+		deleted := map[string]bool{}
+		for _, k := range applicationCredentialIds {
+			if k == "" || deleted[k] {
+				continue
 			}
+
+			if err := deleteApplicationCredential(ctx, region.Name, svc, userId, k); err != nil {
+				r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonApplicationCredentialDeleteFailed, "Deleting application credential %s failed: %s", k, err)
+				return err
+			}
+			deleted[k] = true
+
+			r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonApplicationCredentialDeleted, "Application credential %s deleted", k)
 		}
 
 		logger.Info("Application Credential deleted")
 	}
 
-	if err := r.Delete(ctx, accessSecret); err != nil {
-		if !errors.IsNotFound(err) {
+	if secretFound {
+		if err := r.Delete(ctx, accessSecret); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		logger.Info("Access Secret deleted")
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonSecretDeleted, "Secret %s deleted", accessSecretName)
+	}
+
+	if err := deleteFromSinks(ctx, r.Client, upb.Spec.SecretTemplate, upb.Namespace); err != nil {
+		return err
+	}
+
+	if upb.Status.ApplicationCredential != nil && upb.GetDeletionTimestamp() == nil {
+		if err := upb.UpdateApplicationCredentialStatus(ctx, r.Client, nil); err != nil {
 			return err
 		}
 	}
 
-	logger.Info("Access Secret deleted")
+	return nil
+}
+
+// deleteApplicationCredential deletes the given application credential, ignoring credentials which are already gone
+func deleteApplicationCredential(ctx context.Context, region string, svc *gophercloud.ServiceClient, userId string, id string) error {
+	result := applicationcredentials.Delete(svc, userId, id)
+	audit.Log(ctx, region, "DeleteApplicationCredential", id, applicationCredentialAudit{ID: id, User: userId}, nil, result.Err)
+	if result.Err != nil {
+		if !isOpenStackNotFound(result.Err) {
+			return result.Err
+		}
+	}
 
 	return nil
 }
 
-func openStackIdentityClient(region v1alpha1.Region, project openapi.ProjectCreatedResponse, username string, password string) (*gophercloud.ServiceClient, error) {
-	url, err := url.Parse(region.Spec.Endpoint)
+// applicationCredentialAudit is the audit record of an application credential
+type applicationCredentialAudit struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	User      string     `json:"user"`
+	Roles     []string   `json:"roles,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+func newApplicationCredentialAudit(userId string, ac *applicationcredentials.ApplicationCredential) applicationCredentialAudit {
+	record := applicationCredentialAudit{ID: ac.ID, Name: ac.Name, User: userId}
+	for _, k := range ac.Roles {
+		record.Roles = append(record.Roles, k.Name)
+	}
+
+	if !ac.ExpiresAt.IsZero() {
+		expiresAt := ac.ExpiresAt
+		record.ExpiresAt = &expiresAt
+	}
+
+	return record
+}
+
+func isOpenStackNotFound(err error) bool {
+	return apierror.ClassOf(err) == apierror.NotFound
+}
+
+// openStackIdentityClient authenticates against Keystone of the region as the user, scoped to the project
+func (r *UserProjectBindingReconciler) openStackIdentityClient(ctx context.Context, region v1beta1.Region, project openapi.ProjectCreatedResponse, username string, password string) (*gophercloud.ServiceClient, error) {
+	//Keystone is derived from the reseller API endpoint, which may be stored within the referenced secret
+	endpoint, _, _, err := regionCredentials(ctx, r.Client, region)
 	if err != nil {
 		return nil, err
 	}
 
-	keyStoneUrl := fmt.Sprintf("https://%s:5000", strings.Split(url.Host, ":")[0])
-	domainName := strings.Split(project.Name, "-")[0]
+	keyStoneUrl, err := r.identityEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
 
 	opts := gophercloud.AuthOptions{
 		IdentityEndpoint: keyStoneUrl,
 		Username:         username,
 		Password:         password,
 		TenantName:       project.Name,
-		DomainName:       domainName,
+		DomainName:       openStackDomainName(project),
+	}
+
+	return authenticatedIdentityClient(ctx, region, opts)
+}
+
+// identityEndpoint resolves the Keystone URL of the region with the resolver of the reconciler
+func (r *UserProjectBindingReconciler) identityEndpoint(resellerEndpoint string) (string, error) {
+	if r.KeystoneEndpoint == nil {
+		return OpenStackIdentityEndpoint(resellerEndpoint)
 	}
 
-	client, err := openstack.AuthenticatedClient(opts)
+	return r.KeystoneEndpoint(resellerEndpoint)
+}
+
+// authenticatedIdentityClient authenticates against Keystone and returns an identity client recording metrics and spans for all requests.
+// The spans become children of the span within ctx. All requests pass the guard of the region
+func authenticatedIdentityClient(ctx context.Context, region v1beta1.Region, opts gophercloud.AuthOptions) (*gophercloud.ServiceClient, error) {
+	client, err := openstack.NewClient(opts.IdentityEndpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	svc, err := openstack.NewIdentityV3(client, gophercloud.EndpointOpts{})
-	if err != nil {
+	client.Context = ctx
+	//Mutations which weren't planned explicitly never reach Keystone in plan mode
+	client.HTTPClient = http.Client{Transport: plan.NewTransport(throttle.NewTransport(regionGuard(region), metrics.NewTransport(metrics.APIKeystone, region.Name, tracing.NewTransport(metrics.APIKeystone, region.Name, nil))))}
+
+	if err := openstack.Authenticate(client, opts); err != nil {
 		return nil, err
 	}
 
-	return svc, nil
+	return openstack.NewIdentityV3(client, gophercloud.EndpointOpts{})
+}
+
+// KeystoneEndpointResolver derives the Keystone URL of a region from the endpoint of its reseller API
+type KeystoneEndpointResolver func(resellerEndpoint string) (string, error)
+
+// OpenStackIdentityEndpoint is the default KeystoneEndpointResolver. Keystone listens on port 5000 of the host of the reseller API
+func OpenStackIdentityEndpoint(resellerEndpoint string) (string, error) {
+	url, err := url.Parse(resellerEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://%s:5000", strings.Split(url.Host, ":")[0]), nil
+}
+
+// openStackDomainName returns the domain of the project, which gets prepended to the project name by the reseller API
+func openStackDomainName(project openapi.ProjectCreatedResponse) string {
+	return strings.Split(project.Name, "-")[0]
 }
 
 func getApplicationCredentialName(projectName string, username string) string {
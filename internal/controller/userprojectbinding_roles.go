@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/roles"
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var errUnknownRoles = errors.New("roles are not available in region")
+
+// roleAssignment is the audit record of an assigned or unassigned role
+type roleAssignment struct {
+	Role    string `json:"role"`
+	User    string `json:"user"`
+	Project string `json:"project"`
+}
+
+// ensureProjectRoles reconciles the role assignments of the user within the project to exactly match .spec.roles.
+// Role assignments aren't managed if no roles are specified
+func (r *UserProjectBindingReconciler) ensureProjectRoles(ctx context.Context, logger logr.Logger, upb *v1beta1.UserProjectBinding, region v1beta1.Region, project openapi.ProjectCreatedResponse, userId string) error {
+	if len(upb.Spec.Roles) == 0 {
+		return nil
+	}
+
+	endpoint, username, password, err := regionCredentials(ctx, r.Client, region)
+	if err != nil {
+		return err
+	}
+
+	svc, err := r.openStackDomainIdentityClient(ctx, region, endpoint, username, password, openStackDomainName(project))
+	if err != nil {
+		return err
+	}
+
+	rolePages, err := roles.List(svc, roles.ListOpts{}).AllPages()
+	if err != nil {
+		return err
+	}
+
+	availableRoles, err := roles.ExtractRoles(rolePages)
+	if err != nil {
+		return err
+	}
+
+	roleIds := make(map[string]string, len(availableRoles))
+	roleNames := make(map[string]string, len(availableRoles))
+	for _, k := range availableRoles {
+		roleIds[k.Name] = k.ID
+		roleNames[k.ID] = k.Name
+	}
+
+	wantedRoles := sets.New[string]()
+	unknownRoles := make([]string, 0)
+	for _, k := range upb.Spec.Roles {
+		roleId, ok := roleIds[k]
+		if !ok {
+			unknownRoles = append(unknownRoles, k)
+			continue
+		}
+
+		wantedRoles.Insert(roleId)
+	}
+
+	if len(unknownRoles) > 0 {
+		r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonUnknownRoles, "Roles %s are not available in region %s", strings.Join(unknownRoles, ", "), region.Name)
+
+		if err := upb.UpdateUserProjectBindingCondition(ctx, r.Client, v1beta1.UserProjectBindingInvalidRoles, fmt.Sprintf("Roles %s are not available in region %s", strings.Join(unknownRoles, ", "), region.Name)); err != nil {
+			return err
+		}
+
+		return errUnknownRoles
+	}
+
+	currentRoles := sets.New[string]()
+
+	//Users and projects whose creation was planned have no role assignments yet
+	if !plan.IsPlaceholder(userId) && !plan.IsPlaceholder(project.Id) {
+		assignmentPages, err := roles.ListAssignmentsOnResource(svc, roles.ListAssignmentsOnResourceOpts{
+			UserID:    userId,
+			ProjectID: project.Id,
+		}).AllPages()
+		if err != nil {
+			return err
+		}
+
+		assignedRoles, err := roles.ExtractRoles(assignmentPages)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range assignedRoles {
+			currentRoles.Insert(k.ID)
+		}
+	}
+
+	for _, k := range sets.List(wantedRoles.Difference(currentRoles)) {
+		if p := plan.FromContext(ctx); p != nil {
+			p.Record("Assign role %s to user %s in project %s", roleNames[k], userId, project.Id)
+			continue
+		}
+
+		err := roles.Assign(svc, k, roles.AssignOpts{UserID: userId, ProjectID: project.Id}).ExtractErr()
+		audit.Log(ctx, region.Name, "AssignRole", project.Id, nil, roleAssignment{Role: roleNames[k], User: userId, Project: project.Id}, err)
+		if err != nil {
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonRoleAssignmentFailed, "Assigning role %s to user %s in project %s failed: %s", roleNames[k], userId, project.Id, err)
+			return err
+		}
+
+		logger.Info(fmt.Sprintf("Assigned role %s to user %s in project %s", k, userId, project.Id))
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonRoleAssigned, "Assigned role %s to user %s in project %s", roleNames[k], userId, project.Id)
+	}
+
+	for _, k := range sets.List(currentRoles.Difference(wantedRoles)) {
+		if p := plan.FromContext(ctx); p != nil {
+			p.Record("Unassign role %s from user %s in project %s", roleNames[k], userId, project.Id)
+			continue
+		}
+
+		err := roles.Unassign(svc, k, roles.UnassignOpts{UserID: userId, ProjectID: project.Id}).ExtractErr()
+		audit.Log(ctx, region.Name, "UnassignRole", project.Id, roleAssignment{Role: roleNames[k], User: userId, Project: project.Id}, nil, err)
+		if err != nil {
+			r.Recorder.Eventf(upb, v1.EventTypeWarning, eventReasonRoleAssignmentFailed, "Unassigning role %s from user %s in project %s failed: %s", roleNames[k], userId, project.Id, err)
+			return err
+		}
+
+		logger.Info(fmt.Sprintf("Unassigned role %s from user %s in project %s", k, userId, project.Id))
+		r.Recorder.Eventf(upb, v1.EventTypeNormal, eventReasonRoleUnassigned, "Unassigned role %s from user %s in project %s", roleNames[k], userId, project.Id)
+	}
+
+	return nil
+}
+
+// openStackDomainIdentityClient authenticates against Keystone with the reseller credentials of the region, scoped to the domain of its projects
+func (r *UserProjectBindingReconciler) openStackDomainIdentityClient(ctx context.Context, region v1beta1.Region, endpoint string, username string, password string, domainName string) (*gophercloud.ServiceClient, error) {
+	keyStoneUrl, err := r.identityEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return authenticatedIdentityClient(ctx, region, gophercloud.AuthOptions{
+		IdentityEndpoint: keyStoneUrl,
+		Username:         username,
+		Password:         password,
+		DomainName:       domainName,
+		Scope: &gophercloud.AuthScope{
+			DomainName: domainName,
+		},
+	})
+}
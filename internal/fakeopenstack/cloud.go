@@ -0,0 +1,279 @@
+// Package fakeopenstack provides an in-process fake of the reseller API and of the parts of Keystone used by the operator.
+// Both serve plain HTTP on the loopback interface and share their state, so users created through the reseller API
+// can authenticate against Keystone. It is meant for tests only and keeps everything in memory
+package fakeopenstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDomain is the domain the fake reseller account owns, which gets prepended to the names of created projects
+const DefaultDomain = "fakereseller"
+
+// DefaultRoles are the roles available within Keystone unless others are passed to New
+var DefaultRoles = []string{"member", "reader", "load-balancer_member"}
+
+// Project is an OpenStack project created through the reseller API
+type Project struct {
+	ID          string
+	Name        string
+	Description string
+	Enabled     bool
+	// Quota is the quota document last written through the reseller API
+	Quota json.RawMessage
+	// Members are the ids of the users added to the project through the reseller API
+	Members []string
+}
+
+// User is an OpenStack user created through the reseller API
+type User struct {
+	ID             string
+	Name           string
+	Description    string
+	Enabled        bool
+	DefaultProject string
+	Password       string
+}
+
+// Role is a Keystone role
+type Role struct {
+	ID   string
+	Name string
+}
+
+// ApplicationCredential is a Keystone application credential
+type ApplicationCredential struct {
+	ID           string
+	Name         string
+	Description  string
+	Secret       string
+	ProjectID    string
+	Unrestricted bool
+	Roles        []string
+	AccessRules  []AccessRule
+	ExpiresAt    *time.Time
+}
+
+// AccessRule restricts the API calls of an application credential
+type AccessRule struct {
+	Service string
+	Method  string
+	Path    string
+}
+
+// token is an issued token of the reseller API or Keystone
+type token struct {
+	userID    string
+	projectID string
+}
+
+// Cloud is a fake reseller account together with the Keystone of its region.
+// Use ResellerURL as endpoint of a Region and KeystoneURL as its identity endpoint
+type Cloud struct {
+	// Domain gets prepended to the names of created projects
+	Domain string
+
+	username       string
+	password       string
+	resellerUserID string
+
+	mu                     sync.Mutex
+	nextID                 int
+	projects               map[string]*Project
+	users                  map[string]*User
+	roles                  []Role
+	roleAssignments        map[string]map[string]bool
+	applicationCredentials map[string]map[string]*ApplicationCredential
+	tokens                 map[string]token
+	requests               map[string]int
+
+	reseller *httptest.Server
+	keystone *httptest.Server
+}
+
+// New starts a fake reseller API accepting the given credentials together with its Keystone.
+// The roles default to DefaultRoles. The servers have to be stopped by Close
+func New(username string, password string, roles ...string) *Cloud {
+	if len(roles) == 0 {
+		roles = DefaultRoles
+	}
+
+	c := &Cloud{
+		Domain:                 DefaultDomain,
+		username:               username,
+		password:               password,
+		projects:               map[string]*Project{},
+		users:                  map[string]*User{},
+		roleAssignments:        map[string]map[string]bool{},
+		applicationCredentials: map[string]map[string]*ApplicationCredential{},
+		tokens:                 map[string]token{},
+		requests:               map[string]int{},
+	}
+
+	c.resellerUserID = c.newID()
+	for _, k := range roles {
+		c.roles = append(c.roles, Role{ID: c.newID(), Name: k})
+	}
+
+	c.reseller = httptest.NewServer(c.resellerHandler())
+	c.keystone = httptest.NewServer(c.keystoneHandler())
+
+	return c
+}
+
+// ResellerURL is the endpoint of the fake reseller API
+func (c *Cloud) ResellerURL() string {
+	return c.reseller.URL
+}
+
+// KeystoneURL is the unversioned endpoint of the fake Keystone
+func (c *Cloud) KeystoneURL() string {
+	return c.keystone.URL
+}
+
+// Close stops both servers
+func (c *Cloud) Close() {
+	c.reseller.Close()
+	c.keystone.Close()
+}
+
+// Projects returns a copy of all projects sorted by name
+func (c *Cloud) Projects() []Project {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	projects := make([]Project, 0, len(c.projects))
+	for _, k := range c.projects {
+		project := *k
+		project.Members = append([]string(nil), k.Members...)
+		projects = append(projects, project)
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects
+}
+
+// ProjectBySuffix returns a copy of the project whose name ends with suffix, as the domain gets prepended to project names
+func (c *Cloud) ProjectBySuffix(suffix string) (Project, bool) {
+	for _, k := range c.Projects() {
+		if strings.HasSuffix(k.Name, suffix) {
+			return k, true
+		}
+	}
+
+	return Project{}, false
+}
+
+// Users returns a copy of all users sorted by name
+func (c *Cloud) Users() []User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	users := make([]User, 0, len(c.users))
+	for _, k := range c.users {
+		users = append(users, *k)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users
+}
+
+// UserByName returns a copy of the user with the given name
+func (c *Cloud) UserByName(name string) (User, bool) {
+	for _, k := range c.Users() {
+		if k.Name == name {
+			return k, true
+		}
+	}
+
+	return User{}, false
+}
+
+// AssignedRoles returns the names of the roles assigned to the user within the project
+func (c *Cloud) AssignedRoles(projectID string, userID string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0)
+	for _, k := range c.roles {
+		if c.roleAssignments[assignmentKey(projectID, userID)][k.ID] {
+			names = append(names, k.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ApplicationCredentials returns a copy of the application credentials of the user sorted by name
+func (c *Cloud) ApplicationCredentials(userID string) []ApplicationCredential {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	credentials := make([]ApplicationCredential, 0)
+	for _, k := range c.applicationCredentials[userID] {
+		credentials = append(credentials, *k)
+	}
+
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Name < credentials[j].Name })
+	return credentials
+}
+
+// Requests returns how often the operation, e.g. "POST /v1/projects", was requested
+func (c *Cloud) Requests(operation string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requests[operation]
+}
+
+// newID returns a unique id formatted like the ids of Keystone. The caller must hold mu or own c exclusively
+func (c *Cloud) newID() string {
+	c.nextID++
+	return fmt.Sprintf("%032x", c.nextID)
+}
+
+// issueToken issues a token for the user. The caller must hold mu
+func (c *Cloud) issueToken(userID string, projectID string) string {
+	id := fmt.Sprintf("token-%s", c.newID())
+	c.tokens[id] = token{userID: userID, projectID: projectID}
+
+	return id
+}
+
+// authenticate returns the user id of the reseller account or the user authenticated by the credentials.
+// The caller must hold mu
+func (c *Cloud) authenticate(username string, password string) (string, bool) {
+	if username == c.username {
+		return c.resellerUserID, password == c.password
+	}
+
+	for _, k := range c.users {
+		if k.Name == username {
+			return k.ID, k.Enabled && k.Password == password
+		}
+	}
+
+	return "", false
+}
+
+func assignmentKey(projectID string, userID string) string {
+	return projectID + "/" + userID
+}
+
+// writeJSON writes the body with the given status code
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,246 @@
+package fakeopenstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/roles"
+)
+
+func TestResellerLogin(t *testing.T) {
+	cloud := New("reseller", "secret")
+	defer cloud.Close()
+
+	if status, _ := resellerRequest(t, cloud, "", http.MethodPost, "/v1/login", loginRequest{Username: "reseller", Password: "wrong"}); status != http.StatusUnauthorized {
+		t.Fatalf("expected login with wrong password to be rejected, got status %d", status)
+	}
+
+	if status, _ := resellerRequest(t, cloud, "invalid", http.MethodGet, "/v1/projects", nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected request with invalid token to be rejected, got status %d", status)
+	}
+
+	token := resellerLogin(t, cloud)
+	if status, _ := resellerRequest(t, cloud, token, http.MethodGet, "/v1/projects", nil); status != http.StatusOK {
+		t.Fatalf("expected projects to be listed, got status %d", status)
+	}
+
+	if requests := cloud.Requests(routeLogin); requests != 2 {
+		t.Errorf("expected 2 login requests, got %d", requests)
+	}
+}
+
+func TestResellerProjectsAndUsers(t *testing.T) {
+	cloud := New("reseller", "secret")
+	defer cloud.Close()
+
+	token := resellerLogin(t, cloud)
+	project := projectResponse{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodPost, "/v1/projects", projectCreateRequest{Name: "abc-ns-project", Description: "first"}), &project)
+
+	if project.Name != DefaultDomain+"-abc-ns-project" {
+		t.Fatalf("expected domain to be prepended to project name, got %s", project.Name)
+	}
+
+	if status, _ := resellerRequest(t, cloud, token, http.MethodPost, "/v1/projects", projectCreateRequest{Name: "abc-ns-project"}); status != http.StatusConflict {
+		t.Errorf("expected duplicate project to be rejected, got status %d", status)
+	}
+
+	description := "second"
+	resellerMust(t, cloud, token, http.MethodPatch, "/v1/projects/"+project.Id, projectUpdateRequest{Description: &description})
+	resellerMust(t, cloud, token, http.MethodPut, "/v1/projects/"+project.Id+"/quota", map[string]any{"compute": map[string]int{"cores": 4}})
+	resellerMust(t, cloud, token, http.MethodPut, "/v1/projects/"+project.Id+"/quota", map[string]any{"network": map[string]int{"network": 1}})
+
+	stored, ok := cloud.ProjectBySuffix("abc-ns-project")
+	if !ok || stored.Description != "second" {
+		t.Fatalf("expected updated project, got %+v", stored)
+	}
+
+	quota := map[string]map[string]int{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodGet, "/v1/projects/"+project.Id+"/quota", nil), &quota)
+	if quota["compute"]["cores"] != 4 || quota["network"]["network"] != 1 {
+		t.Errorf("expected quota sections to be merged, got %v", quota)
+	}
+
+	user := userResponse{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodPost, "/v1/users", userCreateRequest{Name: "user@abc.k8s", Password: "pw", DefaultProject: &project.Id}), &user)
+	resellerMust(t, cloud, token, http.MethodPut, fmt.Sprintf("/v1/projects/%s/users/%s", project.Id, user.Id), nil)
+
+	memberships := []membershipResponse{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodGet, fmt.Sprintf("/v1/projects/%s/users", project.Id), nil), &memberships)
+	if len(memberships) != 1 || memberships[0].User != user.Id {
+		t.Errorf("expected user to be member of project, got %v", memberships)
+	}
+
+	resellerMust(t, cloud, token, http.MethodDelete, "/v1/users/"+user.Id, nil)
+	if stored, _ := cloud.ProjectBySuffix("abc-ns-project"); len(stored.Members) != 0 {
+		t.Errorf("expected deleted user to be removed from project, got members %v", stored.Members)
+	}
+
+	resellerMust(t, cloud, token, http.MethodDelete, "/v1/projects/"+project.Id, nil)
+	if status, _ := resellerRequest(t, cloud, token, http.MethodGet, "/v1/projects/"+project.Id, nil); status != http.StatusNotFound {
+		t.Errorf("expected deleted project to be gone, got status %d", status)
+	}
+}
+
+func TestKeystone(t *testing.T) {
+	cloud := New("reseller", "secret")
+	defer cloud.Close()
+
+	token := resellerLogin(t, cloud)
+	project := projectResponse{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodPost, "/v1/projects", projectCreateRequest{Name: "abc-ns-project"}), &project)
+	user := userResponse{}
+	mustDecode(t, resellerMust(t, cloud, token, http.MethodPost, "/v1/users", userCreateRequest{Name: "user@abc.k8s", Password: "pw"}), &user)
+
+	//Users can't authenticate scoped to projects they aren't member of
+	if _, err := identityClient(cloud, gophercloud.AuthOptions{Username: "user@abc.k8s", Password: "pw", DomainName: DefaultDomain, TenantName: project.Name}); err == nil {
+		t.Fatal("expected authentication of non-member to fail")
+	}
+
+	resellerMust(t, cloud, token, http.MethodPut, fmt.Sprintf("/v1/projects/%s/users/%s", project.Id, user.Id), nil)
+
+	admin, err := identityClient(cloud, gophercloud.AuthOptions{Username: "reseller", Password: "secret", DomainName: DefaultDomain, Scope: &gophercloud.AuthScope{DomainName: DefaultDomain}})
+	if err != nil {
+		t.Fatalf("domain scoped authentication failed: %v", err)
+	}
+
+	pages, err := roles.List(admin, roles.ListOpts{}).AllPages()
+	if err != nil {
+		t.Fatalf("listing roles failed: %v", err)
+	}
+	available, err := roles.ExtractRoles(pages)
+	if err != nil || len(available) != len(DefaultRoles) {
+		t.Fatalf("expected %d roles, got %v (%v)", len(DefaultRoles), available, err)
+	}
+
+	if err := roles.Assign(admin, available[1].ID, roles.AssignOpts{UserID: user.Id, ProjectID: project.Id}).ExtractErr(); err != nil {
+		t.Fatalf("assigning role failed: %v", err)
+	}
+	if assigned := cloud.AssignedRoles(project.Id, user.Id); len(assigned) != 2 {
+		t.Fatalf("expected membership role and assigned role, got %v", assigned)
+	}
+
+	if err := roles.Unassign(admin, available[0].ID, roles.UnassignOpts{UserID: user.Id, ProjectID: project.Id}).ExtractErr(); err != nil {
+		t.Fatalf("unassigning role failed: %v", err)
+	}
+	if assigned := cloud.AssignedRoles(project.Id, user.Id); len(assigned) != 1 || assigned[0] != available[1].Name {
+		t.Fatalf("expected only assigned role to remain, got %v", assigned)
+	}
+
+	svc, err := identityClient(cloud, gophercloud.AuthOptions{Username: "user@abc.k8s", Password: "pw", DomainName: DefaultDomain, TenantName: project.Name})
+	if err != nil {
+		t.Fatalf("project scoped authentication failed: %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	created, err := applicationcredentials.Create(svc, user.Id, applicationcredentials.CreateOpts{
+		Name:        "credential",
+		ExpiresAt:   &expiresAt,
+		AccessRules: []applicationcredentials.AccessRule{{Service: "compute", Method: "GET", Path: "/v2.1/servers"}},
+	}).Extract()
+	if err != nil {
+		t.Fatalf("creating application credential failed: %v", err)
+	}
+
+	if created.Secret == "" || created.ExpiresAt.IsZero() || len(created.Roles) != 1 || len(created.AccessRules) != 1 {
+		t.Errorf("unexpected application credential %+v", created)
+	}
+
+	pages, err = applicationcredentials.List(svc, user.Id, applicationcredentials.ListOpts{Name: "credential"}).AllPages()
+	if err != nil {
+		t.Fatalf("listing application credentials failed: %v", err)
+	}
+	listed, err := applicationcredentials.ExtractApplicationCredentials(pages)
+	if err != nil || len(listed) != 1 || listed[0].ID != created.ID || listed[0].Secret != "" {
+		t.Fatalf("expected created application credential without secret, got %+v (%v)", listed, err)
+	}
+
+	if err := applicationcredentials.Delete(svc, user.Id, created.ID).ExtractErr(); err != nil {
+		t.Fatalf("deleting application credential failed: %v", err)
+	}
+
+	_, err = applicationcredentials.Get(svc, user.Id, created.ID).Extract()
+	if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		t.Errorf("expected deleted application credential to be gone, got %v", err)
+	}
+}
+
+func identityClient(cloud *Cloud, opts gophercloud.AuthOptions) (*gophercloud.ServiceClient, error) {
+	opts.IdentityEndpoint = cloud.KeystoneURL()
+
+	provider, err := openstack.AuthenticatedClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{})
+}
+
+func resellerLogin(t *testing.T, cloud *Cloud) string {
+	t.Helper()
+
+	response := map[string]string{}
+	mustDecode(t, resellerMust(t, cloud, "", http.MethodPost, "/v1/login", loginRequest{Username: "reseller", Password: "secret"}), &response)
+
+	return response["access_token"]
+}
+
+func resellerMust(t *testing.T, cloud *Cloud, token string, method string, path string, body any) []byte {
+	t.Helper()
+
+	status, response := resellerRequest(t, cloud, token, method, path, body)
+	if status >= 300 {
+		t.Fatalf("%s %s failed with status %d: %s", method, path, status, response)
+	}
+
+	return response
+}
+
+func resellerRequest(t *testing.T, cloud *Cloud, token string, method string, path string, body any) (int, []byte) {
+	t.Helper()
+
+	encoded := []byte{}
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, cloud.ResellerURL()+path, bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", resellerTokenPrefix+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	response := bytes.Buffer{}
+	if _, err := response.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, response.Bytes()
+}
+
+func mustDecode(t *testing.T, data []byte, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding %s failed: %v", data, err)
+	}
+}
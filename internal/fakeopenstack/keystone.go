@@ -0,0 +1,450 @@
+package fakeopenstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	keystoneTokenHeader = "X-Subject-Token"
+	keystoneAuthHeader  = "X-Auth-Token"
+	// keystoneTimeFormat is the format of the expiry of application credentials
+	keystoneTimeFormat = "2006-01-02T15:04:05.000000"
+)
+
+type keystoneName struct {
+	ID     string        `json:"id,omitempty"`
+	Name   string        `json:"name,omitempty"`
+	Domain *keystoneName `json:"domain,omitempty"`
+}
+
+type keystoneAuthRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User struct {
+					keystoneName
+					Password string `json:"password"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope *struct {
+			Project *keystoneName `json:"project"`
+			Domain  *keystoneName `json:"domain"`
+		} `json:"scope"`
+	} `json:"auth"`
+}
+
+type keystoneRole struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type keystoneAccessRule struct {
+	ID      string `json:"id,omitempty"`
+	Service string `json:"service"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+}
+
+type keystoneApplicationCredential struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Secret       string               `json:"secret,omitempty"`
+	ProjectID    string               `json:"project_id"`
+	Unrestricted bool                 `json:"unrestricted"`
+	Roles        []keystoneRole       `json:"roles"`
+	AccessRules  []keystoneAccessRule `json:"access_rules"`
+	ExpiresAt    *string              `json:"expires_at"`
+}
+
+type keystoneApplicationCredentialRequest struct {
+	ApplicationCredential struct {
+		Name         string               `json:"name"`
+		Description  string               `json:"description"`
+		Unrestricted bool                 `json:"unrestricted"`
+		Roles        []keystoneRole       `json:"roles"`
+		AccessRules  []keystoneAccessRule `json:"access_rules"`
+		ExpiresAt    string               `json:"expires_at"`
+	} `json:"application_credential"`
+}
+
+type keystoneError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Title   string `json:"title"`
+	} `json:"error"`
+}
+
+func (c *Cloud) keystoneHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", c.versions)
+	mux.HandleFunc("POST /v3/auth/tokens", c.issueKeystoneToken)
+	mux.HandleFunc("GET /v3/roles", c.withKeystoneToken(c.listRoles))
+	mux.HandleFunc("GET /v3/projects/{project_id}/users/{user_id}/roles", c.withKeystoneToken(c.listAssignedRoles))
+	mux.HandleFunc("PUT /v3/projects/{project_id}/users/{user_id}/roles/{role_id}", c.withKeystoneToken(c.assignRoleHandler))
+	mux.HandleFunc("DELETE /v3/projects/{project_id}/users/{user_id}/roles/{role_id}", c.withKeystoneToken(c.unassignRole))
+	mux.HandleFunc("GET /v3/users/{user_id}/application_credentials", c.withKeystoneToken(c.withSelf(c.listApplicationCredentials)))
+	mux.HandleFunc("POST /v3/users/{user_id}/application_credentials", c.withKeystoneToken(c.withSelf(c.createApplicationCredential)))
+	mux.HandleFunc("GET /v3/users/{user_id}/application_credentials/{id}", c.withKeystoneToken(c.withSelf(c.getApplicationCredential)))
+	mux.HandleFunc("DELETE /v3/users/{user_id}/application_credentials/{id}", c.withKeystoneToken(c.withSelf(c.deleteApplicationCredential)))
+
+	return c.counted(mux)
+}
+
+// versions serves the version discovery document of Keystone, which only offers v3
+func (c *Cloud) versions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusMultipleChoices, map[string]any{
+		"versions": map[string]any{
+			"values": []map[string]any{{
+				"id":     "v3.14",
+				"status": "stable",
+				"links":  []map[string]string{{"rel": "self", "href": c.KeystoneURL() + "/v3/"}},
+			}},
+		},
+	})
+}
+
+// issueKeystoneToken authenticates the reseller account or a user by password, scoped to a project the user is a member of
+// or to the domain of the reseller
+func (c *Cloud) issueKeystoneToken(w http.ResponseWriter, r *http.Request) {
+	request := keystoneAuthRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeKeystoneError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	credentials := request.Auth.Identity.Password.User
+	userID, ok := c.authenticate(credentials.Name, credentials.Password)
+	if !ok {
+		writeKeystoneError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+
+	body := map[string]any{
+		"methods":    request.Auth.Identity.Methods,
+		"expires_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"user":       keystoneName{ID: userID, Name: credentials.Name, Domain: &keystoneName{Name: c.Domain}},
+		"catalog": []map[string]any{{
+			"id":   "identity",
+			"type": "identity",
+			"name": "keystone",
+			"endpoints": []map[string]string{{
+				"id":        "identity-public",
+				"interface": "public",
+				"region":    "fake",
+				"region_id": "fake",
+				"url":       c.KeystoneURL() + "/v3/",
+			}},
+		}},
+	}
+
+	projectID := ""
+	if scope := request.Auth.Scope; scope != nil {
+		switch {
+		case scope.Project != nil:
+			project := c.scopedProject(*scope.Project)
+			if project == nil || (userID != c.resellerUserID && !c.isMember(project, userID)) {
+				writeKeystoneError(w, http.StatusUnauthorized, "User has no access to project")
+				return
+			}
+
+			projectID = project.ID
+			body["project"] = keystoneName{ID: project.ID, Name: project.Name, Domain: &keystoneName{Name: c.Domain}}
+		case scope.Domain != nil:
+			if userID != c.resellerUserID || (scope.Domain.Name != c.Domain && scope.Domain.ID != c.Domain) {
+				writeKeystoneError(w, http.StatusUnauthorized, "User has no access to domain")
+				return
+			}
+
+			body["domain"] = keystoneName{ID: c.Domain, Name: c.Domain}
+		}
+	}
+
+	w.Header().Set(keystoneTokenHeader, c.issueToken(userID, projectID))
+	writeJSON(w, http.StatusCreated, map[string]any{"token": body})
+}
+
+// withKeystoneToken rejects requests without a valid token
+func (c *Cloud) withKeystoneToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := c.tokens[r.Header.Get(keystoneAuthHeader)]; !ok {
+			writeKeystoneError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// withSelf only allows users to manage their own application credentials
+func (c *Cloud) withSelf(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c.tokens[r.Header.Get(keystoneAuthHeader)].userID != r.PathValue("user_id") {
+			writeKeystoneError(w, http.StatusForbidden, "You are not authorized to perform the requested action.")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (c *Cloud) listRoles(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"roles": c.keystoneRoles(c.roles), "links": map[string]any{}})
+}
+
+func (c *Cloud) listAssignedRoles(w http.ResponseWriter, r *http.Request) {
+	assigned := make([]Role, 0)
+	for _, k := range c.roles {
+		if c.roleAssignments[assignmentKey(r.PathValue("project_id"), r.PathValue("user_id"))][k.ID] {
+			assigned = append(assigned, k)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"roles": c.keystoneRoles(assigned), "links": map[string]any{}})
+}
+
+func (c *Cloud) assignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if !c.roleExists(r.PathValue("role_id")) {
+		writeKeystoneError(w, http.StatusNotFound, fmt.Sprintf("Could not find role: %s.", r.PathValue("role_id")))
+		return
+	}
+
+	c.assignRole(r.PathValue("project_id"), r.PathValue("user_id"), r.PathValue("role_id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cloud) unassignRole(w http.ResponseWriter, r *http.Request) {
+	key := assignmentKey(r.PathValue("project_id"), r.PathValue("user_id"))
+	if !c.roleAssignments[key][r.PathValue("role_id")] {
+		writeKeystoneError(w, http.StatusNotFound, "Could not find role assignment.")
+		return
+	}
+
+	delete(c.roleAssignments[key], r.PathValue("role_id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cloud) listApplicationCredentials(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	credentials := make([]keystoneApplicationCredential, 0)
+	for _, k := range c.applicationCredentials[r.PathValue("user_id")] {
+		if name == "" || k.Name == name {
+			credentials = append(credentials, c.keystoneApplicationCredential(k, false))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"application_credentials": credentials, "links": map[string]any{}})
+}
+
+// createApplicationCredential issues an application credential for the project the token is scoped to.
+// Without requested roles, the credential inherits all roles of the user within the project
+func (c *Cloud) createApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	request := keystoneApplicationCredentialRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeKeystoneError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID := r.PathValue("user_id")
+	projectID := c.tokens[r.Header.Get(keystoneAuthHeader)].projectID
+	if projectID == "" {
+		writeKeystoneError(w, http.StatusForbidden, "Application credentials require a project scoped token.")
+		return
+	}
+
+	spec := request.ApplicationCredential
+	for _, k := range c.applicationCredentials[userID] {
+		if k.Name == spec.Name {
+			writeKeystoneError(w, http.StatusConflict, "Duplicate entry.")
+			return
+		}
+	}
+
+	credential := &ApplicationCredential{
+		ID:           c.newID(),
+		Name:         spec.Name,
+		Description:  spec.Description,
+		Secret:       fmt.Sprintf("secret-%s", c.newID()),
+		ProjectID:    projectID,
+		Unrestricted: spec.Unrestricted,
+	}
+
+	assigned := c.roleAssignments[assignmentKey(projectID, userID)]
+	for _, k := range c.roles {
+		requested := len(spec.Roles) == 0
+		for _, role := range spec.Roles {
+			requested = requested || role.Name == k.Name || role.ID == k.ID
+		}
+
+		if !requested {
+			continue
+		}
+
+		if !assigned[k.ID] {
+			if len(spec.Roles) > 0 {
+				writeKeystoneError(w, http.StatusForbidden, fmt.Sprintf("User has no role %s within project.", k.Name))
+				return
+			}
+
+			continue
+		}
+
+		credential.Roles = append(credential.Roles, k.Name)
+	}
+
+	for _, k := range spec.AccessRules {
+		credential.AccessRules = append(credential.AccessRules, AccessRule{Service: k.Service, Method: k.Method, Path: k.Path})
+	}
+
+	if spec.ExpiresAt != "" {
+		expiresAt, err := parseKeystoneTime(spec.ExpiresAt)
+		if err != nil {
+			writeKeystoneError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		credential.ExpiresAt = &expiresAt
+	}
+
+	if c.applicationCredentials[userID] == nil {
+		c.applicationCredentials[userID] = map[string]*ApplicationCredential{}
+	}
+	c.applicationCredentials[userID][credential.ID] = credential
+
+	writeJSON(w, http.StatusCreated, map[string]any{"application_credential": c.keystoneApplicationCredential(credential, true)})
+}
+
+func (c *Cloud) getApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	credential, ok := c.applicationCredentials[r.PathValue("user_id")][r.PathValue("id")]
+	if !ok {
+		writeKeystoneError(w, http.StatusNotFound, fmt.Sprintf("Could not find Application Credential: %s.", r.PathValue("id")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"application_credential": c.keystoneApplicationCredential(credential, false)})
+}
+
+func (c *Cloud) deleteApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.applicationCredentials[r.PathValue("user_id")][r.PathValue("id")]; !ok {
+		writeKeystoneError(w, http.StatusNotFound, fmt.Sprintf("Could not find Application Credential: %s.", r.PathValue("id")))
+		return
+	}
+
+	delete(c.applicationCredentials[r.PathValue("user_id")], r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scopedProject returns the project referenced by id or by its name. The caller must hold mu
+func (c *Cloud) scopedProject(scope keystoneName) *Project {
+	for _, k := range c.projects {
+		if k.ID == scope.ID || (scope.Name != "" && k.Name == scope.Name) {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// isMember returns whether the user has a role within the project. The caller must hold mu
+func (c *Cloud) isMember(project *Project, userID string) bool {
+	return len(c.roleAssignments[assignmentKey(project.ID, userID)]) > 0
+}
+
+// assignRole assigns the role to the user within the project. The caller must hold mu
+func (c *Cloud) assignRole(projectID string, userID string, roleID string) {
+	key := assignmentKey(projectID, userID)
+	if c.roleAssignments[key] == nil {
+		c.roleAssignments[key] = map[string]bool{}
+	}
+
+	c.roleAssignments[key][roleID] = true
+}
+
+// roleExists returns whether a role with the id exists. The caller must hold mu
+func (c *Cloud) roleExists(id string) bool {
+	for _, k := range c.roles {
+		if k.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Cloud) keystoneRoles(roles []Role) []keystoneRole {
+	keystoneRoles := make([]keystoneRole, 0, len(roles))
+	for _, k := range roles {
+		keystoneRoles = append(keystoneRoles, keystoneRole{ID: k.ID, Name: k.Name})
+	}
+
+	return keystoneRoles
+}
+
+// keystoneApplicationCredential renders the credential, the secret is only returned on creation
+func (c *Cloud) keystoneApplicationCredential(credential *ApplicationCredential, withSecret bool) keystoneApplicationCredential {
+	rendered := keystoneApplicationCredential{
+		ID:           credential.ID,
+		Name:         credential.Name,
+		Description:  credential.Description,
+		ProjectID:    credential.ProjectID,
+		Unrestricted: credential.Unrestricted,
+		Roles:        make([]keystoneRole, 0, len(credential.Roles)),
+		AccessRules:  make([]keystoneAccessRule, 0, len(credential.AccessRules)),
+	}
+
+	if withSecret {
+		rendered.Secret = credential.Secret
+	}
+
+	for _, k := range c.roles {
+		for _, name := range credential.Roles {
+			if k.Name == name {
+				rendered.Roles = append(rendered.Roles, keystoneRole{ID: k.ID, Name: k.Name})
+			}
+		}
+	}
+
+	for i, k := range credential.AccessRules {
+		rendered.AccessRules = append(rendered.AccessRules, keystoneAccessRule{
+			ID:      fmt.Sprintf("%s-%d", credential.ID, i),
+			Service: k.Service,
+			Method:  k.Method,
+			Path:    k.Path,
+		})
+	}
+
+	if credential.ExpiresAt != nil {
+		expiresAt := credential.ExpiresAt.UTC().Format(keystoneTimeFormat)
+		rendered.ExpiresAt = &expiresAt
+	}
+
+	return rendered
+}
+
+// parseKeystoneTime accepts timestamps with and without zone, as Keystone does. Timestamps without zone are UTC
+func parseKeystoneTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+func writeKeystoneError(w http.ResponseWriter, status int, message string) {
+	body := keystoneError{}
+	body.Error.Code = status
+	body.Error.Message = message
+	body.Error.Title = http.StatusText(status)
+
+	writeJSON(w, status, body)
+}
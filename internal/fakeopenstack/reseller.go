@@ -0,0 +1,463 @@
+package fakeopenstack
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// The routes of the reseller API served by the fake, as requested by the psos client
+const (
+	routeLogin          = "POST /v1/login"
+	routeGetProjects    = "GET /v1/projects"
+	routeCreateProject  = "POST /v1/projects"
+	routeGetProject     = "GET /v1/projects/{project_id}"
+	routeUpdateProject  = "PATCH /v1/projects/{project_id}"
+	routeDeleteProject  = "DELETE /v1/projects/{project_id}"
+	routeGetQuota       = "GET /v1/projects/{project_id}/quota"
+	routeUpdateQuota    = "PUT /v1/projects/{project_id}/quota"
+	routeGetMembers     = "GET /v1/projects/{project_id}/users"
+	routeAddMember      = "PUT /v1/projects/{project_id}/users/{user_id}"
+	routeRemoveMember   = "DELETE /v1/projects/{project_id}/users/{user_id}"
+	routeGetUsers       = "GET /v1/users"
+	routeCreateUser     = "POST /v1/users"
+	routeGetUser        = "GET /v1/users/{user_id}"
+	routeUpdateUser     = "PATCH /v1/users/{user_id}"
+	routeDeleteUser     = "DELETE /v1/users/{user_id}"
+	resellerTokenPrefix = "Bearer "
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type projectResponse struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DomainId    string `json:"domain_id"`
+	Enabled     bool   `json:"enabled"`
+}
+
+type projectCreateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+type projectUpdateRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Enabled     *bool   `json:"enabled"`
+}
+
+type userResponse struct {
+	Id             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Enabled        bool   `json:"enabled"`
+	DefaultProject string `json:"default_project_id,omitempty"`
+}
+
+type userCreateRequest struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	Enabled        *bool   `json:"enabled"`
+	DefaultProject *string `json:"default_project_id"`
+	Password       string  `json:"password"`
+}
+
+type userUpdateRequest struct {
+	Name           *string `json:"name"`
+	Description    *string `json:"description"`
+	Enabled        *bool   `json:"enabled"`
+	DefaultProject *string `json:"default_project_id"`
+	Password       *string `json:"password"`
+}
+
+type membershipResponse struct {
+	Project string `json:"project"`
+	User    string `json:"user"`
+}
+
+type resellerError struct {
+	Detail string `json:"detail"`
+}
+
+func (c *Cloud) resellerHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(routeLogin, c.login)
+	mux.HandleFunc(routeGetProjects, c.authorized(c.getProjects))
+	mux.HandleFunc(routeCreateProject, c.authorized(c.createProject))
+	mux.HandleFunc(routeGetProject, c.authorized(c.withProject(c.getProject)))
+	mux.HandleFunc(routeUpdateProject, c.authorized(c.withProject(c.updateProject)))
+	mux.HandleFunc(routeDeleteProject, c.authorized(c.withProject(c.deleteProject)))
+	mux.HandleFunc(routeGetQuota, c.authorized(c.withProject(c.getQuota)))
+	mux.HandleFunc(routeUpdateQuota, c.authorized(c.withProject(c.updateQuota)))
+	mux.HandleFunc(routeGetMembers, c.authorized(c.withProject(c.getMembers)))
+	mux.HandleFunc(routeAddMember, c.authorized(c.withProject(c.withUser(c.addMember))))
+	mux.HandleFunc(routeRemoveMember, c.authorized(c.withProject(c.withUser(c.removeMember))))
+	mux.HandleFunc(routeGetUsers, c.authorized(c.getUsers))
+	mux.HandleFunc(routeCreateUser, c.authorized(c.createUser))
+	mux.HandleFunc(routeGetUser, c.authorized(c.withUser(c.getUser)))
+	mux.HandleFunc(routeUpdateUser, c.authorized(c.withUser(c.updateUser)))
+	mux.HandleFunc(routeDeleteUser, c.authorized(c.withUser(c.deleteUser)))
+
+	return c.counted(mux)
+}
+
+// counted counts the requests per route and serializes them, so handlers can modify the state without further locking
+func (c *Cloud) counted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+		c.requests[r.Pattern]++
+	})
+}
+
+// login accepts the credentials either as JSON or as form, like the OAuth2 password flow
+func (c *Cloud) login(w http.ResponseWriter, r *http.Request) {
+	credentials := loginRequest{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, resellerError{Detail: err.Error()})
+			return
+		}
+	} else {
+		credentials.Username = r.FormValue("username")
+		credentials.Password = r.FormValue("password")
+	}
+
+	if credentials.Username != c.username || credentials.Password != c.password {
+		writeJSON(w, http.StatusUnauthorized, resellerError{Detail: "Incorrect username or password"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": c.issueToken(c.resellerUserID, ""),
+		"token_type":   "bearer",
+	})
+}
+
+// authorized rejects requests without a token of the reseller account
+func (c *Cloud) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		issued, ok := c.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), resellerTokenPrefix)]
+		if !ok || issued.userID != c.resellerUserID {
+			writeJSON(w, http.StatusUnauthorized, resellerError{Detail: "Not authenticated"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// withProject responds with 404 unless the project referenced by the path exists
+func (c *Cloud) withProject(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := c.projects[r.PathValue("project_id")]; !ok {
+			writeJSON(w, http.StatusNotFound, resellerError{Detail: "Project not found"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// withUser responds with 404 unless the user referenced by the path exists
+func (c *Cloud) withUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := c.users[r.PathValue("user_id")]; !ok {
+			writeJSON(w, http.StatusNotFound, resellerError{Detail: "User not found"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (c *Cloud) getProjects(w http.ResponseWriter, _ *http.Request) {
+	projects := make([]projectResponse, 0, len(c.projects))
+	for _, k := range c.projects {
+		projects = append(projects, c.projectResponse(k))
+	}
+
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (c *Cloud) createProject(w http.ResponseWriter, r *http.Request) {
+	request := projectCreateRequest{}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	//The reseller API prepends its domain
+	name := c.Domain + "-" + request.Name
+	for _, k := range c.projects {
+		if k.Name == name {
+			writeJSON(w, http.StatusConflict, resellerError{Detail: "Project already exists"})
+			return
+		}
+	}
+
+	project := &Project{
+		ID:          c.newID(),
+		Name:        name,
+		Description: request.Description,
+		Enabled:     request.Enabled == nil || *request.Enabled,
+		Quota:       json.RawMessage("{}"),
+	}
+	c.projects[project.ID] = project
+
+	writeJSON(w, http.StatusCreated, c.projectResponse(project))
+}
+
+func (c *Cloud) getProject(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.projectResponse(c.projects[r.PathValue("project_id")]))
+}
+
+func (c *Cloud) updateProject(w http.ResponseWriter, r *http.Request) {
+	request := projectUpdateRequest{}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	project := c.projects[r.PathValue("project_id")]
+	if request.Name != nil {
+		//Names may be passed with or without the domain
+		project.Name = c.Domain + "-" + strings.TrimPrefix(*request.Name, c.Domain+"-")
+	}
+	if request.Description != nil {
+		project.Description = *request.Description
+	}
+	if request.Enabled != nil {
+		project.Enabled = *request.Enabled
+	}
+
+	writeJSON(w, http.StatusOK, c.projectResponse(project))
+}
+
+func (c *Cloud) deleteProject(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("project_id")
+	delete(c.projects, id)
+
+	for k := range c.roleAssignments {
+		if strings.HasPrefix(k, id+"/") {
+			delete(c.roleAssignments, k)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cloud) getQuota(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.projects[r.PathValue("project_id")].Quota)
+}
+
+// updateQuota replaces the quota sections (compute, network, volume) contained in the request
+func (c *Cloud) updateQuota(w http.ResponseWriter, r *http.Request) {
+	request := map[string]json.RawMessage{}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	project := c.projects[r.PathValue("project_id")]
+
+	quota := map[string]json.RawMessage{}
+	if err := json.Unmarshal(project.Quota, &quota); err != nil {
+		writeJSON(w, http.StatusInternalServerError, resellerError{Detail: err.Error()})
+		return
+	}
+
+	for k, v := range request {
+		if string(v) != "null" {
+			quota[k] = v
+		}
+	}
+
+	updated, err := json.Marshal(quota)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, resellerError{Detail: err.Error()})
+		return
+	}
+
+	project.Quota = updated
+	writeJSON(w, http.StatusOK, project.Quota)
+}
+
+func (c *Cloud) getMembers(w http.ResponseWriter, r *http.Request) {
+	project := c.projects[r.PathValue("project_id")]
+
+	memberships := make([]membershipResponse, 0, len(project.Members))
+	for _, k := range project.Members {
+		memberships = append(memberships, membershipResponse{Project: project.ID, User: k})
+	}
+
+	writeJSON(w, http.StatusOK, memberships)
+}
+
+// addMember adds the user to the project and grants the first role, so the user can authenticate scoped to the project
+func (c *Cloud) addMember(w http.ResponseWriter, r *http.Request) {
+	project := c.projects[r.PathValue("project_id")]
+	userID := r.PathValue("user_id")
+
+	for _, k := range project.Members {
+		if k == userID {
+			writeJSON(w, http.StatusConflict, resellerError{Detail: "User is already member of project"})
+			return
+		}
+	}
+
+	project.Members = append(project.Members, userID)
+	c.assignRole(project.ID, userID, c.roles[0].ID)
+
+	writeJSON(w, http.StatusOK, membershipResponse{Project: project.ID, User: userID})
+}
+
+func (c *Cloud) removeMember(w http.ResponseWriter, r *http.Request) {
+	project := c.projects[r.PathValue("project_id")]
+	userID := r.PathValue("user_id")
+
+	members := make([]string, 0, len(project.Members))
+	for _, k := range project.Members {
+		if k != userID {
+			members = append(members, k)
+		}
+	}
+
+	if len(members) == len(project.Members) {
+		writeJSON(w, http.StatusNotFound, resellerError{Detail: "User is no member of project"})
+		return
+	}
+
+	project.Members = members
+	delete(c.roleAssignments, assignmentKey(project.ID, userID))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cloud) getUsers(w http.ResponseWriter, _ *http.Request) {
+	users := make([]userResponse, 0, len(c.users))
+	for _, k := range c.users {
+		users = append(users, userResponseOf(k))
+	}
+
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (c *Cloud) createUser(w http.ResponseWriter, r *http.Request) {
+	request := userCreateRequest{}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	for _, k := range c.users {
+		if k.Name == request.Name {
+			writeJSON(w, http.StatusConflict, resellerError{Detail: "User already exists"})
+			return
+		}
+	}
+
+	if request.Name == "" || request.Password == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, resellerError{Detail: "Name and password are required"})
+		return
+	}
+
+	user := &User{
+		ID:          c.newID(),
+		Name:        request.Name,
+		Description: request.Description,
+		Enabled:     request.Enabled == nil || *request.Enabled,
+		Password:    request.Password,
+	}
+	if request.DefaultProject != nil {
+		user.DefaultProject = *request.DefaultProject
+	}
+	c.users[user.ID] = user
+
+	writeJSON(w, http.StatusCreated, userResponseOf(user))
+}
+
+func (c *Cloud) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, userResponseOf(c.users[r.PathValue("user_id")]))
+}
+
+func (c *Cloud) updateUser(w http.ResponseWriter, r *http.Request) {
+	request := userUpdateRequest{}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	user := c.users[r.PathValue("user_id")]
+	if request.Name != nil {
+		user.Name = *request.Name
+	}
+	if request.Description != nil {
+		user.Description = *request.Description
+	}
+	if request.Enabled != nil {
+		user.Enabled = *request.Enabled
+	}
+	if request.DefaultProject != nil {
+		user.DefaultProject = *request.DefaultProject
+	}
+	if request.Password != nil {
+		user.Password = *request.Password
+	}
+
+	writeJSON(w, http.StatusOK, userResponseOf(user))
+}
+
+func (c *Cloud) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("user_id")
+	delete(c.users, id)
+	delete(c.applicationCredentials, id)
+
+	for _, k := range c.projects {
+		members := make([]string, 0, len(k.Members))
+		for _, m := range k.Members {
+			if m != id {
+				members = append(members, m)
+			}
+		}
+		k.Members = members
+
+		delete(c.roleAssignments, assignmentKey(k.ID, id))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cloud) projectResponse(project *Project) projectResponse {
+	return projectResponse{
+		Id:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		DomainId:    c.Domain,
+		Enabled:     project.Enabled,
+	}
+}
+
+func userResponseOf(user *User) userResponse {
+	return userResponse{
+		Id:             user.ID,
+		Name:           user.Name,
+		Description:    user.Description,
+		Enabled:        user.Enabled,
+		DefaultProject: user.DefaultProject,
+	}
+}
+
+// decode decodes the JSON body into v and responds with 422 like the reseller API if it is invalid
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, resellerError{Detail: err.Error()})
+		return false
+	}
+
+	return true
+}
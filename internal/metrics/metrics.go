@@ -0,0 +1,176 @@
+// Package metrics contains the prometheus metrics of the operator, registered on the metrics endpoint of the manager
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// APIs the operator talks to
+const (
+	APIReseller = "reseller"
+	APIKeystone = "keystone"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pco_api_requests_total",
+		Help: "Number of requests sent to the reseller API and OpenStack by region, operation and error class",
+	}, []string{"api", "region", "operation", "error"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pco_api_request_duration_seconds",
+		Help:    "Latency of requests sent to the reseller API and OpenStack by region and operation",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"api", "region", "operation"})
+
+	loginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pco_api_logins_total",
+		Help: "Number of logins to the reseller API and OpenStack by region and error class",
+	}, []string{"api", "region", "error"})
+
+	orphans = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pco_orphaned_resources",
+		Help: "Number of OpenStack projects and users named after this operator without resource, by region and kind",
+	}, []string{"region", "kind"})
+
+	orphansDeletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pco_orphaned_resources_deleted_total",
+		Help: "Number of orphaned OpenStack projects and users deleted by region and kind",
+	}, []string{"region", "kind"})
+
+	inventoryLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pco_inventory_lookups_total",
+		Help: "Number of OpenStack projects and users looked up within the inventory cache by region, kind and result",
+	}, []string{"region", "kind", "result"})
+
+	inventoryAgeDesc = prometheus.NewDesc(
+		"pco_inventory_age_seconds",
+		"Time since the OpenStack projects and users of the inventory cache were last listed, by region and kind",
+		[]string{"region", "kind"}, nil,
+	)
+
+	inventoryRefreshesMu sync.Mutex
+	inventoryRefreshes   = map[[2]string]time.Time{}
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(requestsTotal, requestDuration, loginsTotal, orphans, orphansDeletedTotal, inventoryLookupsTotal, inventoryAgeCollector{})
+}
+
+// SetOrphans records the number of orphans of the given kind found by the last scan of the region
+func SetOrphans(region string, kind string, count int) {
+	orphans.WithLabelValues(region, kind).Set(float64(count))
+}
+
+// ObserveOrphanDeleted records the deletion of an orphan of the given kind
+func ObserveOrphanDeleted(region string, kind string) {
+	orphansDeletedTotal.WithLabelValues(region, kind).Inc()
+}
+
+// DeleteOrphans removes the orphan gauges of a deleted region
+func DeleteOrphans(region string) {
+	orphans.DeletePartialMatch(prometheus.Labels{"region": region})
+}
+
+// ObserveInventoryLookup records a lookup of the given kind within the inventory cache of the region
+func ObserveInventoryLookup(region string, kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	inventoryLookupsTotal.WithLabelValues(region, kind, result).Inc()
+}
+
+// SetInventoryRefreshed records the time the inventory cache of the region was last filled with the given kind
+func SetInventoryRefreshed(region string, kind string, at time.Time) {
+	inventoryRefreshesMu.Lock()
+	defer inventoryRefreshesMu.Unlock()
+
+	inventoryRefreshes[[2]string{region, kind}] = at
+}
+
+// DeleteInventory removes the inventory metrics of a deleted region
+func DeleteInventory(region string) {
+	inventoryLookupsTotal.DeletePartialMatch(prometheus.Labels{"region": region})
+
+	inventoryRefreshesMu.Lock()
+	defer inventoryRefreshesMu.Unlock()
+
+	for k := range inventoryRefreshes {
+		if k[0] == region {
+			delete(inventoryRefreshes, k)
+		}
+	}
+}
+
+// inventoryAgeCollector computes the age of the inventory caches at scrape time
+type inventoryAgeCollector struct{}
+
+func (inventoryAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inventoryAgeDesc
+}
+
+func (inventoryAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	inventoryRefreshesMu.Lock()
+	defer inventoryRefreshesMu.Unlock()
+
+	for k, v := range inventoryRefreshes {
+		ch <- prometheus.MustNewConstMetric(inventoryAgeDesc, prometheus.GaugeValue, time.Since(v).Seconds(), k[0], k[1])
+	}
+}
+
+// ObserveRequest records a request to the given api which started at start and failed with err (or nil)
+func ObserveRequest(api string, region string, operation string, start time.Time, err error) {
+	requestsTotal.WithLabelValues(api, region, operation, ErrorClass(err)).Inc()
+	requestDuration.WithLabelValues(api, region, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveLogin records a login to the given api which failed with err (or nil)
+func ObserveLogin(api string, region string, err error) {
+	loginsTotal.WithLabelValues(api, region, ErrorClass(err)).Inc()
+}
+
+// ErrorClass maps an error to a small set of classes usable as label value
+func ErrorClass(err error) string {
+	if err == nil {
+		return "none"
+	}
+
+	var apiErr *apierror.Error
+	if errors.As(apierror.Classify(err), &apiErr) && apiErr.StatusCode != 0 {
+		return classLabels[apiErr.Class]
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return "timeout"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+
+		return "network"
+	}
+
+	return "unknown"
+}
+
+var classLabels = map[apierror.Class]string{
+	apierror.Auth:       "auth",
+	apierror.Validation: "validation",
+	apierror.NotFound:   "not_found",
+	apierror.Conflict:   "conflict",
+	apierror.RateLimit:  "rate_limit",
+	apierror.Transient:  "server",
+}
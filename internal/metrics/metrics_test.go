@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOrphans(t *testing.T) {
+	orphans.Reset()
+
+	SetOrphans("north", "project", 2)
+	SetOrphans("north", "user", 1)
+	SetOrphans("south", "project", 3)
+
+	//The last scan replaces the number of orphans
+	SetOrphans("north", "project", 0)
+
+	expected := `
+# HELP pco_orphaned_resources Number of OpenStack projects and users named after this operator without resource, by region and kind
+# TYPE pco_orphaned_resources gauge
+pco_orphaned_resources{kind="project",region="north"} 0
+pco_orphaned_resources{kind="project",region="south"} 3
+pco_orphaned_resources{kind="user",region="north"} 1
+`
+	if err := testutil.CollectAndCompare(orphans, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	DeleteOrphans("north")
+
+	expected = `
+# HELP pco_orphaned_resources Number of OpenStack projects and users named after this operator without resource, by region and kind
+# TYPE pco_orphaned_resources gauge
+pco_orphaned_resources{kind="project",region="south"} 3
+`
+	if err := testutil.CollectAndCompare(orphans, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// openStackIdPattern matches the ids within OpenStack API paths, which are hex encoded uuids with or without dashes
+var openStackIdPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// tokenPath is the path used by Keystone to issue tokens
+const tokenPath = "/v3/auth/tokens"
+
+type transport struct {
+	api    string
+	region string
+	next   http.RoundTripper
+}
+
+// NewTransport returns a http.RoundTripper recording every request as an operation of the api within the region.
+// Token requests are additionally recorded as logins
+func NewTransport(api string, region string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{api: api, region: region, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	if err == nil && resp.StatusCode >= 400 {
+		err = statusCodeError(resp.StatusCode)
+	}
+
+	ObserveRequest(t.api, t.region, operation(req), start, err)
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, tokenPath) {
+		ObserveLogin(t.api, t.region, err)
+	}
+
+	if _, ok := err.(statusCodeError); ok {
+		return resp, nil
+	}
+
+	return resp, err
+}
+
+// operation templates the request path, so ids don't end up as label values
+func operation(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, k := range segments {
+		if openStackIdPattern.MatchString(k) {
+			segments[i] = "{id}"
+		}
+	}
+
+	return fmt.Sprintf("%s /%s", req.Method, strings.Join(segments, "/"))
+}
+
+// statusCodeError is only used to classify unsuccessful responses
+type statusCodeError int
+
+func (e statusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", int(e))
+}
+
+func (e statusCodeError) GetStatusCode() int {
+	return int(e)
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, tokenPath):
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/v3/users/"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	requestsTotal.Reset()
+	loginsTotal.Reset()
+
+	c := &http.Client{Transport: NewTransport(APIKeystone, "region", nil)}
+	for _, k := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/v3/users/0123456789abcdef0123456789abcdef"},
+		{http.MethodGet, "/v3/users/01234567-89ab-cdef-0123-456789abcdef"},
+		{http.MethodGet, "/v3/roles"},
+		{http.MethodPost, tokenPath},
+	} {
+		req, err := http.NewRequest(k.method, server.URL+k.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//Unsuccessful responses are passed through instead of failing the request
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", k.method, k.path, err)
+		}
+		resp.Body.Close()
+	}
+
+	expectedRequests := `
+# HELP pco_api_requests_total Number of requests sent to the reseller API and OpenStack by region, operation and error class
+# TYPE pco_api_requests_total counter
+pco_api_requests_total{api="keystone",error="auth",operation="POST /v3/auth/tokens",region="region"} 1
+pco_api_requests_total{api="keystone",error="none",operation="GET /v3/roles",region="region"} 1
+pco_api_requests_total{api="keystone",error="not_found",operation="GET /v3/users/{id}",region="region"} 2
+`
+	if err := testutil.CollectAndCompare(requestsTotal, strings.NewReader(expectedRequests)); err != nil {
+		t.Error(err)
+	}
+
+	expectedLogins := `
+# HELP pco_api_logins_total Number of logins to the reseller API and OpenStack by region and error class
+# TYPE pco_api_logins_total counter
+pco_api_logins_total{api="keystone",error="auth",region="region"} 1
+`
+	if err := testutil.CollectAndCompare(loginsTotal, strings.NewReader(expectedLogins)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransportNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	requestsTotal.Reset()
+
+	c := &http.Client{Transport: NewTransport(APIReseller, "region", nil)}
+	if _, err := c.Get(url + "/v1/projects"); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	expected := `
+# HELP pco_api_requests_total Number of requests sent to the reseller API and OpenStack by region, operation and error class
+# TYPE pco_api_requests_total counter
+pco_api_requests_total{api="reseller",error="network",operation="GET /v1/projects",region="region"} 1
+`
+	if err := testutil.CollectAndCompare(requestsTotal, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package plan records the mutations of OpenStack a reconcile would execute, while its region is in plan mode
+package plan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// placeholderPrefix marks the ids of objects whose creation was planned
+const placeholderPrefix = "planned:"
+
+// Plan collects the mutations of a reconcile. It is inactive until the reconcile knows that its region is in plan mode
+type Plan struct {
+	mu      sync.Mutex
+	active  bool
+	changes []string
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying an inactive plan
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &Plan{})
+}
+
+// Activate switches the reconcile within ctx into plan mode. Contexts without a plan are left unchanged
+func Activate(ctx context.Context) {
+	p, ok := ctx.Value(contextKey{}).(*Plan)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active = true
+}
+
+// FromContext returns the plan of the reconcile if it is active, nil if mutations are executed
+func FromContext(ctx context.Context) *Plan {
+	p, ok := ctx.Value(contextKey{}).(*Plan)
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.active {
+		return nil
+	}
+
+	return p
+}
+
+// Active returns true if the reconcile within ctx plans its mutations instead of executing them
+func Active(ctx context.Context) bool {
+	return FromContext(ctx) != nil
+}
+
+// Record adds a mutation to the plan. Mutations planned twice are recorded once
+func (p *Plan) Record(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	change := fmt.Sprintf(format, args...)
+	for _, k := range p.changes {
+		if k == change {
+			return
+		}
+	}
+
+	p.changes = append(p.changes, change)
+}
+
+// Changes returns the planned mutations in the order they were recorded
+func (p *Plan) Changes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string{}, p.changes...)
+}
+
+// Placeholder returns the id standing in for an object whose creation was planned
+func Placeholder(name string) string {
+	return placeholderPrefix + name
+}
+
+// IsPlaceholder returns true if the id stands in for an object which doesn't exist yet
+func IsPlaceholder(id string) bool {
+	return strings.HasPrefix(id, placeholderPrefix)
+}
@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	ctx := NewContext(context.Background())
+	if Active(ctx) {
+		t.Fatal("expected plan to be inactive until activated")
+	}
+
+	Activate(ctx)
+	p := FromContext(ctx)
+	if p == nil {
+		t.Fatal("expected plan to be active")
+	}
+
+	p.Record("Create project %s", "a")
+	p.Record("Delete user %s", "b")
+	p.Record("Create project %s", "a")
+
+	changes := p.Changes()
+	if len(changes) != 2 || changes[0] != "Create project a" || changes[1] != "Delete user b" {
+		t.Errorf("expected changes to be recorded once in order, got %v", changes)
+	}
+
+	if Active(context.Background()) {
+		t.Error("expected contexts without plan to execute mutations")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	ctx := NewContext(context.Background())
+	Activate(ctx)
+
+	for _, k := range []struct {
+		method string
+		path   string
+	}{{http.MethodGet, "/v3/roles"}, {http.MethodPost, "/v3/auth/tokens"}} {
+		req, _ := http.NewRequestWithContext(ctx, k.method, server.URL+k.path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("expected %s %s to be sent, got %v", k.method, k.path, err)
+		}
+		resp.Body.Close()
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, server.URL+"/v3/projects/a/users/b/roles/c", nil)
+	if _, err := client.Do(req); !errors.Is(err, ErrUnplanned) {
+		t.Fatalf("expected mutation to be refused, got %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests to be sent, got %d", requests)
+	}
+}
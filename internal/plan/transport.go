@@ -0,0 +1,49 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrUnplanned is returned for mutating requests sent while planning, which the caller didn't plan explicitly
+var ErrUnplanned = errors.New("mutation isn't executed in plan mode")
+
+type transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport returns a http.RoundTripper refusing all mutating requests sent with an active plan within their context.
+// Authentication requests pass, as they don't change OpenStack
+func NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Active(req.Context()) || !mutating(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	//RoundTrippers have to close the body of requests they didn't send
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrUnplanned, req.Method, req.URL.Path)
+}
+
+func mutating(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	case http.MethodPost:
+		return !strings.HasSuffix(req.URL.Path, "/auth/tokens")
+	default:
+		return true
+	}
+}
@@ -0,0 +1,122 @@
+package reseller
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+)
+
+// Operations of the audit records
+const (
+	auditCreateProject         = "CreateProject"
+	auditUpdateProject         = "UpdateProject"
+	auditDeleteProject         = "DeleteProject"
+	auditUpdateProjectQuota    = "UpdateProjectQuota"
+	auditCreateUser            = "CreateUser"
+	auditUpdateUser            = "UpdateUser"
+	auditDeleteUser            = "DeleteUser"
+	auditAddUserToProject      = "AddUserToProject"
+	auditRemoveUserFromProject = "RemoveUserFromProject"
+)
+
+// auditedClient decorates a ResellerClient writing an audit record for every write sent to the API.
+// The previous state of projects and users is taken from the inventory of the region, quotas are read before they are updated
+type auditedClient struct {
+	ResellerClient
+	region    string
+	inventory *Inventory
+}
+
+// Audit decorates the client writing audit records for its writes
+func Audit(next ResellerClient, region string, inventory *Inventory) ResellerClient {
+	return &auditedClient{ResellerClient: next, region: region, inventory: inventory}
+}
+
+// CreateProject creates a project
+func (c *auditedClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	created, err := c.ResellerClient.CreateProject(ctx, project)
+	audit.Log(ctx, c.region, auditCreateProject, project.Name, nil, created, err)
+	return created, err
+}
+
+// UpdateProject updates the project with the given id. Updates which didn't change the project aren't recorded
+func (c *auditedClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	before, _ := c.inventory.Project(id)
+	updated, err := c.ResellerClient.UpdateProject(ctx, id, project)
+	if err == nil && unchanged(before, updated) {
+		return updated, err
+	}
+
+	audit.Log(ctx, c.region, auditUpdateProject, id, before, updated, err)
+	return updated, err
+}
+
+// DeleteProject deletes the project with the given id
+func (c *auditedClient) DeleteProject(ctx context.Context, id string) error {
+	before, _ := c.inventory.Project(id)
+	err := c.ResellerClient.DeleteProject(ctx, id)
+	audit.Log(ctx, c.region, auditDeleteProject, id, before, nil, err)
+	return err
+}
+
+// UpdateProjectQuota updates the quota of the project with the given id. Updates requesting the quota the project already has aren't recorded
+func (c *auditedClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	//The record lacks the previous quota if it can't be read, the update is sent anyway
+	before, _ := c.ResellerClient.GetProjectQuota(ctx, id)
+	updated, err := c.ResellerClient.UpdateProjectQuota(ctx, id, quota)
+	if err == nil && before != nil && QuotaMatches(quota, *before) {
+		return updated, err
+	}
+
+	audit.Log(ctx, c.region, auditUpdateProjectQuota, id, before, updated, err)
+	return updated, err
+}
+
+// CreateUser creates a user
+func (c *auditedClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	created, err := c.ResellerClient.CreateUser(ctx, user)
+	audit.Log(ctx, c.region, auditCreateUser, user.Name, nil, created, err)
+	return created, err
+}
+
+// UpdateUser updates the user with the given id. Updates which didn't change the user aren't recorded,
+// unless they set the password, which isn't part of the records
+func (c *auditedClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	before, _ := c.inventory.User(id)
+	updated, err := c.ResellerClient.UpdateUser(ctx, id, user)
+	if err == nil && user.Password == nil && unchanged(before, updated) {
+		return updated, err
+	}
+
+	audit.Log(ctx, c.region, auditUpdateUser, id, before, updated, err)
+	return updated, err
+}
+
+// DeleteUser deletes the user with the given id
+func (c *auditedClient) DeleteUser(ctx context.Context, id string) error {
+	before, _ := c.inventory.User(id)
+	err := c.ResellerClient.DeleteUser(ctx, id)
+	audit.Log(ctx, c.region, auditDeleteUser, id, before, nil, err)
+	return err
+}
+
+// AddUserToProject makes the user a member of the project
+func (c *auditedClient) AddUserToProject(ctx context.Context, projectId string, userId string) error {
+	err := c.ResellerClient.AddUserToProject(ctx, projectId, userId)
+	audit.Log(ctx, c.region, auditAddUserToProject, projectId, nil, openapi.ProjectUserMembership{Project: projectId, User: userId}, err)
+	return err
+}
+
+// RemoveUserFromProject removes the membership of the user within the project
+func (c *auditedClient) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
+	err := c.ResellerClient.RemoveUserFromProject(ctx, projectId, userId)
+	audit.Log(ctx, c.region, auditRemoveUserFromProject, projectId, openapi.ProjectUserMembership{Project: projectId, User: userId}, nil, err)
+	return err
+}
+
+// unchanged checks if the state after a write equals the one before, which is unknown if the inventory lacks it
+func unchanged[T any](before *T, after *T) bool {
+	return before != nil && after != nil && reflect.DeepEqual(*before, *after)
+}
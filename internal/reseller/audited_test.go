@@ -0,0 +1,114 @@
+package reseller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/audit"
+)
+
+// recordingWriter collects the written audit records
+type recordingWriter struct {
+	records []audit.Record
+}
+
+func (w *recordingWriter) Write(record audit.Record) error {
+	w.records = append(w.records, record)
+	return nil
+}
+
+// updatingClient applies user updates to a single user
+type updatingClient struct {
+	ResellerClient
+	user openapi.CreatedOpenStackUser
+}
+
+func (c *updatingClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	if user.Description != nil {
+		c.user.Description = user.Description
+	}
+
+	updated := c.user
+	return &updated, nil
+}
+
+// quotaClient stores the quota of a single project
+type quotaClient struct {
+	ResellerClient
+	quota openapi.UpdateQuota
+}
+
+func (c *quotaClient) GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error) {
+	quota := c.quota
+	return &quota, nil
+}
+
+func (c *quotaClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	c.quota = quota
+	return &quota, nil
+}
+
+func TestAuditedUnchangedUpdates(t *testing.T) {
+	w := &recordingWriter{}
+	audit.SetWriter(w)
+	defer audit.SetWriter(audit.NewLogWriter(logr.Discard()))
+
+	description := "unchanged"
+	next := &updatingClient{user: openapi.CreatedOpenStackUser{Id: "1", Name: "user@abc.k8s", Description: &description}}
+	inventory := InventoryFor("audited-unchanged", "account")
+	inventory.users.fill([]openapi.CreatedOpenStackUser{next.user}, time.Now())
+	client := Audit(next, "audited-unchanged", inventory)
+
+	ctx := context.Background()
+	if _, err := client.UpdateUser(ctx, "1", openapi.UpdateOpenStackUser{Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.records) != 0 {
+		t.Fatalf("expected an update without changes not to be recorded, got %v", w.records)
+	}
+
+	password := "secret"
+	if _, err := client.UpdateUser(ctx, "1", openapi.UpdateOpenStackUser{Password: &password}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.records) != 1 {
+		t.Fatalf("expected a password update to be recorded, got %d records", len(w.records))
+	}
+
+	changed := "changed"
+	if _, err := client.UpdateUser(ctx, "1", openapi.UpdateOpenStackUser{Description: &changed}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.records) != 2 || w.records[1].Operation != auditUpdateUser {
+		t.Fatalf("expected a changed user to be recorded, got %v", w.records)
+	}
+}
+
+func TestAuditedUnchangedQuota(t *testing.T) {
+	w := &recordingWriter{}
+	audit.SetWriter(w)
+	defer audit.SetWriter(audit.NewLogWriter(logr.Discard()))
+
+	cores, sameCores := 4, 4
+	next := &quotaClient{quota: openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &cores, Instances: 2}}}
+	client := Audit(next, "audited-quota", InventoryFor("audited-quota", "account"))
+
+	//The requested quota is allocated anew, as by every reconciliation
+	ctx := context.Background()
+	if _, err := client.UpdateProjectQuota(ctx, "1", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &sameCores, Instances: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.records) != 0 {
+		t.Fatalf("expected an update without changes not to be recorded, got %v", w.records)
+	}
+
+	if _, err := client.UpdateProjectQuota(ctx, "1", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &sameCores, Instances: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.records) != 1 || w.records[0].Operation != auditUpdateProjectQuota {
+		t.Fatalf("expected a changed quota to be recorded, got %v", w.records)
+	}
+}
@@ -0,0 +1,74 @@
+// Package reseller abstracts the reseller API of a region
+package reseller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/pluscontainer/pco-reseller-operator/internal/metrics"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
+)
+
+// ResellerClient covers every operation of the reseller API used by the operator.
+// Implementations return errors classified by apierror
+type ResellerClient interface {
+	// GetProjects lists all projects of the reseller
+	GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error)
+
+	// CreateProject creates a project
+	CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error)
+
+	// UpdateProject updates the project with the given id
+	UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error)
+
+	// DeleteProject deletes the project with the given id
+	DeleteProject(ctx context.Context, id string) error
+
+	// GetProjectQuota returns the quota of the project with the given id
+	GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error)
+
+	// UpdateProjectQuota updates the quota of the project with the given id
+	UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error)
+
+	// GetUsers lists all users of the reseller
+	GetUsers(ctx context.Context) (*[]openapi.CreatedOpenStackUser, error)
+
+	// CreateUser creates a user
+	CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error)
+
+	// UpdateUser updates the user with the given id
+	UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error)
+
+	// DeleteUser deletes the user with the given id
+	DeleteUser(ctx context.Context, id string) error
+
+	// GetUsersInProject lists the memberships of the project with the given id
+	GetUsersInProject(ctx context.Context, projectId string) (*[]openapi.ProjectUserMembership, error)
+
+	// AddUserToProject makes the user a member of the project
+	AddUserToProject(ctx context.Context, projectId string, userId string) error
+
+	// RemoveUserFromProject removes the membership of the user within the project
+	RemoveUserFromProject(ctx context.Context, projectId string, userId string) error
+}
+
+// Factory logs into the reseller API of a region and returns its client
+type Factory func(ctx context.Context, region string, endpoint string, username string, password string) (ResellerClient, error)
+
+// Login is the default Factory. It logs into the reseller API of the region with the psos client
+// and records metrics and spans for every request
+func Login(ctx context.Context, region string, endpoint string, username string, password string) (ResellerClient, error) {
+	_, span := tracing.Start(ctx, fmt.Sprintf("%s Login", metrics.APIReseller), tracing.RegionKey.String(region))
+	client, err := psos.Login(endpoint, username, password)
+	err = apierror.Classify(err)
+	metrics.ObserveLogin(metrics.APIReseller, region, err)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return Observe(NewPsosClient(client), region), nil
+}
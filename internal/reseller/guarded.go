@@ -0,0 +1,137 @@
+package reseller
+
+import (
+	"context"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/throttle"
+)
+
+// guardedClient decorates a ResellerClient sending all requests through the rate limit and circuit breaker of the region
+type guardedClient struct {
+	next  ResellerClient
+	guard *throttle.Guard
+}
+
+// Guard decorates the client sending all requests through the guard of its region
+func Guard(next ResellerClient, guard *throttle.Guard) ResellerClient {
+	return &guardedClient{next: next, guard: guard}
+}
+
+// GetProjects lists all projects of the reseller
+func (c *guardedClient) GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error) {
+	var projects *[]openapi.ProjectCreatedResponse
+	err := c.guard.Do(ctx, func() (err error) {
+		projects, err = c.next.GetProjects(ctx)
+		return err
+	})
+	return projects, err
+}
+
+// CreateProject creates a project
+func (c *guardedClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	var created *openapi.ProjectCreatedResponse
+	err := c.guard.Do(ctx, func() (err error) {
+		created, err = c.next.CreateProject(ctx, project)
+		return err
+	})
+	return created, err
+}
+
+// UpdateProject updates the project with the given id
+func (c *guardedClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	var updated *openapi.ProjectCreatedResponse
+	err := c.guard.Do(ctx, func() (err error) {
+		updated, err = c.next.UpdateProject(ctx, id, project)
+		return err
+	})
+	return updated, err
+}
+
+// DeleteProject deletes the project with the given id
+func (c *guardedClient) DeleteProject(ctx context.Context, id string) error {
+	return c.guard.Do(ctx, func() error {
+		return c.next.DeleteProject(ctx, id)
+	})
+}
+
+// GetProjectQuota returns the quota of the project with the given id
+func (c *guardedClient) GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error) {
+	var quota *openapi.UpdateQuota
+	err := c.guard.Do(ctx, func() (err error) {
+		quota, err = c.next.GetProjectQuota(ctx, id)
+		return err
+	})
+	return quota, err
+}
+
+// UpdateProjectQuota updates the quota of the project with the given id
+func (c *guardedClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	var updated *openapi.UpdateQuota
+	err := c.guard.Do(ctx, func() (err error) {
+		updated, err = c.next.UpdateProjectQuota(ctx, id, quota)
+		return err
+	})
+	return updated, err
+}
+
+// GetUsers lists all users of the reseller
+func (c *guardedClient) GetUsers(ctx context.Context) (*[]openapi.CreatedOpenStackUser, error) {
+	var users *[]openapi.CreatedOpenStackUser
+	err := c.guard.Do(ctx, func() (err error) {
+		users, err = c.next.GetUsers(ctx)
+		return err
+	})
+	return users, err
+}
+
+// CreateUser creates a user
+func (c *guardedClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	var created *openapi.CreatedOpenStackUser
+	err := c.guard.Do(ctx, func() (err error) {
+		created, err = c.next.CreateUser(ctx, user)
+		return err
+	})
+	return created, err
+}
+
+// UpdateUser updates the user with the given id
+func (c *guardedClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	var updated *openapi.CreatedOpenStackUser
+	err := c.guard.Do(ctx, func() (err error) {
+		updated, err = c.next.UpdateUser(ctx, id, user)
+		return err
+	})
+	return updated, err
+}
+
+// DeleteUser deletes the user with the given id
+func (c *guardedClient) DeleteUser(ctx context.Context, id string) error {
+	return c.guard.Do(ctx, func() error {
+		return c.next.DeleteUser(ctx, id)
+	})
+}
+
+// GetUsersInProject lists the memberships of the project with the given id
+func (c *guardedClient) GetUsersInProject(ctx context.Context, projectId string) (*[]openapi.ProjectUserMembership, error) {
+	var memberships *[]openapi.ProjectUserMembership
+	err := c.guard.Do(ctx, func() (err error) {
+		memberships, err = c.next.GetUsersInProject(ctx, projectId)
+		return err
+	})
+	return memberships, err
+}
+
+// AddUserToProject makes the user a member of the project
+func (c *guardedClient) AddUserToProject(ctx context.Context, projectId string, userId string) error {
+	return c.guard.Do(ctx, func() error {
+		return c.next.AddUserToProject(ctx, projectId, userId)
+	})
+}
+
+// RemoveUserFromProject removes the membership of the user within the project
+func (c *guardedClient) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
+	return c.guard.Do(ctx, func() error {
+		return c.next.RemoveUserFromProject(ctx, projectId, userId)
+	})
+}
@@ -0,0 +1,377 @@
+package reseller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"github.com/pluscontainer/pco-reseller-operator/internal/metrics"
+)
+
+// Kinds of the inventory metrics
+const (
+	inventoryKindProject = "project"
+	inventoryKindUser    = "user"
+)
+
+var (
+	inventoryRefreshInterval = time.Minute
+
+	inventoriesMu sync.Mutex
+	inventories   = map[string]*Inventory{}
+)
+
+// SetInventoryRefreshInterval sets the age after which lookups list the projects or users of the region again
+func SetInventoryRefreshInterval(interval time.Duration) {
+	inventoriesMu.Lock()
+	defer inventoriesMu.Unlock()
+
+	inventoryRefreshInterval = interval
+}
+
+// Finder looks up projects and users without listing all of them
+type Finder interface {
+	// FindProject returns the project with the given name. The domain prepended by the reseller API may be omitted
+	FindProject(ctx context.Context, name string) (*openapi.ProjectCreatedResponse, bool, error)
+	// FindUser returns the user with the given name
+	FindUser(ctx context.Context, name string) (*openapi.CreatedOpenStackUser, bool, error)
+}
+
+// Inventory caches the projects and users of the reseller account of a region, indexed by name and id.
+// It is shared by all clients of the region
+type Inventory struct {
+	region  string
+	account string
+
+	//refreshMu serializes the listings, so concurrent lookups of a stale inventory list only once
+	refreshMu sync.Mutex
+	mu        sync.Mutex
+	projects  *index[openapi.ProjectCreatedResponse]
+	users     *index[openapi.CreatedOpenStackUser]
+}
+
+// InventoryFor returns the inventory of the region. It starts empty again if the region switched to another reseller account
+func InventoryFor(region string, account string) *Inventory {
+	inventoriesMu.Lock()
+	defer inventoriesMu.Unlock()
+
+	inventory, ok := inventories[region]
+	if !ok || inventory.account != account {
+		inventory = &Inventory{
+			region:   region,
+			account:  account,
+			projects: newIndex(projectId, projectNames),
+			users:    newIndex(userId, userNames),
+		}
+		inventories[region] = inventory
+	}
+
+	return inventory
+}
+
+// ForgetInventory drops the inventory of a deleted region
+func ForgetInventory(region string) {
+	inventoriesMu.Lock()
+	defer inventoriesMu.Unlock()
+
+	delete(inventories, region)
+	metrics.DeleteInventory(region)
+}
+
+func refreshInterval() time.Duration {
+	inventoriesMu.Lock()
+	defer inventoriesMu.Unlock()
+
+	return inventoryRefreshInterval
+}
+
+// cachedClient decorates a ResellerClient looking up projects and users within the inventory of the region.
+// Listings refill the inventory and writes update it
+type cachedClient struct {
+	ResellerClient
+	inventory *Inventory
+}
+
+// Cache decorates the client with the inventory of its region
+func Cache(next ResellerClient, inventory *Inventory) ResellerClient {
+	return &cachedClient{ResellerClient: next, inventory: inventory}
+}
+
+// GetProjects lists all projects of the reseller and refills the inventory
+func (c *cachedClient) GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error) {
+	projects, err := c.ResellerClient.GetProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.inventory.mu.Lock()
+	c.inventory.projects.fill(*projects, time.Now())
+	c.inventory.mu.Unlock()
+	metrics.SetInventoryRefreshed(c.inventory.region, inventoryKindProject, time.Now())
+
+	return projects, nil
+}
+
+// CreateProject creates a project and adds it to the inventory
+func (c *cachedClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	created, err := c.ResellerClient.CreateProject(ctx, project)
+	c.inventory.updateProject(created, "", err)
+	return created, err
+}
+
+// UpdateProject updates the project with the given id within the API and the inventory
+func (c *cachedClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	updated, err := c.ResellerClient.UpdateProject(ctx, id, project)
+	c.inventory.updateProject(updated, id, err)
+	return updated, err
+}
+
+// DeleteProject deletes the project with the given id and removes it from the inventory
+func (c *cachedClient) DeleteProject(ctx context.Context, id string) error {
+	err := c.ResellerClient.DeleteProject(ctx, id)
+	c.inventory.updateProject(nil, id, err)
+	return err
+}
+
+// GetUsers lists all users of the reseller and refills the inventory
+func (c *cachedClient) GetUsers(ctx context.Context) (*[]openapi.CreatedOpenStackUser, error) {
+	users, err := c.ResellerClient.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.inventory.mu.Lock()
+	c.inventory.users.fill(*users, time.Now())
+	c.inventory.mu.Unlock()
+	metrics.SetInventoryRefreshed(c.inventory.region, inventoryKindUser, time.Now())
+
+	return users, nil
+}
+
+// CreateUser creates a user and adds it to the inventory
+func (c *cachedClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	created, err := c.ResellerClient.CreateUser(ctx, user)
+	c.inventory.updateUser(created, "", err)
+	return created, err
+}
+
+// UpdateUser updates the user with the given id within the API and the inventory
+func (c *cachedClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	updated, err := c.ResellerClient.UpdateUser(ctx, id, user)
+	c.inventory.updateUser(updated, id, err)
+	return updated, err
+}
+
+// DeleteUser deletes the user with the given id and removes it from the inventory
+func (c *cachedClient) DeleteUser(ctx context.Context, id string) error {
+	err := c.ResellerClient.DeleteUser(ctx, id)
+	c.inventory.updateUser(nil, id, err)
+	return err
+}
+
+// FindProject looks up the project within the inventory, listing the projects first if the inventory is stale
+func (c *cachedClient) FindProject(ctx context.Context, name string) (*openapi.ProjectCreatedResponse, bool, error) {
+	refreshed, err := c.inventory.refresh(ctx, c.inventory.projects, func(ctx context.Context) error {
+		_, err := c.GetProjects(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	metrics.ObserveInventoryLookup(c.inventory.region, inventoryKindProject, !refreshed)
+
+	c.inventory.mu.Lock()
+	defer c.inventory.mu.Unlock()
+
+	project, ok := c.inventory.projects.find(name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &project, true, nil
+}
+
+// FindUser looks up the user within the inventory, listing the users first if the inventory is stale
+func (c *cachedClient) FindUser(ctx context.Context, name string) (*openapi.CreatedOpenStackUser, bool, error) {
+	refreshed, err := c.inventory.refresh(ctx, c.inventory.users, func(ctx context.Context) error {
+		_, err := c.GetUsers(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	metrics.ObserveInventoryLookup(c.inventory.region, inventoryKindUser, !refreshed)
+
+	c.inventory.mu.Lock()
+	defer c.inventory.mu.Unlock()
+
+	user, ok := c.inventory.users.find(name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &user, true, nil
+}
+
+// refresh lists the items of the index again if it is stale. It returns whether it listed
+func (i *Inventory) refresh(ctx context.Context, idx interface{ stale(time.Duration) bool }, list func(context.Context) error) (bool, error) {
+	interval := refreshInterval()
+
+	i.mu.Lock()
+	stale := idx.stale(interval)
+	i.mu.Unlock()
+	if !stale {
+		return false, nil
+	}
+
+	i.refreshMu.Lock()
+	defer i.refreshMu.Unlock()
+
+	//Another lookup may have listed meanwhile
+	i.mu.Lock()
+	stale = idx.stale(interval)
+	i.mu.Unlock()
+	if !stale {
+		return false, nil
+	}
+
+	return true, list(ctx)
+}
+
+// Project returns the cached project with the given id without listing the projects
+func (i *Inventory) Project(id string) (*openapi.ProjectCreatedResponse, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	project, ok := i.projects.byId[id]
+	if !ok {
+		return nil, false
+	}
+
+	return &project, true
+}
+
+// User returns the cached user with the given id without listing the users
+func (i *Inventory) User(id string) (*openapi.CreatedOpenStackUser, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	user, ok := i.users.byId[id]
+	if !ok {
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// updateProject applies the outcome of a write to the project with the given id
+func (i *Inventory) updateProject(project *openapi.ProjectCreatedResponse, id string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.projects.update(project, id, err)
+}
+
+// updateUser applies the outcome of a write to the user with the given id
+func (i *Inventory) updateUser(user *openapi.CreatedOpenStackUser, id string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.users.update(user, id, err)
+}
+
+func projectId(project openapi.ProjectCreatedResponse) string {
+	return project.Id
+}
+
+// projectNames indexes projects by their name with and without the domain prepended by the reseller API
+func projectNames(project openapi.ProjectCreatedResponse) []string {
+	names := []string{project.Name}
+	if _, name, ok := strings.Cut(project.Name, "-"); ok {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func userId(user openapi.CreatedOpenStackUser) string {
+	return user.Id
+}
+
+func userNames(user openapi.CreatedOpenStackUser) []string {
+	return []string{user.Name}
+}
+
+// index stores items by id and name
+type index[T any] struct {
+	id          func(T) string
+	names       func(T) []string
+	refreshedAt time.Time
+	byId        map[string]T
+	byName      map[string]string
+}
+
+func newIndex[T any](id func(T) string, names func(T) []string) *index[T] {
+	return &index[T]{id: id, names: names, byId: map[string]T{}, byName: map[string]string{}}
+}
+
+// stale returns true if the index wasn't filled within the interval
+func (i *index[T]) stale(interval time.Duration) bool {
+	return i.refreshedAt.IsZero() || time.Since(i.refreshedAt) > interval
+}
+
+// fill replaces all items
+func (i *index[T]) fill(items []T, at time.Time) {
+	i.byId = map[string]T{}
+	i.byName = map[string]string{}
+	for _, k := range items {
+		i.put(k)
+	}
+
+	i.refreshedAt = at
+}
+
+func (i *index[T]) put(item T) {
+	id := i.id(item)
+	i.remove(id)
+
+	i.byId[id] = item
+	for _, k := range i.names(item) {
+		i.byName[k] = id
+	}
+}
+
+func (i *index[T]) remove(id string) {
+	item, ok := i.byId[id]
+	if !ok {
+		return
+	}
+
+	delete(i.byId, id)
+	for _, k := range i.names(item) {
+		if i.byName[k] == id {
+			delete(i.byName, k)
+		}
+	}
+}
+
+func (i *index[T]) find(name string) (T, bool) {
+	item, ok := i.byId[i.byName[name]]
+	return item, ok
+}
+
+// update applies the outcome of a write, a nil item is a deletion. Written items are stored, deleted items and items which are gone
+// are removed and conflicts mark the index stale, as the API knows something it doesn't
+func (i *index[T]) update(item *T, id string, err error) {
+	switch {
+	case err == nil && item != nil:
+		i.put(*item)
+	case err == nil, apierror.ClassOf(err) == apierror.NotFound:
+		i.remove(id)
+	case apierror.ClassOf(err) == apierror.Conflict:
+		i.refreshedAt = time.Time{}
+	}
+}
@@ -0,0 +1,101 @@
+package reseller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+)
+
+// listingClient serves a fixed list of projects and counts the listings
+type listingClient struct {
+	ResellerClient
+	projects []openapi.ProjectCreatedResponse
+	listings int
+}
+
+func (c *listingClient) GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error) {
+	c.listings++
+	projects := append([]openapi.ProjectCreatedResponse{}, c.projects...)
+	return &projects, nil
+}
+
+func (c *listingClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	for _, k := range c.projects {
+		if k.Name == "domain-"+project.Name {
+			return nil, apierror.New(apierror.Conflict, "project %s exists", project.Name)
+		}
+	}
+
+	created := openapi.ProjectCreatedResponse{Id: project.Name, Name: "domain-" + project.Name}
+	return &created, nil
+}
+
+func (c *listingClient) DeleteProject(ctx context.Context, id string) error {
+	return nil
+}
+
+func TestInventoryProjects(t *testing.T) {
+	ctx := context.Background()
+	next := &listingClient{projects: []openapi.ProjectCreatedResponse{{Id: "1", Name: "domain-abc-ns-first"}}}
+	client := Cache(next, InventoryFor("inventory-projects", "account")).(*cachedClient)
+
+	for _, name := range []string{"abc-ns-first", "domain-abc-ns-first"} {
+		project, found, err := client.FindProject(ctx, name)
+		if err != nil || !found || project.Id != "1" {
+			t.Fatalf("expected to find project by %s, got %v %v %v", name, project, found, err)
+		}
+	}
+
+	if _, found, _ := client.FindProject(ctx, "bc-ns-first"); found {
+		t.Error("expected names to match exactly")
+	}
+
+	if next.listings != 1 {
+		t.Errorf("expected projects to be listed once, got %d listings", next.listings)
+	}
+
+	//Writes update the inventory without listing again
+	if _, err := client.CreateProject(ctx, openapi.ProjectCreate{Name: "abc-ns-second"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := client.FindProject(ctx, "abc-ns-second"); !found {
+		t.Error("expected created project to be found")
+	}
+
+	if err := client.DeleteProject(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := client.FindProject(ctx, "abc-ns-first"); found {
+		t.Error("expected deleted project to be gone")
+	}
+
+	if next.listings != 1 {
+		t.Errorf("expected writes not to list projects, got %d listings", next.listings)
+	}
+
+	//Conflicts reveal projects the inventory doesn't know yet
+	next.projects = append(next.projects, openapi.ProjectCreatedResponse{Id: "3", Name: "domain-abc-ns-third"})
+	if _, err := client.CreateProject(ctx, openapi.ProjectCreate{Name: "abc-ns-third"}); apierror.ClassOf(err) != apierror.Conflict {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if project, found, _ := client.FindProject(ctx, "abc-ns-third"); !found || project.Id != "3" {
+		t.Errorf("expected conflicting project to be found after listing again, got %v", project)
+	}
+
+	if next.listings != 2 {
+		t.Errorf("expected conflict to list projects again, got %d listings", next.listings)
+	}
+}
+
+func TestInventoryAccount(t *testing.T) {
+	first := InventoryFor("inventory-account", "first")
+	if InventoryFor("inventory-account", "first") != first {
+		t.Error("expected inventory to be shared")
+	}
+
+	if InventoryFor("inventory-account", "second") == first {
+		t.Error("expected inventory to start empty for another account")
+	}
+}
@@ -0,0 +1,139 @@
+package reseller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/metrics"
+	"github.com/pluscontainer/pco-reseller-operator/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// observedClient decorates a ResellerClient recording metrics and spans for every request
+type observedClient struct {
+	next   ResellerClient
+	region string
+}
+
+// Observe decorates the client of the region recording metrics and spans for every request
+func Observe(next ResellerClient, region string) ResellerClient {
+	return &observedClient{next: next, region: region}
+}
+
+// observe starts a span for the operation and returns the function recording its outcome
+func (c *observedClient) observe(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	attributes = append(attributes, tracing.RegionKey.String(c.region), tracing.OperationKey.String(operation))
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("%s %s", metrics.APIReseller, operation), attributes...)
+
+	return ctx, func(err error) {
+		metrics.ObserveRequest(metrics.APIReseller, c.region, operation, start, err)
+		tracing.End(span, err)
+	}
+}
+
+// GetProjects lists all projects of the reseller
+func (c *observedClient) GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error) {
+	ctx, done := c.observe(ctx, "GetProjects")
+	projects, err := c.next.GetProjects(ctx)
+	done(err)
+	return projects, err
+}
+
+// CreateProject creates a project
+func (c *observedClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	ctx, done := c.observe(ctx, "CreateProject")
+	created, err := c.next.CreateProject(ctx, project)
+	done(err)
+	return created, err
+}
+
+// UpdateProject updates the project with the given id
+func (c *observedClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	ctx, done := c.observe(ctx, "UpdateProject", tracing.OpenStackProjectIDKey.String(id))
+	updated, err := c.next.UpdateProject(ctx, id, project)
+	done(err)
+	return updated, err
+}
+
+// DeleteProject deletes the project with the given id
+func (c *observedClient) DeleteProject(ctx context.Context, id string) error {
+	ctx, done := c.observe(ctx, "DeleteProject", tracing.OpenStackProjectIDKey.String(id))
+	err := c.next.DeleteProject(ctx, id)
+	done(err)
+	return err
+}
+
+// GetProjectQuota returns the quota of the project with the given id
+func (c *observedClient) GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error) {
+	ctx, done := c.observe(ctx, "GetProjectQuota", tracing.OpenStackProjectIDKey.String(id))
+	quota, err := c.next.GetProjectQuota(ctx, id)
+	done(err)
+	return quota, err
+}
+
+// UpdateProjectQuota updates the quota of the project with the given id
+func (c *observedClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	ctx, done := c.observe(ctx, "UpdateProjectQuota", tracing.OpenStackProjectIDKey.String(id))
+	updated, err := c.next.UpdateProjectQuota(ctx, id, quota)
+	done(err)
+	return updated, err
+}
+
+// GetUsers lists all users of the reseller
+func (c *observedClient) GetUsers(ctx context.Context) (*[]openapi.CreatedOpenStackUser, error) {
+	ctx, done := c.observe(ctx, "GetUsers")
+	users, err := c.next.GetUsers(ctx)
+	done(err)
+	return users, err
+}
+
+// CreateUser creates a user
+func (c *observedClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	ctx, done := c.observe(ctx, "CreateUser")
+	created, err := c.next.CreateUser(ctx, user)
+	done(err)
+	return created, err
+}
+
+// UpdateUser updates the user with the given id
+func (c *observedClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	ctx, done := c.observe(ctx, "UpdateUser", tracing.OpenStackUserIDKey.String(id))
+	updated, err := c.next.UpdateUser(ctx, id, user)
+	done(err)
+	return updated, err
+}
+
+// DeleteUser deletes the user with the given id
+func (c *observedClient) DeleteUser(ctx context.Context, id string) error {
+	ctx, done := c.observe(ctx, "DeleteUser", tracing.OpenStackUserIDKey.String(id))
+	err := c.next.DeleteUser(ctx, id)
+	done(err)
+	return err
+}
+
+// GetUsersInProject lists the memberships of the project with the given id
+func (c *observedClient) GetUsersInProject(ctx context.Context, projectId string) (*[]openapi.ProjectUserMembership, error) {
+	ctx, done := c.observe(ctx, "GetUsersInProject", tracing.OpenStackProjectIDKey.String(projectId))
+	memberships, err := c.next.GetUsersInProject(ctx, projectId)
+	done(err)
+	return memberships, err
+}
+
+// AddUserToProject makes the user a member of the project
+func (c *observedClient) AddUserToProject(ctx context.Context, projectId string, userId string) error {
+	ctx, done := c.observe(ctx, "AddUserToProject", tracing.OpenStackProjectIDKey.String(projectId), tracing.OpenStackUserIDKey.String(userId))
+	err := c.next.AddUserToProject(ctx, projectId, userId)
+	done(err)
+	return err
+}
+
+// RemoveUserFromProject removes the membership of the user within the project
+func (c *observedClient) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
+	ctx, done := c.observe(ctx, "RemoveUserFromProject", tracing.OpenStackProjectIDKey.String(projectId), tracing.OpenStackUserIDKey.String(userId))
+	err := c.next.RemoveUserFromProject(ctx, projectId, userId)
+	done(err)
+	return err
+}
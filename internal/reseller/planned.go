@@ -0,0 +1,193 @@
+package reseller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+)
+
+// plannedClient decorates a ResellerClient recording writes within the plan of the reconcile instead of sending them.
+// Planned writes succeed with the object they would create or update, created objects get a placeholder id.
+// Without an active plan all requests are passed on
+type plannedClient struct {
+	ResellerClient
+}
+
+// plannedFinder keeps the inventory lookups of the decorated client
+type plannedFinder struct {
+	*plannedClient
+	Finder
+}
+
+// Plan decorates the client recording writes within the plan of the reconcile, while it is active
+func Plan(next ResellerClient) ResellerClient {
+	client := &plannedClient{ResellerClient: next}
+	if finder, ok := next.(Finder); ok {
+		return &plannedFinder{plannedClient: client, Finder: finder}
+	}
+
+	return client
+}
+
+// CreateProject plans the creation of the project
+func (c *plannedClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.CreateProject(ctx, project)
+	}
+
+	p.Record("Create project %s", project.Name)
+	return &openapi.ProjectCreatedResponse{
+		Id:          plan.Placeholder(project.Name),
+		Name:        project.Name,
+		Description: project.Description,
+		Enabled:     project.Enabled,
+	}, nil
+}
+
+// UpdateProject plans the update of the project with the given id
+func (c *plannedClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.UpdateProject(ctx, id, project)
+	}
+
+	p.Record("Update project %s: %s", id, describe(project))
+
+	updated := &openapi.ProjectCreatedResponse{Id: id, Enabled: project.Enabled}
+	if project.Name != nil {
+		updated.Name = *project.Name
+	}
+	if project.Description != nil {
+		updated.Description = *project.Description
+	}
+
+	return updated, nil
+}
+
+// DeleteProject plans the deletion of the project with the given id
+func (c *plannedClient) DeleteProject(ctx context.Context, id string) error {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.DeleteProject(ctx, id)
+	}
+
+	p.Record("Delete project %s", id)
+	return nil
+}
+
+// GetProjectQuota returns an empty quota for planned projects
+func (c *plannedClient) GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error) {
+	if plan.IsPlaceholder(id) {
+		return &openapi.UpdateQuota{}, nil
+	}
+
+	return c.ResellerClient.GetProjectQuota(ctx, id)
+}
+
+// UpdateProjectQuota plans the update of the quota of the project with the given id
+func (c *plannedClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.UpdateProjectQuota(ctx, id, quota)
+	}
+
+	p.Record("Update quota of project %s: %s", id, describe(quota))
+	return &quota, nil
+}
+
+// CreateUser plans the creation of the user
+func (c *plannedClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.CreateUser(ctx, user)
+	}
+
+	p.Record("Create user %s", user.Name)
+	return &openapi.CreatedOpenStackUser{
+		Id:             plan.Placeholder(user.Name),
+		Name:           user.Name,
+		Description:    &user.Description,
+		Enabled:        user.Enabled,
+		DefaultProject: user.DefaultProject,
+	}, nil
+}
+
+// UpdateUser plans the update of the user with the given id. Passwords are never recorded
+func (c *plannedClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.UpdateUser(ctx, id, user)
+	}
+
+	redacted := user
+	if redacted.Password != nil {
+		password := "<redacted>"
+		redacted.Password = &password
+	}
+	p.Record("Update user %s: %s", id, describe(redacted))
+
+	updated := &openapi.CreatedOpenStackUser{Id: id, Description: user.Description, Enabled: user.Enabled, DefaultProject: user.DefaultProject}
+	if user.Name != nil {
+		updated.Name = *user.Name
+	}
+
+	return updated, nil
+}
+
+// DeleteUser plans the deletion of the user with the given id
+func (c *plannedClient) DeleteUser(ctx context.Context, id string) error {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.DeleteUser(ctx, id)
+	}
+
+	p.Record("Delete user %s", id)
+	return nil
+}
+
+// GetUsersInProject returns no memberships for planned projects
+func (c *plannedClient) GetUsersInProject(ctx context.Context, projectId string) (*[]openapi.ProjectUserMembership, error) {
+	if plan.IsPlaceholder(projectId) {
+		return &[]openapi.ProjectUserMembership{}, nil
+	}
+
+	return c.ResellerClient.GetUsersInProject(ctx, projectId)
+}
+
+// AddUserToProject plans the membership of the user within the project
+func (c *plannedClient) AddUserToProject(ctx context.Context, projectId string, userId string) error {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.AddUserToProject(ctx, projectId, userId)
+	}
+
+	p.Record("Add user %s to project %s", userId, projectId)
+	return nil
+}
+
+// RemoveUserFromProject plans the removal of the membership of the user within the project
+func (c *plannedClient) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return c.ResellerClient.RemoveUserFromProject(ctx, projectId, userId)
+	}
+
+	p.Record("Remove user %s from project %s", userId, projectId)
+	return nil
+}
+
+// describe renders the request body of a planned write
+func describe(v any) string {
+	body := &strings.Builder{}
+	encoder := json.NewEncoder(body)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err.Error()
+	}
+
+	return strings.TrimSpace(body.String())
+}
@@ -0,0 +1,48 @@
+package reseller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-operator/internal/plan"
+)
+
+func TestPlannedWrites(t *testing.T) {
+	next := &listingClient{}
+	client := Plan(Cache(next, InventoryFor("planned-writes", "account")))
+
+	if _, ok := client.(Finder); !ok {
+		t.Fatal("expected inventory lookups to be kept")
+	}
+
+	ctx := plan.NewContext(context.Background())
+	plan.Activate(ctx)
+
+	created, err := client.CreateProject(ctx, openapi.ProjectCreate{Name: "abc-ns-planned"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.IsPlaceholder(created.Id) {
+		t.Errorf("expected planned project to get a placeholder id, got %s", created.Id)
+	}
+
+	quota, err := client.GetProjectQuota(ctx, created.Id)
+	if err != nil || *quota != (openapi.UpdateQuota{}) {
+		t.Errorf("expected planned project to have an empty quota, got %v %v", quota, err)
+	}
+
+	password := "secret"
+	if _, err := client.UpdateUser(ctx, "1", openapi.UpdateOpenStackUser{Password: &password}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := plan.FromContext(ctx).Changes()
+	if len(changes) != 2 || changes[0] != "Create project abc-ns-planned" || changes[1] != `Update user 1: {"password":"<redacted>"}` {
+		t.Errorf("unexpected planned changes %v", changes)
+	}
+
+	if _, found, _ := client.(Finder).FindProject(ctx, "abc-ns-planned"); found {
+		t.Error("expected planned project not to be added to the inventory")
+	}
+}
@@ -0,0 +1,93 @@
+package reseller
+
+import (
+	"context"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+)
+
+// psosClient adapts the psos client to ResellerClient
+type psosClient struct {
+	client *psos.PsOpenstackClient
+}
+
+// NewPsosClient returns a ResellerClient issuing the requests with the logged in psos client
+func NewPsosClient(client *psos.PsOpenstackClient) ResellerClient {
+	return &psosClient{client: client}
+}
+
+// GetProjects lists all projects of the reseller
+func (c *psosClient) GetProjects(ctx context.Context) (*[]openapi.ProjectCreatedResponse, error) {
+	projects, err := c.client.GetProjects(ctx)
+	return projects, apierror.Classify(err)
+}
+
+// CreateProject creates a project
+func (c *psosClient) CreateProject(ctx context.Context, project openapi.ProjectCreate) (*openapi.ProjectCreatedResponse, error) {
+	created, err := c.client.CreateProject(ctx, project)
+	return created, apierror.Classify(err)
+}
+
+// UpdateProject updates the project with the given id
+func (c *psosClient) UpdateProject(ctx context.Context, id string, project openapi.ProjectUpdate) (*openapi.ProjectCreatedResponse, error) {
+	updated, err := c.client.UpdateProject(ctx, id, project)
+	return updated, apierror.Classify(err)
+}
+
+// DeleteProject deletes the project with the given id
+func (c *psosClient) DeleteProject(ctx context.Context, id string) error {
+	return apierror.Classify(c.client.DeleteProject(ctx, id))
+}
+
+// GetProjectQuota returns the quota of the project with the given id
+func (c *psosClient) GetProjectQuota(ctx context.Context, id string) (*openapi.UpdateQuota, error) {
+	quota, err := c.client.GetProjectQuota(ctx, id)
+	return quota, apierror.Classify(err)
+}
+
+// UpdateProjectQuota updates the quota of the project with the given id
+func (c *psosClient) UpdateProjectQuota(ctx context.Context, id string, quota openapi.UpdateQuota) (*openapi.UpdateQuota, error) {
+	updated, err := c.client.UpdateProjectQuota(ctx, id, quota)
+	return updated, apierror.Classify(err)
+}
+
+// GetUsers lists all users of the reseller
+func (c *psosClient) GetUsers(ctx context.Context) (*[]openapi.CreatedOpenStackUser, error) {
+	users, err := c.client.GetUsers(ctx)
+	return users, apierror.Classify(err)
+}
+
+// CreateUser creates a user
+func (c *psosClient) CreateUser(ctx context.Context, user openapi.CreateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	created, err := c.client.CreateUser(ctx, user)
+	return created, apierror.Classify(err)
+}
+
+// UpdateUser updates the user with the given id
+func (c *psosClient) UpdateUser(ctx context.Context, id string, user openapi.UpdateOpenStackUser) (*openapi.CreatedOpenStackUser, error) {
+	updated, err := c.client.UpdateUser(ctx, id, user)
+	return updated, apierror.Classify(err)
+}
+
+// DeleteUser deletes the user with the given id
+func (c *psosClient) DeleteUser(ctx context.Context, id string) error {
+	return apierror.Classify(c.client.DeleteUser(ctx, id))
+}
+
+// GetUsersInProject lists the memberships of the project with the given id
+func (c *psosClient) GetUsersInProject(ctx context.Context, projectId string) (*[]openapi.ProjectUserMembership, error) {
+	memberships, err := c.client.GetUsersInProject(ctx, projectId)
+	return memberships, apierror.Classify(err)
+}
+
+// AddUserToProject makes the user a member of the project
+func (c *psosClient) AddUserToProject(ctx context.Context, projectId string, userId string) error {
+	return apierror.Classify(c.client.AddUserToProject(ctx, projectId, userId))
+}
+
+// RemoveUserFromProject removes the membership of the user within the project
+func (c *psosClient) RemoveUserFromProject(ctx context.Context, projectId string, userId string) error {
+	return apierror.Classify(c.client.RemoveUserFromProject(ctx, projectId, userId))
+}
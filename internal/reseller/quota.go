@@ -0,0 +1,40 @@
+package reseller
+
+import (
+	"reflect"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+)
+
+// QuotaMatches checks if the current quota of a project already satisfies the desired one.
+// Sections and optional limits left unset within the desired quota aren't compared, as the reseller API reports all of them
+func QuotaMatches(desired openapi.UpdateQuota, current openapi.UpdateQuota) bool {
+	return quotaSectionMatches(desired.Compute, current.Compute) &&
+		quotaSectionMatches(desired.Network, current.Network) &&
+		quotaSectionMatches(desired.Volume, current.Volume)
+}
+
+func quotaSectionMatches[T any](desired *T, current *T) bool {
+	if desired == nil {
+		return true
+	}
+
+	if current == nil {
+		return false
+	}
+
+	desiredValue, currentValue := reflect.ValueOf(desired).Elem(), reflect.ValueOf(current).Elem()
+	for i := range desiredValue.NumField() {
+		field := desiredValue.Field(i)
+		if field.Kind() == reflect.Pointer && field.IsNil() {
+			continue
+		}
+
+		//Optional limits are compared by value, not by address
+		if !reflect.DeepEqual(field.Interface(), currentValue.Field(i).Interface()) {
+			return false
+		}
+	}
+
+	return true
+}
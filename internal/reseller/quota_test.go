@@ -0,0 +1,35 @@
+package reseller
+
+import (
+	"testing"
+
+	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
+)
+
+func TestQuotaMatches(t *testing.T) {
+	cores, sameCores, otherCores, ram := 4, 4, 8, 2048
+
+	tests := []struct {
+		name    string
+		desired openapi.UpdateQuota
+		current openapi.UpdateQuota
+		want    bool
+	}{
+		{"no quota", openapi.UpdateQuota{}, openapi.UpdateQuota{}, true},
+		{"unmanaged sections", openapi.UpdateQuota{}, openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Instances: 2}}, true},
+		{"equal sections at other addresses", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &cores, Instances: 2}}, openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &sameCores, Instances: 2}}, true},
+		{"unset optional limit", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Instances: 2}}, openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &cores, Ram: &ram, Instances: 2}}, true},
+		{"changed optional limit", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &otherCores}}, openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &cores}}, false},
+		{"missing optional limit", openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{Cores: &cores}}, openapi.UpdateQuota{Compute: &openapi.ComputeQuotas{}}, false},
+		{"changed limit", openapi.UpdateQuota{Volume: &openapi.VolumeQuotas{Volumes: 3}}, openapi.UpdateQuota{Volume: &openapi.VolumeQuotas{Volumes: 5}}, false},
+		{"missing section", openapi.UpdateQuota{Network: &openapi.NetworkQuotas{Router: 1}}, openapi.UpdateQuota{}, false},
+	}
+
+	for _, k := range tests {
+		t.Run(k.name, func(t *testing.T) {
+			if got := QuotaMatches(k.desired, k.current); got != k.want {
+				t.Errorf("QuotaMatches() = %t, want %t", got, k.want)
+			}
+		})
+	}
+}
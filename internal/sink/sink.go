@@ -0,0 +1,52 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// vaultTokenKey is the key of the Vault token within the referenced Secret
+const vaultTokenKey = "token"
+
+// Sink delivers generated credentials to a store outside of the Kubernetes cluster
+type Sink interface {
+	// Write stores the given credentials, replacing the existing ones
+	Write(ctx context.Context, data map[string]string) error
+	// Delete removes the credentials from the store, it succeeds if they are already gone
+	Delete(ctx context.Context) error
+}
+
+// FromTemplate returns the external sinks configured within the given template of a resource within namespace
+func FromTemplate(ctx context.Context, c client.Client, template *v1beta1.SecretTemplate, namespace string) ([]Sink, error) {
+	sinks := make([]Sink, 0)
+	if template == nil {
+		return sinks, nil
+	}
+
+	if template.Vault != nil {
+		//The token is only resolved within the namespace of the resource, so it can't be used to read Secrets of other namespaces
+		tokenRef := template.Vault.TokenSecretRef
+		if tokenRef.Namespace != "" && tokenRef.Namespace != namespace {
+			return nil, fmt.Errorf("vault token secret %s/%s is not within namespace %s", tokenRef.Namespace, tokenRef.Name, namespace)
+		}
+
+		tokenSecret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tokenRef.Name}, tokenSecret); err != nil {
+			return nil, err
+		}
+
+		token, ok := tokenSecret.Data[vaultTokenKey]
+		if !ok {
+			return nil, fmt.Errorf("secret %s/%s has no key %s", tokenSecret.Namespace, tokenSecret.Name, vaultTokenKey)
+		}
+
+		sinks = append(sinks, NewVaultKV(template.Vault.Address, template.Vault.Mount, template.Vault.Path, string(token)))
+	}
+
+	return sinks, nil
+}
@@ -0,0 +1,47 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pluscontainer/pco-reseller-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFromTemplateResolvesTokenWithinNamespace(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vault-token"},
+			Data:       map[string][]byte{vaultTokenKey: []byte("tenant-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "operator", Name: "vault-token"},
+			Data:       map[string][]byte{vaultTokenKey: []byte("operator-token")},
+		},
+	).Build()
+
+	template := func(tokenNamespace string) *v1beta1.SecretTemplate {
+		return &v1beta1.SecretTemplate{Vault: &v1beta1.VaultSink{
+			Address:        "http://vault:8200",
+			Path:           "credentials",
+			TokenSecretRef: v1beta1.SecretRef{Name: "vault-token", Namespace: tokenNamespace},
+		}}
+	}
+
+	for _, tokenNamespace := range []string{"", "tenant"} {
+		sinks, err := FromTemplate(ctx, c, template(tokenNamespace), "tenant")
+		if err != nil {
+			t.Fatalf("token namespace %q: %v", tokenNamespace, err)
+		}
+		if len(sinks) != 1 || sinks[0].(*VaultKV).token != "tenant-token" {
+			t.Errorf("token namespace %q: expected the token of the namespace of the resource", tokenNamespace)
+		}
+	}
+
+	if _, err := FromTemplate(ctx, c, template("operator"), "tenant"); err == nil {
+		t.Error("expected a token of another namespace to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultVaultMount = "secret"
+
+// VaultKV stores credentials within a HashiCorp Vault KV v2 secrets engine
+type VaultKV struct {
+	address string
+	mount   string
+	path    string
+	token   string
+
+	httpClient *http.Client
+}
+
+var _ Sink = &VaultKV{}
+
+// NewVaultKV returns a sink for the given path within the KV v2 secrets engine mounted at mount
+func NewVaultKV(address string, mount string, path string, token string) *VaultKV {
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+
+	return &VaultKV{
+		address:    strings.TrimSuffix(address, "/"),
+		mount:      strings.Trim(mount, "/"),
+		path:       strings.Trim(path, "/"),
+		token:      token,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// Write stores the credentials as a new version of the secret
+func (v *VaultKV) Write(ctx context.Context, data map[string]string) error {
+	body, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return err
+	}
+
+	return v.do(ctx, http.MethodPost, "data", body)
+}
+
+// Delete removes all versions and the metadata of the secret
+func (v *VaultKV) Delete(ctx context.Context) error {
+	return v.do(ctx, http.MethodDelete, "metadata", nil)
+}
+
+func (v *VaultKV) do(ctx context.Context, method string, endpoint string, body []byte) error {
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", v.address, v.mount, endpoint, v.path)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	//Deleting a secret which doesn't exist is fine
+	if method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("vault returned %s for %s %s: %s", resp.Status, method, url, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// TestVaultKV runs against a Vault dev server, e.g. started with
+// vault server -dev -dev-root-token-id=root and VAULT_ADDR=http://127.0.0.1:8200 VAULT_TOKEN=root
+func TestVaultKV(t *testing.T) {
+	address := os.Getenv("VAULT_ADDR")
+	token := os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN must be set to run against a Vault dev server")
+	}
+
+	ctx := context.Background()
+	path := fmt.Sprintf("pco-reseller-operator-test/%s", t.Name())
+	vault := NewVaultKV(address, "", path, token)
+
+	data := map[string]string{"username": "user", "password": "secret"}
+	if err := vault.Write(ctx, data); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	stored, status := readVaultKV(t, address, path, token)
+	if status != http.StatusOK {
+		t.Fatalf("expected stored secret, got status %d", status)
+	}
+	for k, v := range data {
+		if stored[k] != v {
+			t.Errorf("expected %s to be %q, got %q", k, v, stored[k])
+		}
+	}
+
+	if err := vault.Delete(ctx); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, status := readVaultKV(t, address, path, token); status != http.StatusNotFound {
+		t.Fatalf("expected deleted secret, got status %d", status)
+	}
+
+	//Deleting again succeeds
+	if err := vault.Delete(ctx); err != nil {
+		t.Fatalf("second delete failed: %v", err)
+	}
+}
+
+func readVaultKV(t *testing.T, address string, path string, token string) (map[string]string, int) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s/data/%s", address, defaultVaultMount, path), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode
+	}
+
+	body := struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body.Data.Data, resp.StatusCode
+}
@@ -0,0 +1,291 @@
+// Package throttle limits the rate of requests to the APIs of a region and stops sending them while the APIs are failing
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+	"golang.org/x/time/rate"
+)
+
+// trialRetryAfter is the delay for requests arriving while the circuit breaker waits for the outcome of its trial request
+const trialRetryAfter = time.Second
+
+// Options configure the rate limit and circuit breaker of a region
+type Options struct {
+	// RequestsPerSecond is the sustained rate of requests. Zero disables the rate limit
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent at once
+	Burst int
+	// MaxWait is how long a request waits for the rate limit before it fails as throttled
+	MaxWait time.Duration
+	// FailureThreshold is the number of consecutive failed requests opening the circuit. Zero disables the circuit breaker
+	FailureThreshold int
+	// OpenDuration is how long no requests are sent once the circuit opened
+	OpenDuration time.Duration
+}
+
+// State is a snapshot of a Guard
+type State struct {
+	// ThrottledUntil is the time the rate limit lets requests pass without waiting again, if requests were throttled
+	ThrottledUntil time.Time
+	// OpenUntil is the time the circuit breaker sends a trial request again, if it is open
+	OpenUntil time.Time
+	// LastError is the failure which opened the circuit
+	LastError error
+}
+
+// Throttled returns true if requests are currently rejected by the rate limit
+func (s State) Throttled(now time.Time) bool {
+	return now.Before(s.ThrottledUntil)
+}
+
+// Open returns true if requests are currently rejected by the circuit breaker
+func (s State) Open(now time.Time) bool {
+	return now.Before(s.OpenUntil)
+}
+
+// Error is returned for requests the Guard didn't send. It is wrapped into an apierror.Error,
+// classified as rate limited if the rate limit was exceeded and as transient if the circuit is open
+type Error struct {
+	retryAfter time.Duration
+	message    string
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// RetryAfter returns the time after which the request may be sent
+func (e *Error) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// RetryAfter returns the delay after which a request rejected by a Guard may be retried. It returns false for all other errors
+func RetryAfter(err error) (time.Duration, bool) {
+	var guardErr *Error
+	if errors.As(err, &guardErr) {
+		return guardErr.retryAfter, true
+	}
+
+	return 0, false
+}
+
+// Guard applies the rate limit and circuit breaker of a region to all requests sent through it
+type Guard struct {
+	region string
+
+	mu             sync.Mutex
+	opts           Options
+	limiter        *rate.Limiter
+	failures       int
+	openUntil      time.Time
+	trial          bool
+	throttledUntil time.Time
+	lastErr        error
+}
+
+func newGuard(region string, opts Options) *Guard {
+	g := &Guard{region: region}
+	g.configure(opts)
+
+	return g
+}
+
+// configure applies changed options, keeping the state of the rate limit and circuit breaker
+func (g *Guard) configure(opts Options) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.limiter != nil && g.opts == opts {
+		return
+	}
+
+	//A changed rate limit starts with a full bucket
+	if g.limiter == nil || g.opts.RequestsPerSecond != opts.RequestsPerSecond || g.opts.Burst != opts.Burst {
+		limit := rate.Inf
+		if opts.RequestsPerSecond > 0 {
+			limit = rate.Limit(opts.RequestsPerSecond)
+		}
+
+		g.limiter = rate.NewLimiter(limit, opts.Burst)
+	}
+
+	g.opts = opts
+}
+
+// State returns a snapshot of the rate limit and circuit breaker
+func (g *Guard) State() State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return State{ThrottledUntil: g.throttledUntil, OpenUntil: g.openUntil, LastError: g.lastErr}
+}
+
+// Do sends the request unless the circuit is open, waiting for the rate limit up to MaxWait.
+// Requests which weren't sent fail with an Error
+func (g *Guard) Do(ctx context.Context, request func() error) error {
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+
+	err := request()
+	g.record(err)
+
+	return err
+}
+
+func (g *Guard) acquire(ctx context.Context) error {
+	g.mu.Lock()
+	now := time.Now()
+
+	if now.Before(g.openUntil) {
+		g.mu.Unlock()
+		return g.unavailable(g.openUntil.Sub(now))
+	}
+
+	//Once the circuit is half open, a single trial request decides whether it closes again
+	halfOpen := !g.openUntil.IsZero()
+	if halfOpen && g.trial {
+		g.mu.Unlock()
+		return g.unavailable(trialRetryAfter)
+	}
+
+	reservation := g.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if !reservation.OK() || delay > g.opts.MaxWait {
+		reservation.CancelAt(now)
+		if !reservation.OK() {
+			delay = g.opts.MaxWait
+		}
+
+		if !now.Before(g.throttledUntil) {
+			changed(g.region)
+		}
+
+		g.throttledUntil = now.Add(delay)
+		g.mu.Unlock()
+		return &apierror.Error{Class: apierror.RateLimit, Err: &Error{retryAfter: delay, message: fmt.Sprintf("requests to region %s are throttled by its rate limit, retrying in %s", g.region, delay.Round(time.Millisecond))}}
+	}
+
+	if halfOpen {
+		g.trial = true
+	}
+	g.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		g.mu.Lock()
+		g.trial = false
+		g.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// record updates the circuit breaker with the outcome of a request
+func (g *Guard) record(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.trial = false
+
+	if !failed(err) {
+		if !g.openUntil.IsZero() {
+			changed(g.region)
+		}
+
+		g.failures = 0
+		g.openUntil = time.Time{}
+		g.lastErr = nil
+		return
+	}
+
+	g.failures++
+	halfOpen := !g.openUntil.IsZero()
+	if g.opts.FailureThreshold <= 0 || (!halfOpen && g.failures < g.opts.FailureThreshold) {
+		return
+	}
+
+	g.openUntil = time.Now().Add(g.opts.OpenDuration)
+	g.lastErr = err
+	changed(g.region)
+}
+
+func (g *Guard) unavailable(retryAfter time.Duration) error {
+	return &apierror.Error{Class: apierror.Transient, Err: &Error{retryAfter: retryAfter, message: fmt.Sprintf("API of region %s is unavailable, circuit breaker retries in %s: %s", g.region, retryAfter.Round(time.Second), g.lastErr)}}
+}
+
+// failed returns whether the error indicates a failing API. Rejected requests and cancelled contexts don't count
+func failed(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	switch apierror.ClassOf(err) {
+	case apierror.Transient, apierror.RateLimit:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	guardsMu sync.Mutex
+	guards   = map[string]*Guard{}
+	onChange func(region string)
+)
+
+// For returns the Guard of the region, applying the options if they changed
+func For(region string, opts Options) *Guard {
+	guardsMu.Lock()
+	g, ok := guards[region]
+	if !ok {
+		g = newGuard(region, opts)
+		guards[region] = g
+	}
+	guardsMu.Unlock()
+
+	g.configure(opts)
+	return g
+}
+
+// Forget drops the Guard of a deleted region
+func Forget(region string) {
+	guardsMu.Lock()
+	defer guardsMu.Unlock()
+
+	delete(guards, region)
+}
+
+// OnChange registers the function called whenever a region gets throttled or its circuit opens or closes.
+// It is called while the Guard is locked and must not block
+func OnChange(fn func(region string)) {
+	guardsMu.Lock()
+	defer guardsMu.Unlock()
+
+	onChange = fn
+}
+
+func changed(region string) {
+	guardsMu.Lock()
+	fn := onChange
+	guardsMu.Unlock()
+
+	if fn != nil {
+		fn(region)
+	}
+}
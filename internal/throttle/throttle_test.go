@@ -0,0 +1,83 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pluscontainer/pco-reseller-operator/internal/apierror"
+)
+
+func TestRateLimit(t *testing.T) {
+	g := newGuard("rate-limit", Options{RequestsPerSecond: 1, Burst: 2, MaxWait: 0})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := g.Do(ctx, func() error { return nil }); err != nil {
+			t.Fatalf("request %d within burst failed: %v", i, err)
+		}
+	}
+
+	err := g.Do(ctx, func() error {
+		t.Fatal("throttled request was sent")
+		return nil
+	})
+
+	retryAfter, ok := RetryAfter(err)
+	if !ok || retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("expected request to be throttled for up to a second, got %v (%v)", retryAfter, err)
+	}
+	if apierror.ClassOf(err) != apierror.RateLimit {
+		t.Errorf("expected throttled request to be classified as rate limited, got %s", apierror.ClassOf(err))
+	}
+	if !g.State().Throttled(time.Now()) {
+		t.Error("expected state to be throttled")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	g := newGuard("circuit-breaker", Options{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond})
+	ctx := context.Background()
+	failure := apierror.New(apierror.Transient, "unavailable")
+
+	//Rejected requests don't indicate a failing API
+	for i := 0; i < 3; i++ {
+		_ = g.Do(ctx, func() error { return apierror.New(apierror.Validation, "invalid") })
+	}
+	if g.State().Open(time.Now()) {
+		t.Fatal("expected invalid requests not to open the circuit")
+	}
+
+	for i := 0; i < 2; i++ {
+		_ = g.Do(ctx, func() error { return failure })
+	}
+	if !g.State().Open(time.Now()) {
+		t.Fatal("expected circuit to be open after reaching the failure threshold")
+	}
+
+	err := g.Do(ctx, func() error {
+		t.Fatal("request was sent while the circuit is open")
+		return nil
+	})
+	if _, ok := RetryAfter(err); !ok || apierror.ClassOf(err) != apierror.Transient {
+		t.Fatalf("expected request to be stopped as transient error, got %v", err)
+	}
+
+	//A failed trial request opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	if err := g.Do(ctx, func() error { return failure }); !errors.Is(err, failure) {
+		t.Fatalf("expected trial request to be sent, got %v", err)
+	}
+	if !g.State().Open(time.Now()) {
+		t.Fatal("expected failed trial request to open the circuit again")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := g.Do(ctx, func() error { return nil }); err != nil {
+		t.Fatalf("expected trial request to succeed, got %v", err)
+	}
+	if state := g.State(); state.Open(time.Now()) || !state.OpenUntil.IsZero() {
+		t.Errorf("expected successful trial request to close the circuit, got %+v", state)
+	}
+}
@@ -0,0 +1,58 @@
+package throttle
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type transport struct {
+	guard *Guard
+	next  http.RoundTripper
+}
+
+// NewTransport returns a http.RoundTripper sending every request through the guard.
+// Server errors and throttled responses count as failures of the circuit breaker
+func NewTransport(guard *Guard, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{guard: guard, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	sent := false
+	err := t.guard.Do(req.Context(), func() error {
+		var err error
+		sent = true
+		resp, err = t.next.RoundTrip(req)
+		if err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) {
+			return statusCodeError(resp.StatusCode)
+		}
+
+		return err
+	})
+
+	if _, ok := err.(statusCodeError); ok {
+		return resp, nil
+	}
+
+	//RoundTrippers have to close the body of requests they didn't send
+	if !sent && req.Body != nil {
+		req.Body.Close()
+	}
+
+	return resp, err
+}
+
+// statusCodeError is only used to record unsuccessful responses as failures
+type statusCodeError int
+
+func (e statusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", int(e))
+}
+
+func (e statusCodeError) GetStatusCode() int {
+	return int(e)
+}
@@ -0,0 +1,122 @@
+// Package tracing configures the optional OpenTelemetry tracing of the operator
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	tracerName  = "github.com/pluscontainer/pco-reseller-operator"
+	serviceName = "pco-reseller-operator"
+)
+
+// Attributes attached to the spans
+const (
+	RegionKey                  = attribute.Key("pco.region")
+	NamespaceKey               = attribute.Key("k8s.namespace.name")
+	NameKey                    = attribute.Key("k8s.object.name")
+	KindKey                    = attribute.Key("k8s.object.kind")
+	OperationKey               = attribute.Key("pco.operation")
+	OpenStackProjectIDKey      = attribute.Key("openstack.project.id")
+	OpenStackUserIDKey         = attribute.Key("openstack.user.id")
+	ApplicationCredentialIDKey = attribute.Key("openstack.application_credential.id")
+)
+
+// Options configure the exporter of the traces
+type Options struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector. Tracing is disabled if empty
+	Endpoint string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// SampleRatio is the ratio of reconciles which get traced
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider exporting to the configured OTLP endpoint.
+// The returned function flushes and stops the exporter
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio %f must be between 0 and 1", opts.SampleRatio)
+	}
+
+	exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span using the global tracer provider, which doesn't record anything unless tracing is set up
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records the error (if any) and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// SetAttributes adds the attributes to the span within ctx, e.g. once the OpenStack ids are known
+func SetAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
+
+// Phase runs f within a child span of the span within ctx
+func Phase(ctx context.Context, name string, f func(context.Context) error, attributes ...attribute.KeyValue) error {
+	ctx, span := Start(ctx, name, attributes...)
+	err := f(ctx)
+	End(span, err)
+
+	return err
+}
+
+// ObjectAttributes returns the attributes identifying a Kubernetes object
+func ObjectAttributes(kind string, name types.NamespacedName) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{KindKey.String(kind), NameKey.String(name.Name)}
+	if name.Namespace != "" {
+		attributes = append(attributes, NamespaceKey.String(name.Namespace))
+	}
+
+	return attributes
+}
@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func setupInMemoryExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, k := range spans {
+		if k.Name == name {
+			return k
+		}
+	}
+
+	t.Fatalf("span %s not found within %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func hasAttribute(span tracetest.SpanStub, kv attribute.KeyValue) bool {
+	for _, k := range span.Attributes {
+		if k == kv {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestPhase(t *testing.T) {
+	exporter := setupInMemoryExporter(t)
+
+	errFailed := errors.New("failed")
+	ctx, span := Start(context.Background(), "UserProjectBinding.Reconcile", ObjectAttributes("UserProjectBinding", types.NamespacedName{Namespace: "default", Name: "sample"})...)
+	err := Phase(ctx, "UserProjectBinding.Finalize", func(ctx context.Context) error {
+		SetAttributes(ctx, OpenStackUserIDKey.String("user-id"))
+		return errFailed
+	})
+	End(span, err)
+
+	if err != errFailed {
+		t.Fatalf("expected error of phase to be returned, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	reconcile := spanByName(t, spans, "UserProjectBinding.Reconcile")
+	finalize := spanByName(t, spans, "UserProjectBinding.Finalize")
+
+	if finalize.Parent.SpanID() != reconcile.SpanContext.SpanID() {
+		t.Errorf("expected phase to be a child of the reconcile span")
+	}
+	if finalize.Status.Code != codes.Error || reconcile.Status.Code != codes.Error {
+		t.Errorf("expected error status, got %v and %v", finalize.Status.Code, reconcile.Status.Code)
+	}
+	if !hasAttribute(finalize, OpenStackUserIDKey.String("user-id")) {
+		t.Errorf("expected user id attribute on phase span")
+	}
+	if !hasAttribute(reconcile, NamespaceKey.String("default")) || !hasAttribute(reconcile, NameKey.String("sample")) {
+		t.Errorf("expected object attributes on reconcile span, got %v", reconcile.Attributes)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	exporter := setupInMemoryExporter(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/roles" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := http.Client{Transport: NewTransport("keystone", "region-a", nil)}
+	ctx, parent := Start(context.Background(), "parent")
+
+	for _, path := range []string{"/v3/roles", "/v3/users/missing"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	for _, k := range spans[:2] {
+		if k.Name != "keystone GET" {
+			t.Errorf("unexpected span name %s", k.Name)
+		}
+		if k.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected request span to be a child of the parent span")
+		}
+		if !hasAttribute(k, RegionKey.String("region-a")) {
+			t.Errorf("expected region attribute, got %v", k.Attributes)
+		}
+	}
+
+	if spans[0].Status.Code == codes.Error {
+		t.Errorf("expected successful request not to be marked as error")
+	}
+	if spans[1].Status.Code != codes.Error {
+		t.Errorf("expected failed request to be marked as error")
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+type transport struct {
+	api    string
+	region string
+	next   http.RoundTripper
+}
+
+// NewTransport returns a http.RoundTripper creating a span for every request of the api within the region.
+// Spans are children of the span within the context of the request
+func NewTransport(api string, region string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{api: api, region: region, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), fmt.Sprintf("%s %s", t.api, req.Method),
+		RegionKey.String(t.region),
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLPath(req.URL.Path),
+	)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err == nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			End(span, fmt.Errorf("unexpected status code %d", resp.StatusCode))
+			return resp, nil
+		}
+	}
+
+	End(span, err)
+	return resp, err
+}
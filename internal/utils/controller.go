@@ -3,8 +3,10 @@ package utils
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
 	"os"
+	"regexp"
 
 	v1 "k8s.io/api/core/v1"
 	k8errors "k8s.io/apimachinery/pkg/api/errors"
@@ -15,26 +17,72 @@ import (
 
 const (
 	controllerIdSecretName = "pco-reseller-operator-id"
-	controllerIdSecretKey  = "id"
+	// controllerIdReplacementSecretName holds the new controller id while the immutable secret gets recreated
+	controllerIdReplacementSecretName = "pco-reseller-operator-id-replacement"
+	controllerIdSecretKey             = "id"
 )
 
 var errControllerIdNotSet = errors.New("controller id not set")
 
-// ControllerIdentifier fetches the pco-reseller-operator-id secret and retrieves the unique operator id from it
-func ControllerIdentifier(ctx context.Context, r client.Client) (*string, error) {
-	controllerNamespace := os.Getenv("CONTROLLER_NAMESPACE")
+// controllerIdPattern restricts controller ids to values usable within OpenStack project names and the domain of user e-mail addresses
+var controllerIdPattern = regexp.MustCompile(`^[a-z0-9]{3,16}$`)
 
+// ValidateControllerIdentifier checks if the controller id may be used to name OpenStack projects and users
+func ValidateControllerIdentifier(controllerId string) error {
+	if !controllerIdPattern.MatchString(controllerId) {
+		return fmt.Errorf("controller id %q must consist of 3 to 16 lowercase letters and digits", controllerId)
+	}
+
+	return nil
+}
+
+// ControllerIdentifier fetches the pco-reseller-operator-id secret and retrieves the unique operator id from it.
+// The secret is created at startup by EnsureControllerIdentifier
+func ControllerIdentifier(ctx context.Context, r client.Reader) (*string, error) {
 	controllerIdentifierSecret := &v1.Secret{}
-	err := r.Get(ctx, types.NamespacedName{
-		Name:      controllerIdSecretName,
-		Namespace: controllerNamespace,
-	}, controllerIdentifierSecret)
+	if err := r.Get(ctx, controllerIdSecretNamespacedName(), controllerIdentifierSecret); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
-		if !k8errors.IsNotFound(err) {
-			return nil, err
+	controllerIdentifier := string(controllerIdentifierSecret.Data[controllerIdSecretKey])
+
+	if IsEmpty(controllerIdentifier) {
+		return nil, errControllerIdNotSet
+	}
+
+	return &controllerIdentifier, nil
+}
+
+// EnsureControllerIdentifier validates the configured controller id and stores it within the pco-reseller-operator-id secret.
+// If none is configured, the stored one is kept or a random one gets generated on the first start.
+// A configured id differing from the stored one is rejected, as the names of all OpenStack projects and users depend on it
+func EnsureControllerIdentifier(ctx context.Context, c client.Client, configured string) (string, error) {
+	if configured != "" {
+		if err := ValidateControllerIdentifier(configured); err != nil {
+			return "", err
+		}
+	}
+
+	//The rekey command may have been interrupted while recreating the secret
+	if err := ResumeControllerIdentifierReplacement(ctx, c); err != nil {
+		return "", err
+	}
+
+	stored, err := ControllerIdentifier(ctx, c)
+	if err != nil && !k8errors.IsNotFound(err) {
+		return "", err
+	}
+
+	if stored != nil {
+		if configured != "" && configured != *stored {
+			return "", fmt.Errorf("configured controller id %s differs from stored controller id %s, use the rekey command to migrate", configured, *stored)
 		}
 
+		return *stored, ValidateControllerIdentifier(*stored)
+	}
+
+	controllerIdentifier := configured
+	if controllerIdentifier == "" {
 		//Generate random controller id
 		letterRunes := []rune("abcdefghijklmnopqrstuvwxyz")
 
@@ -43,29 +91,101 @@ func ControllerIdentifier(ctx context.Context, r client.Client) (*string, error)
 			randomCharacters[i] = letterRunes[rand.Intn(len(letterRunes))]
 		}
 
-		isTrue := true
-
-		controllerIdentifierSecret = &v1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      controllerIdSecretName,
-				Namespace: controllerNamespace,
-			},
-			StringData: map[string]string{
-				controllerIdSecretKey: string(randomCharacters),
-			},
-			Immutable: &isTrue,
+		controllerIdentifier = string(randomCharacters)
+	}
+
+	if err := createControllerIdentifierSecret(ctx, c, controllerIdSecretName, controllerIdentifier); err != nil {
+		return "", err
+	}
+
+	return controllerIdentifier, nil
+}
+
+// ReplaceControllerIdentifier replaces the stored controller id. The secret is immutable and gets recreated,
+// so the new id is stored within a replacement secret first, from which an interrupted replacement gets completed
+func ReplaceControllerIdentifier(ctx context.Context, c client.Client, controllerId string) error {
+	if err := ValidateControllerIdentifier(controllerId); err != nil {
+		return err
+	}
+
+	if err := createControllerIdentifierSecret(ctx, c, controllerIdReplacementSecretName, controllerId); err != nil {
+		if !k8errors.IsAlreadyExists(err) {
+			return err
+		}
+
+		pending, err := readControllerIdentifier(ctx, c, controllerIdReplacementSecretName)
+		if err != nil {
+			return err
 		}
 
-		if err := r.Create(ctx, controllerIdentifierSecret); err != nil {
-			return nil, err
+		if pending != controllerId {
+			return fmt.Errorf("replacement of the controller id by %s is pending", pending)
 		}
 	}
 
-	controllerIdentifier := string(controllerIdentifierSecret.Data[controllerIdSecretKey])
+	return completeControllerIdentifierReplacement(ctx, c, controllerId)
+}
 
-	if IsEmpty(controllerIdentifier) {
-		return nil, errControllerIdNotSet
+// ResumeControllerIdentifierReplacement completes a replacement of the controller id which got interrupted, if there is one
+func ResumeControllerIdentifierReplacement(ctx context.Context, c client.Client) error {
+	pending, err := readControllerIdentifier(ctx, c, controllerIdReplacementSecretName)
+	if err != nil {
+		return client.IgnoreNotFound(err)
 	}
 
-	return &controllerIdentifier, nil
+	return completeControllerIdentifierReplacement(ctx, c, pending)
+}
+
+func completeControllerIdentifierReplacement(ctx context.Context, c client.Client, controllerId string) error {
+	if err := c.Delete(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      controllerIdSecretName,
+		Namespace: ControllerNamespace(),
+	}}); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	if err := createControllerIdentifierSecret(ctx, c, controllerIdSecretName, controllerId); err != nil {
+		return err
+	}
+
+	return client.IgnoreNotFound(c.Delete(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      controllerIdReplacementSecretName,
+		Namespace: ControllerNamespace(),
+	}}))
+}
+
+func createControllerIdentifierSecret(ctx context.Context, c client.Client, name string, controllerId string) error {
+	isTrue := true
+
+	return c.Create(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ControllerNamespace(),
+		},
+		Data: map[string][]byte{
+			controllerIdSecretKey: []byte(controllerId),
+		},
+		Immutable: &isTrue,
+	})
+}
+
+func readControllerIdentifier(ctx context.Context, r client.Reader, name string) (string, error) {
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ControllerNamespace()}, secret); err != nil {
+		return "", err
+	}
+
+	return string(secret.Data[controllerIdSecretKey]), nil
+}
+
+// ControllerNamespace returns the namespace the operator is running in
+func ControllerNamespace() string {
+	return os.Getenv("CONTROLLER_NAMESPACE")
+}
+
+func controllerIdSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      controllerIdSecretName,
+		Namespace: ControllerNamespace(),
+	}
 }
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateControllerIdentifier(t *testing.T) {
+	tests := []struct {
+		name         string
+		controllerId string
+		valid        bool
+	}{
+		{"letters", "abcdef", true},
+		{"letters and digits", "prod01", true},
+		{"minimum length", "abc", true},
+		{"maximum length", "abcdefghijklmnop", true},
+		{"too short", "ab", false},
+		{"too long", "abcdefghijklmnopq", false},
+		{"empty", "", false},
+		{"uppercase", "Prod01", false},
+		{"hyphen", "prod-01", false},
+		{"dot", "prod.01", false},
+		{"at sign", "prod@01", false},
+	}
+
+	for _, k := range tests {
+		t.Run(k.name, func(t *testing.T) {
+			if err := ValidateControllerIdentifier(k.controllerId); (err == nil) != k.valid {
+				t.Errorf("ValidateControllerIdentifier(%q) = %v, want valid %t", k.controllerId, err, k.valid)
+			}
+		})
+	}
+}
+
+func TestReplaceControllerIdentifier(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("CONTROLLER_NAMESPACE", "operator")
+
+	controllerIdSecret := func(name string, controllerId string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "operator", Name: name},
+			Data:       map[string][]byte{controllerIdSecretKey: []byte(controllerId)},
+		}
+	}
+
+	expectReplacementDeleted := func(t *testing.T, c client.Client) {
+		t.Helper()
+
+		err := c.Get(ctx, types.NamespacedName{Namespace: "operator", Name: controllerIdReplacementSecretName}, &v1.Secret{})
+		if !k8errors.IsNotFound(err) {
+			t.Errorf("expected the replacement secret to be deleted, got %v", err)
+		}
+	}
+
+	t.Run("replaces the stored id", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithObjects(controllerIdSecret(controllerIdSecretName, "abcdef")).Build()
+
+		if err := ReplaceControllerIdentifier(ctx, c, "prod01"); err != nil {
+			t.Fatal(err)
+		}
+
+		if stored, err := ControllerIdentifier(ctx, c); err != nil || *stored != "prod01" {
+			t.Errorf("stored controller id = %v, %v, want prod01", stored, err)
+		}
+
+		expectReplacementDeleted(t, c)
+	})
+
+	t.Run("completes an interrupted replacement", func(t *testing.T) {
+		//The stored secret got deleted, but its replacement wasn't created yet
+		c := fake.NewClientBuilder().WithObjects(controllerIdSecret(controllerIdReplacementSecretName, "prod01")).Build()
+
+		if err := ResumeControllerIdentifierReplacement(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+
+		if stored, err := ControllerIdentifier(ctx, c); err != nil || *stored != "prod01" {
+			t.Errorf("stored controller id = %v, %v, want prod01", stored, err)
+		}
+
+		expectReplacementDeleted(t, c)
+	})
+
+	t.Run("rejects another id while a replacement is pending", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithObjects(
+			controllerIdSecret(controllerIdSecretName, "abcdef"),
+			controllerIdSecret(controllerIdReplacementSecretName, "prod01"),
+		).Build()
+
+		if err := ReplaceControllerIdentifier(ctx, c, "prod02"); err == nil {
+			t.Error("expected the replacement to be rejected")
+		}
+
+		if stored, err := ControllerIdentifier(ctx, c); err != nil || *stored != "abcdef" {
+			t.Errorf("stored controller id = %v, %v, want abcdef", stored, err)
+		}
+	})
+}
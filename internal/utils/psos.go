@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	"github.com/pluscontainer/pco-reseller-cli/pkg/openapi"
-	"github.com/pluscontainer/pco-reseller-cli/pkg/psos"
+	"github.com/pluscontainer/pco-reseller-operator/internal/reseller"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -17,8 +17,21 @@ var ErrOpenStackProjectNotFound = errors.New("openstack project not found")
 // ErrOpenStackUserNotFound is returned if the user is not found
 var ErrOpenStackUserNotFound = errors.New("openstack user not found")
 
-// GetOpenStackProject returns the openstack project from the API
-func GetOpenStackProject(ctx context.Context, client *psos.PsOpenstackClient, openStackProjectName string) (*openapi.ProjectCreatedResponse, error) {
+// GetOpenStackProject returns the openstack project from the inventory of the client or else from the API
+func GetOpenStackProject(ctx context.Context, client reseller.ResellerClient, openStackProjectName string) (*openapi.ProjectCreatedResponse, error) {
+	if finder, ok := client.(reseller.Finder); ok {
+		project, found, err := finder.FindProject(ctx, openStackProjectName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, ErrOpenStackProjectNotFound
+		}
+
+		return project, nil
+	}
+
 	existingProjects, err := client.GetProjects(ctx)
 	if err != nil {
 		return nil, err
@@ -34,8 +47,21 @@ func GetOpenStackProject(ctx context.Context, client *psos.PsOpenstackClient, op
 	return nil, ErrOpenStackProjectNotFound
 }
 
-// GetOpenStackUser returns the openstack user from the API
-func GetOpenStackUser(ctx context.Context, client *psos.PsOpenstackClient, openStackUsername string) (*openapi.CreatedOpenStackUser, error) {
+// GetOpenStackUser returns the openstack user from the inventory of the client or else from the API
+func GetOpenStackUser(ctx context.Context, client reseller.ResellerClient, openStackUsername string) (*openapi.CreatedOpenStackUser, error) {
+	if finder, ok := client.(reseller.Finder); ok {
+		user, found, err := finder.FindUser(ctx, openStackUsername)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, ErrOpenStackUserNotFound
+		}
+
+		return user, nil
+	}
+
 	existingUsers, err := client.GetUsers(ctx)
 	if err != nil {
 		return nil, err
@@ -54,3 +80,24 @@ func GetOpenStackUser(ctx context.Context, client *psos.PsOpenstackClient, openS
 func GetOpenStackProjectName(controllerId string, project types.NamespacedName) string {
 	return fmt.Sprintf("%s-%s-%s", controllerId, project.Namespace, project.Name)
 }
+
+// TrimOpenStackProjectDomain drops the domain the reseller API prepends to the project name on creation
+func TrimOpenStackProjectDomain(openStackProjectName string) string {
+	_, name, found := strings.Cut(openStackProjectName, "-")
+	if !found {
+		return openStackProjectName
+	}
+
+	return name
+}
+
+// IsOpenStackProjectOfController returns true if the project name was templated by GetOpenStackProjectName for the given controller id.
+// The domain prepended by the reseller API is skipped
+func IsOpenStackProjectOfController(controllerId string, openStackProjectName string) bool {
+	return strings.HasPrefix(TrimOpenStackProjectDomain(openStackProjectName), controllerId+"-")
+}
+
+// IsOpenStackUserOfController returns true if the username was templated by User.Mail for the given controller id
+func IsOpenStackUserOfController(controllerId string, openStackUsername string) bool {
+	return strings.HasSuffix(openStackUsername, fmt.Sprintf("@%s.k8s", controllerId))
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestIsOpenStackProjectOfController(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		want    bool
+	}{
+		{"templated", "reseller-abc-ns-project", true},
+		{"other controller", "reseller-xyz-ns-project", false},
+		{"controller id as suffix of the domain", "resellerabc-ns-project", false},
+		{"controller id within the namespace", "reseller-xyz-abc-project", false},
+		{"controller id after a dot", "reseller-team.abc-project", false},
+		{"controller id after an underscore", "reseller-my_abc-project", false},
+		{"controller id as prefix of another id", "reseller-abcd-ns-project", false},
+		{"without domain", "abc-ns-project", false},
+		{"without hyphen", "abc", false},
+	}
+
+	for _, k := range tests {
+		t.Run(k.name, func(t *testing.T) {
+			if got := IsOpenStackProjectOfController("abc", k.project); got != k.want {
+				t.Errorf("IsOpenStackProjectOfController(%q) = %t, want %t", k.project, got, k.want)
+			}
+		})
+	}
+}